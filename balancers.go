@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"errors"
 	"math/rand/v2"
 	"net/url"
@@ -63,26 +64,20 @@ type RandomBalancer struct {
 	chkInterval time.Duration
 	chckFn      HostChecker
 	ch          chan *url.URL
+	observer    HealthObserver
 
-	wg   sync.WaitGroup
-	done chan struct{}
+	wg        sync.WaitGroup
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
-// NewRandomBalancer returns a new RandomBalancer.
+// NewRandomBalancer returns a new RandomBalancer. The RandomBalancer runs background
+// goroutines to check the health of bad hosts, so it must be closed, either by
+// calling Close or by using CloseOnContext, once it is no longer needed.
 func NewRandomBalancer(urls []string, chckFn HostChecker, d time.Duration) (*RandomBalancer, error) {
-	hosts := make(map[string]*Host)
-	for _, s := range urls {
-		u, err := url.Parse(s)
-		if err != nil {
-			return nil, err
-		}
-		if _, ok := hosts[u.String()]; ok {
-			return nil, ErrDuplicateAddresses
-		}
-		hosts[u.String()] = &Host{URL: u, Healthy: true}
-	}
-	if len(hosts) == 0 {
-		return nil, ErrNoHostsAvailable
+	hosts, err := parseHosts(urls)
+	if err != nil {
+		return nil, err
 	}
 	rb := &RandomBalancer{
 		hosts:       hosts,
@@ -98,6 +93,19 @@ func NewRandomBalancer(urls []string, chckFn HostChecker, d time.Duration) (*Ran
 	return rb, nil
 }
 
+// NewRandomBalancerFromNodes returns a new RandomBalancer for the API addresses of
+// the given nodes, such as those returned by ParseNodes. Nodes which are not reachable,
+// or which have no API address, are excluded.
+func NewRandomBalancerFromNodes(nodes []Node, chckFn HostChecker, d time.Duration) (*RandomBalancer, error) {
+	var urls []string
+	for _, n := range nodes {
+		if n.Reachable && n.APIAddr != "" {
+			urls = append(urls, n.APIAddr)
+		}
+	}
+	return NewRandomBalancer(urls, chckFn, d)
+}
+
 // Next returns a random address from the list of addresses it currently
 // considers healthy.
 func (rb *RandomBalancer) Next() (*url.URL, error) {
@@ -113,9 +121,75 @@ func (rb *RandomBalancer) Next() (*url.URL, error) {
 // will not return this address until the RandomBalancer considers it healthy
 // again.
 func (rb *RandomBalancer) MarkBad(u *url.URL) {
+	rb.mu.Lock()
+	var changed bool
+	if h, ok := rb.hosts[u.String()]; ok {
+		changed = h.Healthy
+		h.Healthy = false
+	}
+	observer := rb.observer
+	rb.mu.Unlock()
+	if changed && observer != nil {
+		observer(u, false)
+	}
+}
+
+// SetHealthObserver sets a function to be called each time one of the RandomBalancer's
+// hosts becomes healthy or unhealthy. Pass nil to remove the observer.
+func (rb *RandomBalancer) SetHealthObserver(fn HealthObserver) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	rb.observer = fn
+}
+
+// SetHosts replaces the list of addresses used by the RandomBalancer. Hosts
+// which are in both the old and new lists keep their health status, and new
+// hosts are considered healthy.
+func (rb *RandomBalancer) SetHosts(urls []string) error {
+	hosts, err := parseHosts(urls)
+	if err != nil {
+		return err
+	}
 	rb.mu.Lock()
 	defer rb.mu.Unlock()
-	rb.hosts[u.String()].Healthy = false
+	for k, h := range hosts {
+		if old, ok := rb.hosts[k]; ok {
+			hosts[k] = old
+		} else {
+			h.Healthy = true
+		}
+	}
+	rb.hosts = hosts
+	return nil
+}
+
+// AddHost adds an address to the RandomBalancer. The new host is considered
+// healthy.
+func (rb *RandomBalancer) AddHost(address string) error {
+	u, err := url.Parse(address)
+	if err != nil {
+		return err
+	}
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	if _, ok := rb.hosts[u.String()]; ok {
+		return ErrDuplicateAddresses
+	}
+	rb.hosts[u.String()] = &Host{URL: u, Healthy: true}
+	return nil
+}
+
+// RemoveHost removes an address from the RandomBalancer. It is not an error
+// to remove an address which is not known to the RandomBalancer.
+func (rb *RandomBalancer) RemoveHost(address string) error {
+	u, err := url.Parse(address)
+	if err != nil {
+		return err
+	}
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	delete(rb.hosts, u.String())
+	return nil
 }
 
 // Healthy returns the slice of currently healthy hosts.
@@ -144,27 +218,41 @@ func (rb *RandomBalancer) Bad() []*url.URL {
 	return bad
 }
 
-// Close closes the RandomBalancer. A closed RandomBalancer should not be reused.
+// Close closes the RandomBalancer, stopping its background goroutines. A closed
+// RandomBalancer should not be reused. It is safe to call Close more than once.
 func (rb *RandomBalancer) Close() {
-	close(rb.done)
+	rb.closeOnce.Do(func() { close(rb.done) })
 	rb.wg.Wait()
 }
 
+// CloseOnContext arranges for the RandomBalancer to be closed when ctx is done, so
+// that its background goroutines do not outlive the work it was created for.
+func (rb *RandomBalancer) CloseOnContext(ctx context.Context) {
+	go func() {
+		select {
+		case <-ctx.Done():
+			rb.Close()
+		case <-rb.done:
+		}
+	}()
+}
+
 func (rb *RandomBalancer) checkBadHosts() {
 	defer rb.wg.Done()
 	ticker := time.NewTicker(rb.chkInterval)
+	defer ticker.Stop()
 	for {
 		select {
 		case <-ticker.C:
-			rb.mu.RLock()
-			for _, host := range rb.hosts {
-				if !host.Healthy {
-					if ok := rb.chckFn(host.URL); ok {
-						rb.ch <- host.URL
+			for _, u := range rb.Bad() {
+				if ok := rb.chckFn(u); ok {
+					select {
+					case rb.ch <- u:
+					case <-rb.done:
+						return
 					}
 				}
 			}
-			rb.mu.RUnlock()
 		case <-rb.done:
 			return
 		}
@@ -177,15 +265,199 @@ func (rb *RandomBalancer) markGoodHosts() {
 		select {
 		case u := <-rb.ch:
 			rb.mu.Lock()
+			var changed bool
 			for _, host := range rb.hosts {
 				if host.URL == u {
+					changed = !host.Healthy
 					host.Healthy = true
 					break
 				}
 			}
+			observer := rb.observer
 			rb.mu.Unlock()
+			if changed && observer != nil {
+				observer(u, true)
+			}
 		case <-rb.done:
 			return
 		}
 	}
 }
+
+// RoundRobinBalancer takes a list of addresses and cycles through those it considers
+// healthy, in the order they were supplied, when Next() is called. At the start all
+// supplied addresses are considered healthy. As with RandomBalancer, a client can call
+// MarkBad() to mark an address as unhealthy, and the RoundRobinBalancer periodically
+// checks the health of bad addresses, marking them healthy again once they recover.
+type RoundRobinBalancer struct {
+	mu    sync.Mutex
+	hosts []*Host
+	next  int
+
+	chkInterval time.Duration
+	chckFn      HostChecker
+	observer    HealthObserver
+
+	wg        sync.WaitGroup
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewRoundRobinBalancer returns a new RoundRobinBalancer. The RoundRobinBalancer runs
+// a background goroutine to check the health of bad hosts, so it must be closed once
+// it is no longer needed.
+func NewRoundRobinBalancer(urls []string, chckFn HostChecker, d time.Duration) (*RoundRobinBalancer, error) {
+	byURL, err := parseHosts(urls)
+	if err != nil {
+		return nil, err
+	}
+	hosts := make([]*Host, 0, len(urls))
+	for _, s := range urls {
+		u, _ := url.Parse(s)
+		hosts = append(hosts, byURL[u.String()])
+	}
+	rb := &RoundRobinBalancer{
+		hosts:       hosts,
+		chkInterval: d,
+		chckFn:      chckFn,
+		done:        make(chan struct{}),
+	}
+
+	rb.wg.Add(1)
+	go rb.checkBadHosts()
+	return rb, nil
+}
+
+// NewRoundRobinBalancerFromNodes returns a new RoundRobinBalancer for the API addresses
+// of the given nodes, such as those returned by ParseNodes. Nodes which are not
+// reachable, or which have no API address, are excluded.
+func NewRoundRobinBalancerFromNodes(nodes []Node, chckFn HostChecker, d time.Duration) (*RoundRobinBalancer, error) {
+	var urls []string
+	for _, n := range nodes {
+		if n.Reachable && n.APIAddr != "" {
+			urls = append(urls, n.APIAddr)
+		}
+	}
+	return NewRoundRobinBalancer(urls, chckFn, d)
+}
+
+// Next returns the next healthy address, after the one last returned.
+func (rb *RoundRobinBalancer) Next() (*url.URL, error) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	for range rb.hosts {
+		h := rb.hosts[rb.next]
+		rb.next = (rb.next + 1) % len(rb.hosts)
+		if h.Healthy {
+			return h.URL, nil
+		}
+	}
+	return nil, ErrNoHostsAvailable
+}
+
+// MarkBad marks an address returned by Next() as bad. The RoundRobinBalancer will
+// skip this address until it considers it healthy again.
+func (rb *RoundRobinBalancer) MarkBad(u *url.URL) {
+	rb.mu.Lock()
+	var changed bool
+	for _, h := range rb.hosts {
+		if h.URL.String() == u.String() {
+			changed = h.Healthy
+			h.Healthy = false
+		}
+	}
+	observer := rb.observer
+	rb.mu.Unlock()
+	if changed && observer != nil {
+		observer(u, false)
+	}
+}
+
+// SetHealthObserver sets a function to be called each time one of the
+// RoundRobinBalancer's hosts becomes healthy or unhealthy. Pass nil to remove the
+// observer.
+func (rb *RoundRobinBalancer) SetHealthObserver(fn HealthObserver) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	rb.observer = fn
+}
+
+// Healthy returns the slice of currently healthy hosts, in order.
+func (rb *RoundRobinBalancer) Healthy() []*url.URL {
+	return rb.filter(true)
+}
+
+// Bad returns the slice of currently bad hosts, in order.
+func (rb *RoundRobinBalancer) Bad() []*url.URL {
+	return rb.filter(false)
+}
+
+// Close closes the RoundRobinBalancer, stopping its background goroutine. A closed
+// RoundRobinBalancer should not be reused. It is safe to call Close more than once.
+func (rb *RoundRobinBalancer) Close() {
+	rb.closeOnce.Do(func() { close(rb.done) })
+	rb.wg.Wait()
+}
+
+func (rb *RoundRobinBalancer) filter(healthy bool) []*url.URL {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	var urls []*url.URL
+	for _, h := range rb.hosts {
+		if h.Healthy == healthy {
+			urls = append(urls, h.URL)
+		}
+	}
+	return urls
+}
+
+func (rb *RoundRobinBalancer) checkBadHosts() {
+	defer rb.wg.Done()
+	ticker := time.NewTicker(rb.chkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for _, u := range rb.Bad() {
+				if !rb.chckFn(u) {
+					continue
+				}
+				rb.mu.Lock()
+				var changed bool
+				for _, h := range rb.hosts {
+					if h.URL == u {
+						changed = changed || !h.Healthy
+						h.Healthy = true
+					}
+				}
+				observer := rb.observer
+				rb.mu.Unlock()
+				if changed && observer != nil {
+					observer(u, true)
+				}
+			}
+		case <-rb.done:
+			return
+		}
+	}
+}
+
+// parseHosts parses the given addresses into a map of hosts, keyed by URL. It
+// returns an error if the addresses contain duplicates, or are empty.
+func parseHosts(urls []string) (map[string]*Host, error) {
+	hosts := make(map[string]*Host)
+	for _, s := range urls {
+		u, err := url.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := hosts[u.String()]; ok {
+			return nil, ErrDuplicateAddresses
+		}
+		hosts[u.String()] = &Host{URL: u, Healthy: true}
+	}
+	if len(hosts) == 0 {
+		return nil, ErrNoHostsAvailable
+	}
+	return hosts, nil
+}
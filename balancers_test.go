@@ -0,0 +1,275 @@
+package http
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_RandomBalancer_SetHosts(t *testing.T) {
+	rb, err := NewRandomBalancer([]string{"http://a:4001", "http://b:4001"}, neverHealthy, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer rb.Close()
+
+	rb.MarkBad(mustParseURL("http://b:4001"))
+	if err := rb.SetHosts([]string{"http://b:4001", "http://c:4001"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exp, got := []string{"http://c:4001"}, urlStrings(rb.Healthy()); !slices.Equal(exp, got) {
+		t.Fatalf("expected healthy hosts %v, got %v", exp, got)
+	}
+	if exp, got := []string{"http://b:4001"}, urlStrings(rb.Bad()); !slices.Equal(exp, got) {
+		t.Fatalf("expected bad hosts %v, got %v", exp, got)
+	}
+
+	if err := rb.SetHosts(nil); err != ErrNoHostsAvailable {
+		t.Fatalf("expected ErrNoHostsAvailable, got %v", err)
+	}
+	if err := rb.SetHosts([]string{"http://a:4001", "http://a:4001"}); err != ErrDuplicateAddresses {
+		t.Fatalf("expected ErrDuplicateAddresses, got %v", err)
+	}
+}
+
+func Test_RandomBalancer_AddRemoveHost(t *testing.T) {
+	rb, err := NewRandomBalancer([]string{"http://a:4001"}, neverHealthy, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer rb.Close()
+
+	if err := rb.AddHost("http://b:4001"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := rb.AddHost("http://b:4001"); err != ErrDuplicateAddresses {
+		t.Fatalf("expected ErrDuplicateAddresses, got %v", err)
+	}
+	if exp, got := []string{"http://a:4001", "http://b:4001"}, urlStrings(rb.Healthy()); !slices.Equal(exp, got) {
+		t.Fatalf("expected healthy hosts %v, got %v", exp, got)
+	}
+
+	if err := rb.RemoveHost("http://a:4001"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := rb.RemoveHost("http://z:4001"); err != nil {
+		t.Fatalf("unexpected error removing unknown host: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		u, err := rb.Next()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if exp, got := "http://b:4001", u.String(); exp != got {
+			t.Fatalf("expected %s, got %s", exp, got)
+		}
+	}
+
+	// Marking a removed host as bad is a no-op.
+	rb.MarkBad(mustParseURL("http://a:4001"))
+}
+
+func Test_RandomBalancer_ConcurrentUpdates(t *testing.T) {
+	rb, err := NewRandomBalancer([]string{"http://a:4001"}, func(*url.URL) bool { return true }, time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer rb.Close()
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if u, err := rb.Next(); err == nil {
+					rb.MarkBad(u)
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 100; i++ {
+		rb.AddHost("http://b:4001")
+		rb.SetHosts([]string{"http://a:4001", "http://c:4001"})
+		rb.RemoveHost("http://c:4001")
+	}
+	close(done)
+	wg.Wait()
+}
+
+func Test_RoundRobinBalancer(t *testing.T) {
+	var recovered atomic.Bool
+	chk := func(*url.URL) bool { return recovered.Load() }
+	rb, err := NewRoundRobinBalancer([]string{"http://a:4001", "http://b:4001", "http://c:4001"}, chk, time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer rb.Close()
+
+	next := func() string {
+		t.Helper()
+		u, err := rb.Next()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return u.String()
+	}
+	var got []string
+	for range 4 {
+		got = append(got, next())
+	}
+	if exp := []string{"http://a:4001", "http://b:4001", "http://c:4001", "http://a:4001"}; !slices.Equal(exp, got) {
+		t.Fatalf("expected %v, got %v", exp, got)
+	}
+
+	rb.MarkBad(mustParseURL("http://c:4001"))
+	got = got[:0]
+	for range 3 {
+		got = append(got, next())
+	}
+	if exp := []string{"http://b:4001", "http://a:4001", "http://b:4001"}; !slices.Equal(exp, got) {
+		t.Fatalf("expected bad host to be skipped, got %v", got)
+	}
+
+	rb.MarkBad(mustParseURL("http://a:4001"))
+	rb.MarkBad(mustParseURL("http://b:4001"))
+	if _, err := rb.Next(); err != ErrNoHostsAvailable {
+		t.Fatalf("expected ErrNoHostsAvailable, got %v", err)
+	}
+
+	recovered.Store(true)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(rb.Bad()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for hosts to recover, bad: %v", urlStrings(rb.Bad()))
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if exp, got := []string{"http://a:4001", "http://b:4001", "http://c:4001"}, urlStrings(rb.Healthy()); !slices.Equal(exp, got) {
+		t.Fatalf("expected all hosts healthy, got %v", got)
+	}
+
+	if _, err := NewRoundRobinBalancer([]string{"http://a:4001", "http://a:4001"}, chk, time.Hour); err != ErrDuplicateAddresses {
+		t.Fatalf("expected ErrDuplicateAddresses, got %v", err)
+	}
+}
+
+func neverHealthy(*url.URL) bool {
+	return false
+}
+
+func mustParseURL(s string) *url.URL {
+	u, err := url.Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+func urlStrings(urls []*url.URL) []string {
+	s := make([]string, len(urls))
+	for i, u := range urls {
+		s[i] = u.String()
+	}
+	sort.Strings(s)
+	return s
+}
+
+func Test_RandomBalancer_CloseOnContext(t *testing.T) {
+	rb, err := NewRandomBalancer([]string{"http://a:4001"}, neverHealthy, time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	rb.CloseOnContext(ctx)
+	cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		rb.wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for balancer goroutines to stop")
+	}
+
+	// Closing again, explicitly, must be safe.
+	rb.Close()
+}
+
+func Test_NewRandomBalancerFromNodes(t *testing.T) {
+	nodes, err := ParseNodes([]byte(`{"nodes": [
+		{"id": "1", "api_addr": "http://a:4001", "addr": "a:4002", "voter": true, "reachable": true, "leader": true},
+		{"id": "2", "api_addr": "http://b:4001", "addr": "b:4002", "voter": true, "reachable": false, "error": "connection refused"},
+		{"id": "3", "api_addr": "http://c:4001", "addr": "c:4002", "voter": false, "reachable": true}
+	]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rb, err := NewRandomBalancerFromNodes(nodes, neverHealthy, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer rb.Close()
+	if exp, got := []string{"http://a:4001", "http://c:4001"}, urlStrings(rb.Healthy()); !slices.Equal(exp, got) {
+		t.Fatalf("expected hosts %v, got %v", exp, got)
+	}
+
+	if _, err := NewRandomBalancerFromNodes(nodes[1:2], neverHealthy, time.Hour); err != ErrNoHostsAvailable {
+		t.Fatalf("expected ErrNoHostsAvailable with no reachable nodes, got %v", err)
+	}
+}
+
+func Test_ParseNodes_Role(t *testing.T) {
+	nodes, err := ParseNodes([]byte(`{"nodes": [
+		{"id": "1", "api_addr": "http://a:4001", "addr": "a:4002", "voter": true, "reachable": true, "leader": true},
+		{"id": "2", "api_addr": "http://b:4001", "addr": "b:4002", "voter": true, "reachable": true},
+		{"id": "3", "api_addr": "http://c:4001", "addr": "c:4002", "voter": false, "reachable": true},
+		{"id": "4", "api_addr": "http://d:4001", "addr": "d:4002", "voter": true, "reachable": false, "error": "connection refused"}
+	]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exp := []NodeRole{NodeRoleLeader, NodeRoleFollower, NodeRoleNonVoter, NodeRoleFollower}
+	if len(nodes) != len(exp) {
+		t.Fatalf("expected %d nodes, got %d", len(exp), len(nodes))
+	}
+	for i, n := range nodes {
+		if n.Role != exp[i] {
+			t.Fatalf("expected node %s to have role %s, got %s", n.ID, exp[i], n.Role)
+		}
+	}
+	if exp, got := "nonvoter", NodeRoleNonVoter.String(); exp != got {
+		t.Fatalf("expected %s, got %s", exp, got)
+	}
+}
+
+func Test_ParseNodes_Legacy(t *testing.T) {
+	nodes, err := ParseNodes([]byte(`{
+		"2": {"api_addr": "http://b:4001", "addr": "b:4002", "reachable": true},
+		"1": {"api_addr": "http://a:4001", "addr": "a:4002", "reachable": true, "leader": true}
+	}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(nodes) != 2 || nodes[0].ID != "1" || nodes[1].ID != "2" || !nodes[0].Leader {
+		t.Fatalf("unexpected nodes: %+v", nodes)
+	}
+	if nodes[0].Role != NodeRoleLeader {
+		t.Fatalf("expected legacy leader to have role leader, got %s", nodes[0].Role)
+	}
+}
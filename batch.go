@@ -0,0 +1,129 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// defaultBatchMaxStatements is the number of statements ExecuteBatch sends in each
+// request if BatchOptions.MaxStatements is not set.
+const defaultBatchMaxStatements = 1000
+
+// BatchOptions configures how ExecuteBatch sends rows to the node.
+type BatchOptions struct {
+	// ExecuteOptions configures each request sent to /db/execute. If Transaction
+	// is set, the statements of each request are executed in a transaction, but
+	// separate requests are not executed atomically.
+	ExecuteOptions
+
+	// MaxStatements is the maximum number of statements sent in each request. If
+	// zero, 1000 is used.
+	MaxStatements int
+
+	// MaxBytes is the maximum size, in bytes, of the JSON encoding of the statements
+	// sent in each request. If zero, there is no limit. A statement larger than
+	// MaxBytes is sent in a request of its own.
+	MaxBytes int
+}
+
+// BatchResult summarizes the requests sent by ExecuteBatch.
+type BatchResult struct {
+	// RowsAffected is the total number of rows affected by the statements executed.
+	RowsAffected int64
+
+	// Requests is the number of requests sent to the node.
+	Requests int
+}
+
+// ExecuteBatch executes the parameterized statement sql once for each element of
+// rows, which holds the positional parameters for that execution. The statements are
+// sent in as many requests to /db/execute as needed to respect opts.MaxStatements and
+// opts.MaxBytes, one after another. opts may be nil, in which case default options are
+// used.
+//
+// If a request fails, or a statement returns an error, no further requests are sent.
+// The error is returned, along with a result describing the requests sent so far. An
+// error returned for a statement is a *StatementError whose Index is the index of the
+// row in rows.
+func (c *Client) ExecuteBatch(ctx context.Context, sql string, rows [][]any, opts *BatchOptions) (*BatchResult, error) {
+	var o BatchOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.MaxStatements < 0 || o.MaxBytes < 0 {
+		return nil, errors.New("batch limits must not be negative")
+	}
+	if o.MaxStatements == 0 {
+		o.MaxStatements = defaultBatchMaxStatements
+	}
+
+	var br BatchResult
+	var chunk SQLStatements
+	var chunkBytes, first int
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		br.Requests++
+		er, err := c.Execute(ctx, chunk, &o.ExecuteOptions)
+		if er != nil {
+			for _, r := range er.Results {
+				br.RowsAffected += r.RowsAffected
+			}
+		}
+		if err != nil {
+			offsetStatementErrors(err, first)
+			return err
+		}
+		if er.Error != "" {
+			return &StatementError{Index: -1, Message: er.Error}
+		}
+		for i, r := range er.Results {
+			if r.Error != "" {
+				return &StatementError{Index: first + i, SQL: sql, Message: r.Error}
+			}
+		}
+		chunk, chunkBytes, first = nil, 0, first+len(chunk)
+		return nil
+	}
+
+	for _, row := range rows {
+		stmt := &SQLStatement{SQL: sql, PositionalParams: row}
+		var n int
+		if o.MaxBytes > 0 {
+			b, err := json.Marshal(stmt)
+			if err != nil {
+				return &br, err
+			}
+			n = len(b) + 1
+		}
+		if len(chunk) == o.MaxStatements || (o.MaxBytes > 0 && chunkBytes+n > o.MaxBytes) {
+			if err := flush(); err != nil {
+				return &br, err
+			}
+		}
+		chunk = append(chunk, stmt)
+		chunkBytes += n
+	}
+	if err := flush(); err != nil {
+		return &br, err
+	}
+	return &br, nil
+}
+
+// offsetStatementErrors adds offset to the Index of each StatementError in err, which
+// may join several errors, as promoted errors do. It converts the index of a statement
+// within a request into the index of its row within a batch.
+func offsetStatementErrors(err error, offset int) {
+	switch e := err.(type) {
+	case *StatementError:
+		if e.Index >= 0 {
+			e.Index += offset
+		}
+	case interface{ Unwrap() []error }:
+		for _, err := range e.Unwrap() {
+			offsetStatementErrors(err, offset)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_ExecuteBatch(t *testing.T) {
+	var sizes []int
+	failAt := -1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/db/execute" {
+			t.Errorf("expected path /db/execute, got %s", r.URL.Path)
+		}
+		var stmts []json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&stmts); err != nil {
+			t.Errorf("unexpected error decoding body: %v", err)
+		}
+		sizes = append(sizes, len(stmts))
+		var results []string
+		for i := range stmts {
+			if i == failAt {
+				results = append(results, `{"error": "UNIQUE constraint failed"}`)
+				continue
+			}
+			results = append(results, `{"rows_affected": 1}`)
+		}
+		fmt.Fprintf(w, `{"results": [%s]}`, strings.Join(results, ","))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	rows := make([][]any, 25)
+	for i := range rows {
+		rows[i] = []any{i, fmt.Sprintf("name%d", i)}
+	}
+	const sql = "INSERT INTO foo(id, name) VALUES(?, ?)"
+
+	br, err := cl.ExecuteBatch(context.Background(), sql, rows, &BatchOptions{MaxStatements: 10})
+	if err != nil {
+		t.Fatalf("unexpected error from ExecuteBatch: %v", err)
+	}
+	if exp := (BatchResult{RowsAffected: 25, Requests: 3}); *br != exp {
+		t.Fatalf("expected result %+v, got %+v", exp, *br)
+	}
+	if exp, got := fmt.Sprint([]int{10, 10, 5}), fmt.Sprint(sizes); exp != got {
+		t.Fatalf("expected request sizes %s, got %s", exp, got)
+	}
+
+	// Requests are limited to 3 statements by size.
+	sizes = nil
+	b, _ := json.Marshal(&SQLStatement{SQL: sql, PositionalParams: rows[0]})
+	br, err = cl.ExecuteBatch(context.Background(), sql, rows[:7], &BatchOptions{MaxBytes: 3*(len(b)+1) + 1})
+	if err != nil {
+		t.Fatalf("unexpected error from ExecuteBatch: %v", err)
+	}
+	if exp, got := fmt.Sprint([]int{3, 3, 1}), fmt.Sprint(sizes); exp != got {
+		t.Fatalf("expected request sizes %s, got %s", exp, got)
+	}
+
+	// A failed statement stops the batch, and is identified by its row.
+	sizes = nil
+	failAt = 4
+	br, err = cl.ExecuteBatch(context.Background(), sql, rows, &BatchOptions{MaxStatements: 10})
+	var se *StatementError
+	if !errors.As(err, &se) || se.Index != 4 || se.Message != "UNIQUE constraint failed" {
+		t.Fatalf("expected error for row 4, got %v", err)
+	}
+	if exp := (BatchResult{RowsAffected: 9, Requests: 1}); *br != exp {
+		t.Fatalf("expected result %+v, got %+v", exp, *br)
+	}
+
+	if _, err := cl.ExecuteBatch(context.Background(), sql, rows, &BatchOptions{MaxStatements: -1}); err == nil {
+		t.Fatalf("expected error for negative limit")
+	}
+}
+
+func Test_ExecuteBatch_PromotedErrors(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 2 {
+			w.Write([]byte(`{"results": [{"rows_affected": 1}, {"error": "UNIQUE constraint failed"}]}`))
+			return
+		}
+		w.Write([]byte(`{"results": [{"rows_affected": 1}, {"rows_affected": 1}]}`))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	rows := [][]any{{0}, {1}, {2}, {3}, {4}}
+	const sql = "INSERT INTO foo(id) VALUES(?)"
+	for _, joined := range []bool{false, true} {
+		requests = 0
+		cl.PromoteErrors(!joined)
+		cl.PromoteJoinedErrors(joined)
+		_, err := cl.ExecuteBatch(context.Background(), sql, rows, &BatchOptions{MaxStatements: 2})
+		var se *StatementError
+		if !errors.As(err, &se) || se.Index != 3 {
+			t.Fatalf("expected error for row 3 with joined=%v, got %v", joined, err)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package http
+
+import (
+	"errors"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// errNotLeaderAware is returned by a CircuitBreakerBalancer when asked for the Leader
+// or a Follower, but the balancer it wraps cannot identify them.
+var errNotLeaderAware = errors.New("balancer is not leader-aware")
+
+// maxBreakerAttempts is the number of times a CircuitBreakerBalancer asks the balancer
+// it wraps for a host, before checking every host the wrapped balancer lists.
+const maxBreakerAttempts = 10
+
+// CircuitState is the state of the circuit breaker for a single host.
+type CircuitState int
+
+const (
+	// CircuitClosed indicates requests are sent to the host as normal.
+	CircuitClosed CircuitState = iota
+
+	// CircuitOpen indicates the host has failed repeatedly, and requests are not
+	// sent to it until the cooldown has elapsed.
+	CircuitOpen
+
+	// CircuitHalfOpen indicates the cooldown has elapsed, and a single trial request
+	// may be sent to the host. If the trial succeeds the circuit is closed, otherwise
+	// it is opened again.
+	CircuitHalfOpen
+)
+
+// String returns the string representation of a CircuitState.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// circuit tracks the failures of a single host.
+type circuit struct {
+	failures int
+	openedAt time.Time
+	open     bool
+
+	// trialAt is when a trial request was last permitted while half-open.
+	trialAt time.Time
+}
+
+// CircuitBreakerBalancer wraps a LoadBalancer, and stops returning any host which
+// has failed a number of consecutive requests. Once a host's circuit is opened, it
+// is not returned by Next() until a cooldown has elapsed, after which a single trial
+// request is permitted. A successful trial closes the circuit, and a failed trial
+// opens it for another cooldown.
+//
+// When a circuit is opened, the host is also marked bad with the wrapped balancer,
+// if it supports MarkBad(), so the wrapped balancer can recheck its health.
+//
+// The client reports the outcome of each request to a CircuitBreakerBalancer, as it
+// implements OutcomeRecorder. If the wrapped balancer is a LeaderAwareBalancer, so
+// is the CircuitBreakerBalancer.
+type CircuitBreakerBalancer struct {
+	lb        LoadBalancer
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+	now      func() time.Time
+}
+
+// NewCircuitBreakerBalancer returns a CircuitBreakerBalancer wrapping lb, which opens
+// the circuit for a host after threshold consecutive failed requests, for cooldown.
+// A threshold less than 1 is treated as 1.
+func NewCircuitBreakerBalancer(lb LoadBalancer, threshold int, cooldown time.Duration) *CircuitBreakerBalancer {
+	return &CircuitBreakerBalancer{
+		lb:        lb,
+		threshold: max(threshold, 1),
+		cooldown:  cooldown,
+		circuits:  make(map[string]*circuit),
+		now:       time.Now,
+	}
+}
+
+// Next returns the next address from the wrapped balancer whose circuit permits a
+// request. If the wrapped balancer repeatedly returns hosts whose circuits are open,
+// and it lists its healthy hosts, as the RandomBalancer and RoundRobinBalancer do,
+// each of them is checked in turn. If no such address can be found,
+// ErrNoHostsAvailable is returned.
+func (cb *CircuitBreakerBalancer) Next() (*url.URL, error) {
+	u, err := cb.permitted(cb.lb.Next)
+	if err != ErrNoHostsAvailable {
+		return u, err
+	}
+	if hl, ok := cb.lb.(interface{ Healthy() []*url.URL }); ok {
+		for _, u := range hl.Healthy() {
+			if cb.allow(u) {
+				return u, nil
+			}
+		}
+	}
+	return nil, ErrNoHostsAvailable
+}
+
+// Leader returns the Leader identified by the wrapped balancer, if its circuit
+// permits a request.
+func (cb *CircuitBreakerBalancer) Leader() (*url.URL, error) {
+	lab, ok := cb.lb.(LeaderAwareBalancer)
+	if !ok {
+		return nil, errNotLeaderAware
+	}
+	return cb.permitted(lab.Leader)
+}
+
+// Follower returns a Follower identified by the wrapped balancer, if its circuit
+// permits a request.
+func (cb *CircuitBreakerBalancer) Follower() (*url.URL, error) {
+	lab, ok := cb.lb.(LeaderAwareBalancer)
+	if !ok {
+		return nil, errNotLeaderAware
+	}
+	return cb.permitted(lab.Follower)
+}
+
+// RecordSuccess records that a request to u succeeded, closing its circuit.
+func (cb *CircuitBreakerBalancer) RecordSuccess(u *url.URL) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	delete(cb.circuits, u.String())
+}
+
+// RecordFailure records that a request to u failed. If the host has now failed
+// the threshold number of consecutive requests, or a trial request failed, its
+// circuit is opened.
+func (cb *CircuitBreakerBalancer) RecordFailure(u *url.URL) {
+	cb.mu.Lock()
+	c, ok := cb.circuits[u.String()]
+	if !ok {
+		c = &circuit{}
+		cb.circuits[u.String()] = c
+	}
+	c.failures++
+	trip := c.open || c.failures >= cb.threshold
+	if trip {
+		c.open = true
+		c.openedAt = cb.now()
+		c.trialAt = time.Time{}
+	}
+	cb.mu.Unlock()
+
+	if trip {
+		if mb, ok := cb.lb.(interface{ MarkBad(*url.URL) }); ok {
+			mb.MarkBad(u)
+		}
+	}
+}
+
+// State returns the state of the circuit for u.
+func (cb *CircuitBreakerBalancer) State(u *url.URL) CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state(cb.circuits[u.String()])
+}
+
+// permitted calls next until it returns an address whose circuit permits a request.
+func (cb *CircuitBreakerBalancer) permitted(next func() (*url.URL, error)) (*url.URL, error) {
+	for i := 0; i < maxBreakerAttempts; i++ {
+		u, err := next()
+		if err != nil {
+			return nil, err
+		}
+		if cb.allow(u) {
+			return u, nil
+		}
+	}
+	return nil, ErrNoHostsAvailable
+}
+
+// allow returns whether a request may be sent to u. If u's circuit is half-open,
+// allow permits a single trial request per cooldown period.
+func (cb *CircuitBreakerBalancer) allow(u *url.URL) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	c := cb.circuits[u.String()]
+	switch cb.state(c) {
+	case CircuitClosed:
+		return true
+	case CircuitHalfOpen:
+		if c.trialAt.IsZero() || cb.now().Sub(c.trialAt) >= cb.cooldown {
+			c.trialAt = cb.now()
+			return true
+		}
+	}
+	return false
+}
+
+func (cb *CircuitBreakerBalancer) state(c *circuit) CircuitState {
+	switch {
+	case c == nil || !c.open:
+		return CircuitClosed
+	case cb.now().Sub(c.openedAt) < cb.cooldown:
+		return CircuitOpen
+	default:
+		return CircuitHalfOpen
+	}
+}
@@ -0,0 +1,180 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_CircuitBreakerBalancer_States(t *testing.T) {
+	lb, err := NewLoopbackBalancer("http://a:4001")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	now := time.Now()
+	cb := NewCircuitBreakerBalancer(lb, 3, time.Minute)
+	cb.now = func() time.Time { return now }
+	u := mustParseURL("http://a:4001")
+
+	// Failures below the threshold, or interrupted by a success, do not trip the breaker.
+	cb.RecordFailure(u)
+	cb.RecordFailure(u)
+	cb.RecordSuccess(u)
+	cb.RecordFailure(u)
+	cb.RecordFailure(u)
+	if exp, got := CircuitClosed, cb.State(u); exp != got {
+		t.Fatalf("expected state %s, got %s", exp, got)
+	}
+	if _, err := cb.Next(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cb.RecordFailure(u)
+	if exp, got := CircuitOpen, cb.State(u); exp != got {
+		t.Fatalf("expected state %s, got %s", exp, got)
+	}
+	if _, err := cb.Next(); err != ErrNoHostsAvailable {
+		t.Fatalf("expected ErrNoHostsAvailable, got %v", err)
+	}
+
+	// After the cooldown, a single trial is permitted.
+	now = now.Add(time.Minute)
+	if exp, got := CircuitHalfOpen, cb.State(u); exp != got {
+		t.Fatalf("expected state %s, got %s", exp, got)
+	}
+	if _, err := cb.Next(); err != nil {
+		t.Fatalf("unexpected error for trial request: %v", err)
+	}
+	if _, err := cb.Next(); err != ErrNoHostsAvailable {
+		t.Fatalf("expected ErrNoHostsAvailable while trial outstanding, got %v", err)
+	}
+
+	// A failed trial reopens the circuit.
+	cb.RecordFailure(u)
+	if exp, got := CircuitOpen, cb.State(u); exp != got {
+		t.Fatalf("expected state %s, got %s", exp, got)
+	}
+
+	// A successful trial closes it.
+	now = now.Add(time.Minute)
+	if _, err := cb.Next(); err != nil {
+		t.Fatalf("unexpected error for trial request: %v", err)
+	}
+	cb.RecordSuccess(u)
+	if exp, got := CircuitClosed, cb.State(u); exp != got {
+		t.Fatalf("expected state %s, got %s", exp, got)
+	}
+}
+
+func Test_CircuitBreakerBalancer_MarkBad(t *testing.T) {
+	rb, err := NewRandomBalancer([]string{"http://a:4001", "http://b:4001"}, neverHealthy, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer rb.Close()
+	cb := NewCircuitBreakerBalancer(rb, 2, time.Hour)
+
+	a := mustParseURL("http://a:4001")
+	cb.RecordFailure(a)
+	if len(rb.Bad()) != 0 {
+		t.Fatalf("expected no bad hosts before breaker trips, got %v", rb.Bad())
+	}
+	cb.RecordFailure(a)
+	if exp, got := []string{"http://a:4001"}, urlStrings(rb.Bad()); len(got) != 1 || got[0] != exp[0] {
+		t.Fatalf("expected bad hosts %v, got %v", exp, got)
+	}
+	for i := 0; i < 10; i++ {
+		u, err := cb.Next()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if exp, got := "http://b:4001", u.String(); exp != got {
+			t.Fatalf("expected %s, got %s", exp, got)
+		}
+	}
+}
+
+func Test_CircuitBreakerBalancer_Client(t *testing.T) {
+	var healthy atomic.Bool
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"results": []}`))
+	}))
+	defer ts.Close()
+
+	lb, err := NewLoopbackBalancer(ts.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	const cooldown = 100 * time.Millisecond
+	cb := NewCircuitBreakerBalancer(lb, 3, cooldown)
+	client, err := NewClientWithBalancer(cb, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	for i := 0; i < 3; i++ {
+		var httpErr *HTTPError
+		if _, err := client.QuerySingle(context.Background(), "SELECT 1"); !errors.As(err, &httpErr) {
+			t.Fatalf("expected HTTPError, got %v", err)
+		}
+	}
+	if _, err := client.QuerySingle(context.Background(), "SELECT 1"); err != ErrNoHostsAvailable {
+		t.Fatalf("expected ErrNoHostsAvailable once breaker open, got %v", err)
+	}
+	if exp, got := int32(3), hits.Load(); exp != got {
+		t.Fatalf("expected %d requests to reach server, got %d", exp, got)
+	}
+
+	healthy.Store(true)
+	time.Sleep(cooldown)
+	if _, err := client.QuerySingle(context.Background(), "SELECT 1"); err != nil {
+		t.Fatalf("expected trial request to succeed, got %v", err)
+	}
+	if exp, got := CircuitClosed, cb.State(mustParseURL(ts.URL)); exp != got {
+		t.Fatalf("expected state %s, got %s", exp, got)
+	}
+	if _, err := client.QuerySingle(context.Background(), "SELECT 1"); err != nil {
+		t.Fatalf("expected request to succeed after recovery, got %v", err)
+	}
+}
+
+// stuckBalancer always returns its first host from Next, but lists all its hosts
+// as healthy.
+type stuckBalancer struct {
+	hosts []*url.URL
+}
+
+func (sb *stuckBalancer) Next() (*url.URL, error) { return sb.hosts[0], nil }
+
+func (sb *stuckBalancer) Healthy() []*url.URL { return sb.hosts }
+
+func Test_CircuitBreakerBalancer_Scan(t *testing.T) {
+	a, b := mustParseURL("http://a:4001"), mustParseURL("http://b:4001")
+	cb := NewCircuitBreakerBalancer(&stuckBalancer{hosts: []*url.URL{a, b}}, 1, time.Hour)
+	cb.RecordFailure(a)
+	u, err := cb.Next()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exp, got := b.String(), u.String(); exp != got {
+		t.Fatalf("expected %s, got %s", exp, got)
+	}
+
+	cb.RecordFailure(b)
+	if _, err := cb.Next(); err != ErrNoHostsAvailable {
+		t.Fatalf("expected ErrNoHostsAvailable, got %v", err)
+	}
+}
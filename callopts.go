@@ -0,0 +1,87 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"net/http"
+	"slices"
+)
+
+type headersKey struct{}
+
+type credentialsKey struct{}
+
+// credentials are Basic Auth credentials attached to a context.
+type credentials struct {
+	username string
+	password string
+}
+
+// WithHeaders returns a copy of ctx which causes requests made with it to carry the
+// given headers, in addition to any attached to ctx already. Values for a header
+// replace any attached to ctx for the same header. Headers the client sets itself,
+// such as Content-Type, take precedence over headers given here.
+//
+// An Authorization header given here takes precedence over credentials set with
+// SetBasicAuth, allowing, for example, a proxy to send requests on behalf of several
+// users through one client.
+func WithHeaders(ctx context.Context, header http.Header) context.Context {
+	h := headersFromContext(ctx).Clone()
+	if h == nil {
+		h = make(http.Header, len(header))
+	}
+	for k, v := range header {
+		h[http.CanonicalHeaderKey(k)] = append([]string(nil), v...)
+	}
+	return context.WithValue(ctx, headersKey{}, h)
+}
+
+// WithCredentials returns a copy of ctx which causes requests made with it to use the
+// given Basic Auth credentials, instead of any set with SetBasicAuth.
+func WithCredentials(ctx context.Context, username, password string) context.Context {
+	return context.WithValue(ctx, credentialsKey{}, credentials{username, password})
+}
+
+func headersFromContext(ctx context.Context) http.Header {
+	h, _ := ctx.Value(headersKey{}).(http.Header)
+	return h
+}
+
+// applyContext applies any headers and credentials attached to ctx to req.
+func applyContext(ctx context.Context, req *http.Request) {
+	for k, v := range headersFromContext(ctx) {
+		req.Header[k] = v
+	}
+	if creds, ok := ctx.Value(credentialsKey{}).(credentials); ok {
+		req.SetBasicAuth(creds.username, creds.password)
+	}
+}
+
+// contextDigest returns a digest of the headers and credentials attached to ctx, or
+// the empty string if there are none. Requests made with contexts whose digests
+// differ may be authorized differently, so must not share responses.
+func contextDigest(ctx context.Context) string {
+	h := headersFromContext(ctx)
+	creds, hasCreds := ctx.Value(credentialsKey{}).(credentials)
+	if len(h) == 0 && !hasCreds {
+		return ""
+	}
+	d := sha256.New()
+	for _, k := range slices.Sorted(maps.Keys(h)) {
+		d.Write([]byte(k))
+		for _, v := range h[k] {
+			d.Write([]byte{0})
+			d.Write([]byte(v))
+		}
+		d.Write([]byte{'\n'})
+	}
+	if hasCreds {
+		d.Write([]byte{1})
+		d.Write([]byte(creds.username))
+		d.Write([]byte{0})
+		d.Write([]byte(creds.password))
+	}
+	return hex.EncodeToString(d.Sum(nil))
+}
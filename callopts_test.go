@@ -0,0 +1,79 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_WithHeadersAndCredentials(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(`{"results": []}`))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+	cl.SetBasicAuth("admin", "secret")
+
+	basic := func(user, pass string) string {
+		r, _ := http.NewRequest("GET", "http://localhost", nil)
+		r.SetBasicAuth(user, pass)
+		return r.Header.Get("Authorization")
+	}
+
+	for _, tt := range []struct {
+		name    string
+		ctx     context.Context
+		expAuth string
+		expHdrs map[string]string
+	}{
+		{
+			name:    "client credentials",
+			ctx:     context.Background(),
+			expAuth: basic("admin", "secret"),
+		},
+		{
+			name:    "per-call credentials",
+			ctx:     WithCredentials(context.Background(), "tenant", "pw"),
+			expAuth: basic("tenant", "pw"),
+		},
+		{
+			name: "per-call headers",
+			ctx: WithHeaders(
+				WithHeaders(context.Background(), http.Header{"x-tenant": {"a"}, "X-Trace": {"1"}}),
+				http.Header{"X-Tenant": {"b"}, "Authorization": {"Bearer abc"}, "Content-Type": {"text/plain"}},
+			),
+			expAuth: "Bearer abc",
+			expHdrs: map[string]string{"X-Tenant": "b", "X-Trace": "1", "Content-Type": "application/json"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := cl.ExecuteSingle(tt.ctx, "DELETE FROM foo"); err != nil {
+				t.Fatalf("unexpected error from ExecuteSingle: %v", err)
+			}
+			if exp, got := tt.expAuth, got.Get("Authorization"); exp != got {
+				t.Fatalf("expected Authorization %q, got %q", exp, got)
+			}
+			for k, v := range tt.expHdrs {
+				if got := got.Values(k); len(got) != 1 || got[0] != v {
+					t.Fatalf("expected header %s %q, got %q", k, v, got)
+				}
+			}
+		})
+	}
+
+	// The client's credentials are unaffected by per-call credentials.
+	if _, err := cl.QuerySingle(context.Background(), "SELECT 1"); err != nil {
+		t.Fatalf("unexpected error from QuerySingle: %v", err)
+	}
+	if exp, got := basic("admin", "secret"), got.Get("Authorization"); exp != got {
+		t.Fatalf("expected Authorization %q, got %q", exp, got)
+	}
+}
@@ -0,0 +1,89 @@
+package http
+
+import (
+	"net/http"
+)
+
+// ClientOption configures a Client created by NewClient.
+type ClientOption func(*Client)
+
+// WithHTTPClient configures the client to send requests with httpClient. If httpClient
+// is nil, the default client is used.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithBalancer configures the client to send requests to the hosts chosen by lb,
+// rather than to the node at the URL passed to NewClient, which may then be empty.
+func WithBalancer(lb LoadBalancer) ClientOption {
+	return func(c *Client) {
+		c.lb = lb
+	}
+}
+
+// WithBasicAuth configures the client to use Basic Auth, as SetBasicAuth does.
+func WithBasicAuth(username, password string) ClientOption {
+	return func(c *Client) {
+		c.basicAuthUser = username
+		c.basicAuthPass = password
+	}
+}
+
+// WithRetry configures the client to retry failed requests up to n times, as
+// SetMaxRetries does.
+func WithRetry(n int) ClientOption {
+	return func(c *Client) {
+		c.SetMaxRetries(n)
+	}
+}
+
+// WithRetryPolicy configures how the client retries failed requests, as
+// SetRetryPolicy does.
+func WithRetryPolicy(p *RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.SetRetryPolicy(p)
+	}
+}
+
+// WithPromoteErrors enables or disables the promotion of statement-level errors to
+// Go errors, as PromoteErrors does.
+func WithPromoteErrors(b bool) ClientOption {
+	return func(c *Client) {
+		c.PromoteErrors(b)
+	}
+}
+
+// WithDefaultQueryOptions configures the client's default query options, as
+// SetDefaultQueryOptions does.
+func WithDefaultQueryOptions(opts *QueryOptions) ClientOption {
+	return func(c *Client) {
+		c.defQueryOpts = clonePtr(opts)
+	}
+}
+
+// WithDefaultExecuteOptions configures the client's default execute options, as
+// SetDefaultExecuteOptions does.
+func WithDefaultExecuteOptions(opts *ExecuteOptions) ClientOption {
+	return func(c *Client) {
+		c.defExecOpts = clonePtr(opts)
+	}
+}
+
+// WithDefaultRequestOptions configures the client's default request options, as
+// SetDefaultRequestOptions does.
+func WithDefaultRequestOptions(opts *RequestOptions) ClientOption {
+	return func(c *Client) {
+		c.defReqOpts = clonePtr(opts)
+	}
+}
+
+// clonePtr returns a pointer to a copy of *p, or nil if p is nil.
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	cp := *p
+	return &cp
+}
@@ -0,0 +1,236 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_NewClient_Options(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+	var auths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries = append(queries, r.URL.RawQuery)
+		user, pass, _ := r.BasicAuth()
+		auths = append(auths, user+":"+pass)
+		mu.Unlock()
+		if r.URL.Path == "/db/execute" {
+			w.Write([]byte(`{"results": [{"error": "no such table: foo"}]}`))
+			return
+		}
+		w.Write([]byte(`{"results": [{"columns": ["id"], "types": ["integer"], "values": [[1]]}]}`))
+	}))
+	defer server.Close()
+
+	httpClient := DefaultHTTPClient()
+	cl, err := NewClient("",
+		WithBalancer(mustNewLoopbackBalancer(t, server.URL)),
+		WithHTTPClient(httpClient),
+		WithBasicAuth("admin", "secret"),
+		WithRetry(2),
+		WithPromoteErrors(true),
+		WithDefaultQueryOptions(&QueryOptions{Level: ReadConsistencyLevelWeak}),
+		WithDefaultExecuteOptions(&ExecuteOptions{Timings: true}),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+	if cl.httpClient != httpClient {
+		t.Fatalf("expected HTTP client to be used")
+	}
+	if exp, got := int32(2), cl.maxRetries.Load(); exp != got {
+		t.Fatalf("expected max retries %d, got %d", exp, got)
+	}
+
+	if _, err := cl.QuerySingle(context.Background(), "SELECT * FROM foo"); err != nil {
+		t.Fatalf("unexpected error from QuerySingle: %v", err)
+	}
+	if _, err := cl.Query(context.Background(), SQLStatements{{SQL: "SELECT * FROM foo"}}, &QueryOptions{Timings: true}); err != nil {
+		t.Fatalf("unexpected error from Query: %v", err)
+	}
+	if _, err := cl.ExecuteSingle(context.Background(), "DELETE FROM foo"); !errors.Is(err, ErrStatementError) {
+		t.Fatalf("expected promoted statement error, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if exp := []string{"level=weak", "level=weak&timings=true", "timings=true"}; !slices.Equal(exp, queries) {
+		t.Fatalf("expected query parameters %v, got %v", exp, queries)
+	}
+	for _, a := range auths {
+		if a != "admin:secret" {
+			t.Fatalf("expected Basic Auth admin:secret, got %s", a)
+		}
+	}
+}
+
+func Test_NewClientWithBalancer_Nil(t *testing.T) {
+	if _, err := NewClientWithBalancer(nil, nil); err == nil {
+		t.Fatalf("expected error creating client with nil balancer")
+	}
+}
+
+func mustNewLoopbackBalancer(t *testing.T, u string) *LoopbackBalancer {
+	t.Helper()
+	lb, err := NewLoopbackBalancer(u)
+	if err != nil {
+		t.Fatalf("failed to create balancer: %v", err)
+	}
+	return lb
+}
+
+func Test_SetDefaultOptions(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries = append(queries, r.URL.Path+"?"+r.URL.RawQuery)
+		mu.Unlock()
+		w.Write([]byte(`{"results": []}`))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	defaults := &RequestOptions{Level: ReadConsistencyLevelNone, Timings: true}
+	cl.SetDefaultRequestOptions(defaults)
+	defaults.Timings = false
+	cl.SetDefaultExecuteOptions(&ExecuteOptions{Timeout: time.Second})
+	cl.SetDefaultQueryOptions(&QueryOptions{Level: ReadConsistencyLevelWeak})
+
+	ctx := context.Background()
+	stmts := SQLStatements{{SQL: "SELECT 1"}}
+	callOpts := &RequestOptions{Level: ReadConsistencyLevelStrong}
+	if _, err := cl.Request(ctx, stmts, callOpts); err != nil {
+		t.Fatalf("unexpected error from Request: %v", err)
+	}
+	if callOpts.Timings {
+		t.Fatalf("expected call options not to be modified")
+	}
+	if _, err := cl.Request(ctx, stmts, nil); err != nil {
+		t.Fatalf("unexpected error from Request: %v", err)
+	}
+	if _, err := cl.Execute(ctx, stmts, &ExecuteOptions{Timeout: time.Minute}); err != nil {
+		t.Fatalf("unexpected error from Execute: %v", err)
+	}
+	if _, err := cl.Query(ctx, stmts, &QueryOptions{Pretty: true}); err != nil {
+		t.Fatalf("unexpected error from Query: %v", err)
+	}
+	cl.SetDefaultQueryOptions(nil)
+	if _, err := cl.Query(ctx, stmts, nil); err != nil {
+		t.Fatalf("unexpected error from Query: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	exp := []string{
+		"/db/request?level=strong&timings=true",
+		"/db/request?level=none&timings=true",
+		"/db/execute?timeout=1m0s",
+		"/db/query?level=weak&pretty=true",
+		"/db/query?",
+	}
+	if !slices.Equal(exp, queries) {
+		t.Fatalf("expected requests %v, got %v", exp, queries)
+	}
+}
+
+func Test_DefaultOptions_ForcedFields(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries = append(queries, r.URL.RawQuery)
+		mu.Unlock()
+		if r.URL.Query().Has("associative") {
+			w.Write([]byte(`{"results": [{"types": {"n": "integer"}, "rows": [{"n": 1}]}]}`))
+			return
+		}
+		w.Write([]byte(`{"results": [{"columns": ["n"], "types": ["integer"], "values": [[1]]}]}`))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, WithDefaultQueryOptions(&QueryOptions{
+		Associative: true,
+		Level:       ReadConsistencyLevelNone,
+		Freshness:   time.Second,
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+	ctx := context.Background()
+
+	batch, err := cl.QueryBatch(ctx, map[string]*SQLStatement{"n": {SQL: "SELECT 1 AS n"}}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from QueryBatch: %v", err)
+	}
+	if len(batch["n"].Values) != 1 {
+		t.Fatalf("expected one row from QueryBatch, got %v", batch["n"].Values)
+	}
+	v, err := cl.QueryScalar(ctx, "SELECT 1 AS n")
+	if err != nil {
+		t.Fatalf("unexpected error from QueryScalar: %v", err)
+	}
+	if v != int64(1) {
+		t.Fatalf("expected scalar 1, got %v", v)
+	}
+	if _, err := cl.Query(ctx, SQLStatements{{SQL: "SELECT 1 AS n"}}, (&QueryOptions{}).WithLeaderRead()); err != nil {
+		t.Fatalf("unexpected error from Query with leader read: %v", err)
+	}
+	if _, err := cl.Query(ctx, SQLStatements{{SQL: "SELECT 1 AS n"}}, nil); err != nil {
+		t.Fatalf("unexpected error from Query: %v", err)
+	}
+	if _, err := cl.Query(ctx, SQLStatements{{SQL: "SELECT 1 AS n"}}, &QueryOptions{Pretty: true, NoDefaults: true}); err != nil {
+		t.Fatalf("unexpected error from Query: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	exp := []string{
+		"freshness=1s&level=none",
+		"freshness=1s&level=none",
+		"associative=true&level=weak",
+		"associative=true&freshness=1s&level=none",
+		"pretty=true",
+	}
+	if !slices.Equal(exp, queries) {
+		t.Fatalf("expected query parameters %v, got %v", exp, queries)
+	}
+}
+
+func Test_MergeOptions(t *testing.T) {
+	def := &QueryOptions{Level: ReadConsistencyLevelNone, Freshness: time.Second, Timings: true}
+	if got := mergeOptions(def, nil); got != def {
+		t.Fatalf("expected defaults for nil options, got %+v", got)
+	}
+	opts := &QueryOptions{Pretty: true}
+	if got := mergeOptions(nil, opts); got != opts {
+		t.Fatalf("expected options without defaults, got %+v", got)
+	}
+	got := mergeOptions(def, opts)
+	if exp := (QueryOptions{Level: ReadConsistencyLevelNone, Freshness: time.Second, Timings: true, Pretty: true}); *got != exp {
+		t.Fatalf("expected %+v, got %+v", exp, *got)
+	}
+	got = mergeOptions(def, &QueryOptions{Level: ReadConsistencyLevelStrong})
+	if exp := (QueryOptions{Level: ReadConsistencyLevelStrong, Timings: true}); *got != exp {
+		t.Fatalf("expected consistency taken from call, got %+v", *got)
+	}
+	noDefaults := &QueryOptions{Pretty: true, NoDefaults: true}
+	if got := mergeOptions(def, noDefaults); got != noDefaults {
+		t.Fatalf("expected options with NoDefaults to be used as given, got %+v", got)
+	}
+}
@@ -0,0 +1,133 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// clusterCheckInterval is how often a client created by NewClusterClient checks
+	// whether unhealthy nodes have recovered, and the time allowed for each check.
+	clusterCheckInterval = 5 * time.Second
+
+	// clusterFailureThreshold is the number of consecutive failed requests after which
+	// a client created by NewClusterClient considers a node unhealthy.
+	clusterFailureThreshold = 3
+)
+
+// ReadyChecker returns a HostChecker which considers a host healthy if its /readyz
+// endpoint reports it ready, as Ready does with opts, which may be nil. It can be
+// passed to balancers such as RandomBalancer, so that hosts marked bad are returned to
+// use once they are ready. Each check is made with the client's HTTP client,
+// credentials and middleware. If timeout is non-zero, a check which takes longer fails.
+func (c *Client) ReadyChecker(opts *ReadyOptions, timeout time.Duration) HostChecker {
+	return func(u *url.URL) bool {
+		hc, err := c.hostClient(u.String())
+		if err != nil {
+			return false
+		}
+		ctx := context.Background()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		_, err = hc.Ready(ctx, opts)
+		return err == nil
+	}
+}
+
+// NewClusterClient returns a Client for the cluster whose nodes are at the given
+// addresses, which spreads requests across the nodes which are healthy. If
+// httpClient is nil, the default client is used.
+//
+// The client's balancer is a RandomBalancer, wrapped by a CircuitBreakerBalancer. A
+// node which fails several consecutive requests is marked bad, and is not sent further
+// requests until its /readyz endpoint, checked with ReadyChecker, reports it ready.
+// The balancer is closed when the client is closed.
+func NewClusterClient(addresses []string, httpClient *http.Client) (*Client, error) {
+	var checker HostChecker
+	rb, err := NewRandomBalancer(addresses, func(u *url.URL) bool {
+		return checker(u)
+	}, clusterCheckInterval)
+	if err != nil {
+		return nil, err
+	}
+	lb := NewCircuitBreakerBalancer(rb, clusterFailureThreshold, clusterCheckInterval)
+	c, err := NewClientWithBalancer(lb, httpClient)
+	if err != nil {
+		rb.Close()
+		return nil, err
+	}
+	checker = c.ReadyChecker(nil, clusterCheckInterval)
+	c.closeBalancer = rb.Close
+	return c, nil
+}
+
+// NodeInfo describes a node of the cluster, as returned by ClusterInfo.
+type NodeInfo struct {
+	Node
+
+	// Status is the node's status, as returned by Status, or nil if it could not be
+	// fetched.
+	Status json.RawMessage
+
+	// Err is the error fetching the node's status, if any.
+	Err error
+}
+
+// ClusterInfo is a cluster-wide view of the cluster's nodes and their status.
+type ClusterInfo struct {
+	// Nodes are the nodes of the cluster, including non-voting nodes.
+	Nodes []NodeInfo
+}
+
+// Leader returns the node which is the Leader of the cluster, and whether one is known.
+func (ci *ClusterInfo) Leader() (NodeInfo, bool) {
+	for _, n := range ci.Nodes {
+		if n.Leader {
+			return n, true
+		}
+	}
+	return NodeInfo{}, false
+}
+
+// ClusterInfo returns the nodes of the cluster, as reported by the node chosen by the
+// client's balancer, along with the status of each node. The nodes' statuses are
+// fetched concurrently, using the client's HTTP client, credentials and middleware. A
+// node whose status cannot be fetched, for example because it is unreachable, has its
+// Err set, and does not cause ClusterInfo to fail.
+func (c *Client) ClusterInfo(ctx context.Context) (*ClusterInfo, error) {
+	nodes, err := c.NodeList(ctx, &NodeOptions{NonVoters: true, Version: "2"})
+	if err != nil {
+		return nil, err
+	}
+
+	ci := &ClusterInfo{Nodes: make([]NodeInfo, len(nodes))}
+	var wg sync.WaitGroup
+	for i, n := range nodes {
+		ni := &ci.Nodes[i]
+		ni.Node = n
+		if n.APIAddr == "" {
+			ni.Err = fmt.Errorf("node %s has no API address", n.ID)
+			continue
+		}
+		hc, err := c.hostClient(n.APIAddr)
+		if err != nil {
+			ni.Err = err
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ni.Status, ni.Err = hc.Status(ctx)
+		}()
+	}
+	wg.Wait()
+	return ci, nil
+}
@@ -0,0 +1,134 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func Test_ReadyChecker(t *testing.T) {
+	var ready atomic.Bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/readyz" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("sync") != "true" {
+			t.Errorf("expected sync query parameter, got %s", r.URL.RawQuery)
+		}
+		if user, _, _ := r.BasicAuth(); user != "admin" {
+			t.Errorf("expected client credentials, got user %q", user)
+		}
+		if !ready.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("[+]node ok"))
+	}))
+	defer ts.Close()
+
+	client, err := NewClient("http://localhost:4001", WithBasicAuth("admin", "secret"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer client.Close()
+	check := client.ReadyChecker(&ReadyOptions{Sync: true}, clusterCheckInterval)
+	u := mustParseURL(ts.URL)
+	if check(u) {
+		t.Fatalf("expected host not to be ready")
+	}
+	ready.Store(true)
+	if !check(u) {
+		t.Fatalf("expected host to be ready")
+	}
+}
+
+func Test_NewClusterClient(t *testing.T) {
+	if _, err := NewClusterClient(nil, nil); err != ErrNoHostsAvailable {
+		t.Fatalf("expected ErrNoHostsAvailable, got %v", err)
+	}
+
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"results": []}`))
+	}))
+	defer healthy.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	client, err := NewClusterClient([]string{healthy.URL, failing.URL}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	var failures int
+	for range 30 {
+		if _, err := client.QuerySingle(context.Background(), "SELECT 1"); err != nil {
+			failures++
+		}
+	}
+	if failures > clusterFailureThreshold {
+		t.Fatalf("expected at most %d failures, got %d", clusterFailureThreshold, failures)
+	}
+	rb := client.lb.(*CircuitBreakerBalancer).lb.(*RandomBalancer)
+	if failures == clusterFailureThreshold {
+		if bad := rb.Bad(); len(bad) != 1 || bad[0].String() != failing.URL {
+			t.Fatalf("expected failing host to be marked bad, got %v", bad)
+		}
+	}
+}
+
+func Test_ClusterInfo(t *testing.T) {
+	newNode := func(id string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/status" {
+				t.Errorf("unexpected path: %s", r.URL.Path)
+			}
+			w.Write([]byte(`{"node": {"id": "` + id + `"}}`))
+		}))
+	}
+	node1 := newNode("1")
+	defer node1.Close()
+	node2 := newNode("2")
+	node2.Close()
+
+	nodes := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/nodes" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"nodes": [
+			{"id": "1", "api_addr": "` + node1.URL + `", "addr": "10.0.0.1:4002", "voter": true, "reachable": true, "leader": true},
+			{"id": "2", "api_addr": "` + node2.URL + `", "addr": "10.0.0.2:4002", "voter": true},
+			{"id": "3", "addr": "10.0.0.3:4002"}
+		]}`))
+	}))
+	defer nodes.Close()
+
+	client, err := NewClient(nodes.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer client.Close()
+	ci, err := client.ClusterInfo(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error calling ClusterInfo: %v", err)
+	}
+	if exp, got := 3, len(ci.Nodes); exp != got {
+		t.Fatalf("expected %d nodes, got %d", exp, got)
+	}
+	leader, ok := ci.Leader()
+	if !ok || leader.ID != "1" {
+		t.Fatalf("expected node 1 to be Leader, got %v", leader)
+	}
+	if leader.Err != nil || string(leader.Status) != `{"node": {"id": "1"}}` {
+		t.Fatalf("unexpected status of node 1: %s, %v", leader.Status, leader.Err)
+	}
+	for _, n := range ci.Nodes[1:] {
+		if n.Err == nil || n.Status != nil {
+			t.Fatalf("expected error fetching status of node %s, got %s", n.ID, n.Status)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// Decoder is the interface a JSON decoder must support.
+type Decoder interface {
+	// UseNumber causes the Decoder to unmarshal a number into an any as a
+	// json.Number instead of as a float64.
+	UseNumber()
+
+	// Decode reads the next JSON-encoded value from its input and stores it
+	// in the value pointed to by v.
+	Decode(v any) error
+}
+
+// Codec is the interface a JSON implementation must support to be used by the
+// client. It allows a faster JSON implementation to be used in place of the
+// standard library. A Codec must honor the json.Marshaler and json.Unmarshaler
+// interfaces, as the types in this package rely on them.
+//
+// The client uses its Codec to marshal the statements of an Execute, Query, or
+// Request, and to decode the responses to them, including their results. The
+// rows read by QueryIter, and the responses of the status and node endpoints, are
+// decoded with encoding/json instead. A QueryResponse or RequestResponse
+// unmarshaled directly, through its UnmarshalJSON method, is decoded with StdCodec.
+type Codec interface {
+	// Marshal returns the JSON encoding of v.
+	Marshal(v any) ([]byte, error)
+
+	// NewDecoder returns a new Decoder which reads from r.
+	NewDecoder(r io.Reader) Decoder
+}
+
+// StdCodec is a Codec which uses the encoding/json package. It is the default
+// Codec used by the client.
+type StdCodec struct{}
+
+// Marshal implements Codec.
+func (StdCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// NewDecoder implements Codec.
+func (StdCodec) NewDecoder(r io.Reader) Decoder {
+	return json.NewDecoder(r)
+}
+
+// decodeJSON decodes data into v with codec, unmarshaling numbers held in an any
+// as json.Number.
+func decodeJSON(codec Codec, data []byte, v any) error {
+	dec := codec.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
@@ -0,0 +1,91 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+// recordingCodec is a Codec which counts its invocations.
+type recordingCodec struct {
+	StdCodec
+	marshals atomic.Int64
+	decoders atomic.Int64
+}
+
+func (rc *recordingCodec) Marshal(v any) ([]byte, error) {
+	rc.marshals.Add(1)
+	return rc.StdCodec.Marshal(v)
+}
+
+func (rc *recordingCodec) NewDecoder(r io.Reader) Decoder {
+	rc.decoders.Add(1)
+	return rc.StdCodec.NewDecoder(r)
+}
+
+func Test_Codec(t *testing.T) {
+	respBody := `{"results": [{"columns": ["id", "name"], "types": ["integer", "text"], "values": [[1, "fiona"]]}]}`
+	var gotBodies [][]byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("unexpected error reading body: %v", err)
+		}
+		gotBodies = append(gotBodies, b)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(respBody))
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(ts.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer client.Close()
+	stmts := SQLStatements{{SQL: "SELECT * FROM foo WHERE id=?", PositionalParams: []any{1}}}
+
+	expQR, err := client.Query(context.Background(), stmts, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	codec := &recordingCodec{}
+	client.SetCodec(codec)
+	gotQR, err := client.Query(context.Background(), stmts, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exp, got := int64(1), codec.marshals.Load(); exp != got {
+		t.Fatalf("expected %d calls to Marshal, got %d", exp, got)
+	}
+	// The response and its results are decoded separately, both with the codec.
+	if exp, got := int64(2), codec.decoders.Load(); exp != got {
+		t.Fatalf("expected %d calls to NewDecoder, got %d", exp, got)
+	}
+	if !reflect.DeepEqual(expQR, gotQR) {
+		t.Fatalf("expected response %+v, got %+v", expQR, gotQR)
+	}
+	if exp, got := string(gotBodies[0]), string(gotBodies[1]); exp != got {
+		t.Fatalf("expected request body %s, got %s", exp, got)
+	}
+	v, ok := gotQR.GetQueryResults()[0].Values[0][0].(json.Number)
+	if !ok || v.String() != "1" {
+		t.Fatalf("expected json.Number 1, got %T %v", gotQR.GetQueryResults()[0].Values[0][0], v)
+	}
+
+	client.StreamRequestBodies(true)
+	if _, err := client.Execute(context.Background(), stmts, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exp, got := int64(2), codec.marshals.Load(); exp != got {
+		t.Fatalf("expected %d calls to Marshal, got %d", exp, got)
+	}
+	if exp, got := string(gotBodies[0]), string(gotBodies[2]); exp != got {
+		t.Fatalf("expected streamed request body %s, got %s", exp, got)
+	}
+}
@@ -10,7 +10,7 @@ import (
 
 func main() {
 	// Create a client pointing to a rqlite node
-	client, err := rqlitehttp.NewClient("http://localhost:4001", nil)
+	client, err := rqlitehttp.NewClient("http://localhost:4001")
 	if err != nil {
 		panic(err)
 	}
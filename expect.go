@@ -0,0 +1,79 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RowsAffectedError is returned by ExecuteExpectRows when a statement does not
+// affect the expected number of rows.
+type RowsAffectedError struct {
+	// Want is the number of rows the statement was expected to affect.
+	Want int64
+
+	// Got is the number of rows the statement affected, or would have affected had
+	// it not been rolled back.
+	Got int64
+
+	// RolledBack reports whether the changes made by the statement were rolled back.
+	RolledBack bool
+}
+
+// Error implements the error interface.
+func (e *RowsAffectedError) Error() string {
+	msg := fmt.Sprintf("expected %d rows affected, got %d", e.Want, e.Got)
+	if e.RolledBack {
+		msg += " (rolled back)"
+	}
+	return msg
+}
+
+// rowsAffectedGuard is executed, in the same transaction, after the statement passed
+// to ExecuteExpectRows. If the statement did not change the expected number of rows,
+// taking the absolute value of the smallest 64-bit integer causes SQLite to fail with
+// an integer overflow, and the node rolls back the transaction.
+const rowsAffectedGuard = "SELECT CASE WHEN changes() = ? THEN 0 ELSE abs(-9223372036854775808) END"
+
+// ExecuteExpectRows executes a single statement, such as an UPDATE or DELETE, and
+// returns a *RowsAffectedError if it does not affect exactly want rows.
+//
+// The statement is executed within a transaction, followed by a check of the number
+// of rows it changed. If the check fails the node rolls back the transaction, so the
+// statement has no effect, and the returned error has RolledBack set.
+func (c *Client) ExecuteExpectRows(ctx context.Context, sql string, want int64, args ...any) error {
+	stmt, err := NewSQLStatement(sql, args...)
+	if err != nil {
+		return err
+	}
+	guard, err := NewSQLStatement(rowsAffectedGuard, want)
+	if err != nil {
+		return err
+	}
+	er, err := c.Execute(ctx, SQLStatements{stmt, guard}, &ExecuteOptions{Transaction: true})
+	if err != nil {
+		return err
+	}
+	if er.Error != "" {
+		return errors.New(er.Error)
+	}
+	if len(er.Results) == 0 {
+		return fmt.Errorf("unexpected results executing statement")
+	}
+	if msg := er.Results[0].Error; msg != "" {
+		return errors.New(msg)
+	}
+
+	got := er.Results[0].RowsAffected
+	if len(er.Results) > 1 && er.Results[1].Error != "" {
+		if !strings.Contains(er.Results[1].Error, "integer overflow") {
+			return errors.New(er.Results[1].Error)
+		}
+		return &RowsAffectedError{Want: want, Got: got, RolledBack: true}
+	}
+	if got != want {
+		return &RowsAffectedError{Want: want, Got: got}
+	}
+	return nil
+}
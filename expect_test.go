@@ -0,0 +1,112 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_ExecuteExpectRows(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		response string
+		expErr   error
+	}{
+		{
+			name:     "match",
+			response: `{"results": [{"rows_affected": 2}, {}]}`,
+		},
+		{
+			name:     "mismatch",
+			response: `{"results": [{"rows_affected": 3}, {"error": "integer overflow"}]}`,
+			expErr:   &RowsAffectedError{Want: 2, Got: 3, RolledBack: true},
+		},
+		{
+			name:     "statement error",
+			response: `{"results": [{"error": "no such table: foo"}]}`,
+			expErr:   errors.New("no such table: foo"),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/db/execute" {
+					t.Errorf("unexpected path: %s", r.URL.Path)
+				}
+				if !r.URL.Query().Has("transaction") {
+					t.Errorf("expected transaction, got query %s", r.URL.RawQuery)
+				}
+				var stmts SQLStatements
+				if err := json.NewDecoder(r.Body).Decode(&stmts); err != nil {
+					t.Errorf("unexpected error decoding body: %v", err)
+				}
+				if len(stmts) != 2 {
+					t.Fatalf("expected 2 statements, got %d", len(stmts))
+				}
+				if exp, got := "UPDATE foo SET name = ? WHERE age > ?", stmts[0].SQL; exp != got {
+					t.Errorf("expected statement %s, got %s", exp, got)
+				}
+				if exp, got := rowsAffectedGuard, stmts[1].SQL; exp != got {
+					t.Errorf("expected guard %s, got %s", exp, got)
+				}
+				if exp, got := `[2]`, mustJSON(t, stmts[1].PositionalParams); exp != got {
+					t.Errorf("expected guard params %s, got %s", exp, got)
+				}
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			cl, err := NewClient(server.URL, nil)
+			if err != nil {
+				t.Fatalf("unexpected error from NewClient: %v", err)
+			}
+			defer cl.Close()
+
+			err = cl.ExecuteExpectRows(context.Background(), "UPDATE foo SET name = ? WHERE age > ?", 2, "fiona", 20)
+			if tt.expErr == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.expErr.Error() {
+				t.Fatalf("expected error %v, got %v", tt.expErr, err)
+			}
+		})
+	}
+}
+
+func Test_ExecuteExpectRows_ErrorType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"results": [{"rows_affected": 0}, {}]}`))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	err = cl.ExecuteExpectRows(context.Background(), "DELETE FROM foo WHERE id = 1", 1)
+	var rae *RowsAffectedError
+	if !errors.As(err, &rae) {
+		t.Fatalf("expected RowsAffectedError, got %v", err)
+	}
+	if rae.Want != 1 || rae.Got != 0 || rae.RolledBack {
+		t.Fatalf("unexpected error contents: %+v", rae)
+	}
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("failed to marshal %v: %v", v, err)
+	}
+	return string(b)
+}
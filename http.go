@@ -2,16 +2,24 @@ package http
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"maps"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"reflect"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -24,6 +32,74 @@ func DefaultHTTPClient() *http.Client {
 	}
 }
 
+// HighThroughputOptions configures the HTTP client returned by
+// NewHighThroughputClient. Zero values select the defaults.
+type HighThroughputOptions struct {
+	// MaxIdleConnsPerHost is the number of idle connections kept open to each node,
+	// ready for reuse. The default is 100, rather than the 2 used by net/http, which
+	// under load causes connections to be repeatedly closed and reopened.
+	MaxIdleConnsPerHost int
+
+	// MaxConnsPerHost, if set, limits the number of connections to each node, in any
+	// state. Requests beyond the limit wait for a connection to become available.
+	MaxConnsPerHost int
+
+	// IdleConnTimeout is how long an idle connection is kept open. The default is
+	// 90 seconds.
+	IdleConnTimeout time.Duration
+
+	// ForceHTTP2 signals whether to attempt HTTP/2 over TLS. HTTP/2 multiplexes
+	// concurrent requests to a node over a single connection.
+	ForceHTTP2 bool
+
+	// TLSClientConfig, if set, is the TLS configuration used to connect to nodes.
+	TLSClientConfig *tls.Config
+
+	// Timeout is the client's timeout. The default is 5 seconds.
+	Timeout time.Duration
+}
+
+// NewHighThroughputClient returns an HTTP client tuned for making thousands of
+// requests per second to a small number of nodes, for passing to NewClient. The
+// client keeps many more idle connections open to each node than the client returned
+// by DefaultHTTPClient, so that concurrent requests reuse connections rather than
+// opening new ones. opts may be nil, in which case default options are used.
+func NewHighThroughputClient(opts *HighThroughputOptions) *http.Client {
+	var o HighThroughputOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.MaxIdleConnsPerHost == 0 {
+		o.MaxIdleConnsPerHost = 100
+	}
+	if o.IdleConnTimeout == 0 {
+		o.IdleConnTimeout = 90 * time.Second
+	}
+	if o.Timeout == 0 {
+		o.Timeout = 5 * time.Second
+	}
+
+	var tr *http.Transport
+	if dt, ok := http.DefaultTransport.(*http.Transport); ok {
+		tr = dt.Clone()
+	} else {
+		tr = &http.Transport{}
+	}
+	// Idle connections are limited per node, not in total.
+	tr.MaxIdleConns = 0
+	tr.MaxIdleConnsPerHost = o.MaxIdleConnsPerHost
+	tr.MaxConnsPerHost = o.MaxConnsPerHost
+	tr.IdleConnTimeout = o.IdleConnTimeout
+	tr.ForceAttemptHTTP2 = o.ForceHTTP2
+	if o.TLSClientConfig != nil {
+		tr.TLSClientConfig = o.TLSClientConfig
+	}
+	return &http.Client{
+		Transport: tr,
+		Timeout:   o.Timeout,
+	}
+}
+
 // NewHTTPTLSClientInsecure returns an HTTP client configured for simple TLS, but
 // skipping server certificate verification. The client's timeout is
 // set as 5 seconds.
@@ -41,7 +117,18 @@ func NewHTTPTLSClientInsecure() (*http.Client, error) {
 // NewHTTPTLSClient returns an HTTP client configured for simple TLS, using the
 // provided CA certificate.
 func NewHTTPTLSClient(caCertPath string) (*http.Client, error) {
-	config := &tls.Config{}
+	return NewHTTPTLSClientWithServerName(caCertPath, "")
+}
+
+// NewHTTPTLSClientWithServerName returns an HTTP client configured for simple TLS,
+// using the provided CA certificate, which verifies the server's certificate against
+// serverName rather than the host in the request URL. This allows nodes to be
+// addressed by IP address while presenting certificates issued for a hostname. If
+// serverName is empty, the host in the request URL is used.
+func NewHTTPTLSClientWithServerName(caCertPath, serverName string) (*http.Client, error) {
+	config := &tls.Config{
+		ServerName: serverName,
+	}
 
 	asn1Data, err := os.ReadFile(caCertPath)
 	if err != nil {
@@ -90,6 +177,185 @@ func NewHTTPMutualTLSClient(clientCertPath, clientKeyPath, caCertPath string) (*
 	}, nil
 }
 
+// TLSClientOptions configures the HTTP client returned by NewHTTPTLSClientWithOptions.
+// Zero values select the defaults.
+type TLSClientOptions struct {
+	// RootCAs, if set, is the pool of CA certificates used to verify nodes'
+	// certificates. If none of RootCAs, CACertPath and CACertPEM is set, the system's
+	// pool is used.
+	RootCAs *x509.CertPool
+
+	// CACertPath, if set, is the path of a PEM file of CA certificates used to verify
+	// nodes' certificates. They are added to RootCAs, if that is also set.
+	CACertPath string
+
+	// CACertPEM, if set, holds PEM-encoded CA certificates used to verify nodes'
+	// certificates, for example as fetched from a secrets manager. They are added to
+	// RootCAs, if that is also set, along with any read from CACertPath.
+	CACertPEM []byte
+
+	// SystemRoots signals whether to add the CA certificates from CACertPath and
+	// CACertPEM to the system's pool, rather than trusting only those certificates.
+	// It is ignored if RootCAs is set.
+	SystemRoots bool
+
+	// ClientCertPath and ClientKeyPath, if set, are the paths of the certificate and
+	// key the client presents to nodes which require mutual TLS.
+	ClientCertPath string
+	ClientKeyPath  string
+
+	// ClientCertPEM and ClientKeyPEM, if set, are the PEM-encoded certificate and key
+	// the client presents to nodes which require mutual TLS.
+	ClientCertPEM []byte
+	ClientKeyPEM  []byte
+
+	// ClientCertificates, if set, are presented to nodes which require mutual TLS,
+	// along with any certificate loaded from ClientCertPath or ClientCertPEM.
+	ClientCertificates []tls.Certificate
+
+	// InsecureSkipVerify signals whether to skip verification of nodes' certificates.
+	InsecureSkipVerify bool
+
+	// MinVersion is the minimum TLS version to accept, for example tls.VersionTLS13.
+	// The default is TLS 1.2.
+	MinVersion uint16
+
+	// ServerName, if set, is the name nodes' certificates are verified against, rather
+	// than the host in the request URL.
+	ServerName string
+
+	// HTTP2 signals whether to use HTTP/2 with nodes which support it.
+	HTTP2 bool
+
+	// MaxIdleConnsPerHost, IdleConnTimeout and Timeout are as for
+	// HighThroughputOptions.
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+	Timeout             time.Duration
+
+	// TLSHandshakeTimeout is the maximum time to wait for a TLS handshake. The default
+	// is 10 seconds.
+	TLSHandshakeTimeout time.Duration
+}
+
+// NewHTTPTLSClientWithOptions returns an HTTP client configured for TLS as set by opts.
+// Connections are pooled as by NewHighThroughputClient, so concurrent requests reuse
+// connections rather than repeating TLS handshakes. opts may be nil, in which case
+// default options are used, verifying nodes' certificates against the system's pool.
+func NewHTTPTLSClientWithOptions(opts *TLSClientOptions) (*http.Client, error) {
+	var o TLSClientOptions
+	if opts != nil {
+		o = *opts
+	}
+	config := &tls.Config{
+		RootCAs:            o.RootCAs,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+		MinVersion:         o.MinVersion,
+		ServerName:         o.ServerName,
+	}
+	if config.MinVersion == 0 {
+		config.MinVersion = tls.VersionTLS12
+	}
+
+	var caPEMs [][]byte
+	if o.CACertPath != "" {
+		asn1Data, err := os.ReadFile(o.CACertPath)
+		if err != nil {
+			return nil, err
+		}
+		caPEMs = append(caPEMs, asn1Data)
+	}
+	if len(o.CACertPEM) > 0 {
+		caPEMs = append(caPEMs, o.CACertPEM)
+	}
+	if len(caPEMs) > 0 {
+		switch {
+		case config.RootCAs != nil:
+			config.RootCAs = config.RootCAs.Clone()
+		case o.SystemRoots:
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				return nil, err
+			}
+			config.RootCAs = pool
+		default:
+			config.RootCAs = x509.NewCertPool()
+		}
+		for _, b := range caPEMs {
+			if !config.RootCAs.AppendCertsFromPEM(b) {
+				return nil, fmt.Errorf("failed to append CA certs from PEM")
+			}
+		}
+	}
+
+	if o.ClientCertPath != "" || o.ClientKeyPath != "" {
+		cert, err := tls.LoadX509KeyPair(o.ClientCertPath, o.ClientKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = append(config.Certificates, cert)
+	}
+	if len(o.ClientCertPEM) > 0 || len(o.ClientKeyPEM) > 0 {
+		cert, err := tls.X509KeyPair(o.ClientCertPEM, o.ClientKeyPEM)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = append(config.Certificates, cert)
+	}
+	config.Certificates = append(config.Certificates, o.ClientCertificates...)
+
+	hc := NewHighThroughputClient(&HighThroughputOptions{
+		MaxIdleConnsPerHost: o.MaxIdleConnsPerHost,
+		IdleConnTimeout:     o.IdleConnTimeout,
+		ForceHTTP2:          o.HTTP2,
+		TLSClientConfig:     config,
+		Timeout:             o.Timeout,
+	})
+	tr := hc.Transport.(*http.Transport)
+	if !o.HTTP2 {
+		// A non-nil, empty TLSNextProto disables HTTP/2.
+		tr.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	if o.TLSHandshakeTimeout != 0 {
+		tr.TLSHandshakeTimeout = o.TLSHandshakeTimeout
+	} else if tr.TLSHandshakeTimeout == 0 {
+		tr.TLSHandshakeTimeout = 10 * time.Second
+	}
+	return hc, nil
+}
+
+// NewHTTPTLSClientFromPEM is like NewHTTPTLSClient, but takes the PEM-encoded CA
+// certificate rather than the path of a file holding it.
+func NewHTTPTLSClientFromPEM(caCertPEM []byte) (*http.Client, error) {
+	return NewHTTPTLSClientWithOptions(&TLSClientOptions{CACertPEM: caCertPEM})
+}
+
+// NewHTTPMutualTLSClientFromPEM is like NewHTTPMutualTLSClient, but takes the
+// PEM-encoded client certificate, client key, and trusted CA rather than the paths of
+// files holding them.
+func NewHTTPMutualTLSClientFromPEM(clientCertPEM, clientKeyPEM, caCertPEM []byte) (*http.Client, error) {
+	return NewHTTPTLSClientWithOptions(&TLSClientOptions{
+		CACertPEM:     caCertPEM,
+		ClientCertPEM: clientCertPEM,
+		ClientKeyPEM:  clientKeyPEM,
+	})
+}
+
+// NewHTTPUnixSocketClient returns an HTTP client which sends every request over the
+// Unix domain socket at socketPath, regardless of the host in the request URL. The
+// client's timeout is set as 5 seconds.
+func NewHTTPUnixSocketClient(socketPath string) *http.Client {
+	dialer := &net.Dialer{}
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, "unix", socketPath)
+			},
+		},
+		Timeout: 5 * time.Second,
+	}
+}
+
 // ExecuteResponse represents the JSON returned by /db/execute.
 type ExecuteResponse struct {
 	Results        []ExecuteResult `json:"results"`
@@ -114,6 +380,20 @@ func (er *ExecuteResponse) HasError() (bool, int, string) {
 	return false, -1, ""
 }
 
+// statementErrors returns every error in the response.
+func (er *ExecuteResponse) statementErrors() []error {
+	if er.Error != "" {
+		return []error{&StatementError{Index: -1, Message: er.Error}}
+	}
+	var errs []error
+	for i, result := range er.Results {
+		if result.Error != "" {
+			errs = append(errs, &StatementError{Index: i, Message: result.Error})
+		}
+	}
+	return errs
+}
+
 // ExecuteResult is an element of ExecuteResponse.Results.
 type ExecuteResult struct {
 	LastInsertID int64   `json:"last_insert_id"`
@@ -147,6 +427,158 @@ type QueryResult struct {
 	Error   string   `json:"error,omitempty"`
 }
 
+// DuplicateColumnMode controls how a QueryResult whose columns share a name, such as
+// the result of "SELECT a.id, b.id FROM a JOIN b", is converted to maps keyed by
+// column name.
+type DuplicateColumnMode int
+
+const (
+	// DuplicateColumnsSuffix renames the second and subsequent columns sharing a name
+	// by appending "_1", "_2", and so on, skipping any suffix which is itself the name
+	// of a column. For example, columns "id" and "id" become "id" and "id_1".
+	DuplicateColumnsSuffix DuplicateColumnMode = iota
+
+	// DuplicateColumnsError returns an error if any columns share a name.
+	DuplicateColumnsError
+)
+
+// UniqueColumns returns the names of the result's columns, in order, with duplicate
+// names handled according to mode.
+func (qr QueryResult) UniqueColumns(mode DuplicateColumnMode) ([]string, error) {
+	seen := make(map[string]bool, len(qr.Columns))
+	for _, col := range qr.Columns {
+		if seen[col] && mode == DuplicateColumnsError {
+			return nil, fmt.Errorf("duplicate column name: %s", col)
+		}
+		seen[col] = true
+	}
+	if len(seen) == len(qr.Columns) {
+		return qr.Columns, nil
+	}
+
+	names := make([]string, len(qr.Columns))
+	used := make(map[string]bool, len(qr.Columns))
+	for i, col := range qr.Columns {
+		name := col
+		for n := 1; used[name]; n++ {
+			name = fmt.Sprintf("%s_%d", col, n)
+			if seen[name] {
+				name = col
+			}
+		}
+		used[name] = true
+		names[i] = name
+	}
+	return names, nil
+}
+
+// AsMaps returns the rows of the result as maps of column name to value, with
+// duplicate column names handled according to mode.
+func (qr QueryResult) AsMaps(mode DuplicateColumnMode) ([]map[string]any, error) {
+	cols, err := qr.UniqueColumns(mode)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]map[string]any, len(qr.Values))
+	for i, vals := range qr.Values {
+		if len(vals) != len(cols) {
+			return nil, fmt.Errorf("row %d has %d values, expected %d", i, len(vals), len(cols))
+		}
+		row := make(map[string]any, len(cols))
+		for j, col := range cols {
+			row[col] = vals[j]
+		}
+		rows[i] = row
+	}
+	return rows, nil
+}
+
+// AsAssoc converts the result to the associative form, with duplicate column names
+// handled according to mode. When rqlite returns results in the associative form,
+// columns which share a name overwrite one another, so a query which may return
+// duplicate column names should be made in the default form and converted.
+func (qr QueryResult) AsAssoc(mode DuplicateColumnMode) (QueryResultAssoc, error) {
+	cols, err := qr.UniqueColumns(mode)
+	if err != nil {
+		return QueryResultAssoc{}, err
+	}
+	rows, err := qr.AsMaps(mode)
+	if err != nil {
+		return QueryResultAssoc{}, err
+	}
+	types := make(map[string]string, len(cols))
+	for i, col := range cols {
+		if i < len(qr.Types) {
+			types[col] = qr.Types[i]
+		}
+	}
+	return QueryResultAssoc{Types: types, Rows: rows, Time: qr.Time, Error: qr.Error}, nil
+}
+
+// Columnar returns the result in columnar form, with the values of each column
+// gathered into a slice. data[i] holds the values of columns[i], in row order, which
+// can be more convenient than Values for loading results into analytics tools. If a
+// row has fewer values than there are columns, the missing values are nil.
+func (qr QueryResult) Columnar() (columns []string, data [][]any) {
+	data = make([][]any, len(qr.Columns))
+	for i := range data {
+		data[i] = make([]any, len(qr.Values))
+	}
+	for r, vals := range qr.Values {
+		for i := range min(len(vals), len(data)) {
+			data[i][r] = vals[i]
+		}
+	}
+	return qr.Columns, data
+}
+
+// IndexBy groups the rows of the result by their value in column, returning each row
+// as a map of column name to value, as returned by AsMaps with DuplicateColumnsSuffix.
+// Rows sharing a key are returned in the order they appear in the result. An error is
+// returned if the result has no such column.
+//
+// Keys have the types of the decoded values, so an integer key is a json.Number,
+// text is a string, and NULL is nil.
+func (qr QueryResult) IndexBy(column string) (map[any][]map[string]any, error) {
+	idx := slices.Index(qr.Columns, column)
+	if idx == -1 {
+		return nil, fmt.Errorf("no such column: %s", column)
+	}
+	rows, err := qr.AsMaps(DuplicateColumnsSuffix)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[any][]map[string]any)
+	for i, row := range rows {
+		key := qr.Values[i][idx]
+		m[key] = append(m[key], row)
+	}
+	return m, nil
+}
+
+// DecodeBlobs replaces the values of the result's BLOB columns with []byte. rqlite
+// returns BLOB data as base64 strings, or as arrays of byte values if
+// QueryOptions.BlobAsArray is set, and both forms are decoded. NULL values are left
+// as nil.
+func (qr *QueryResult) DecodeBlobs() error {
+	for i, typ := range qr.Types {
+		if !isBlobType(typ) {
+			continue
+		}
+		for r, vals := range qr.Values {
+			if i >= len(vals) || vals[i] == nil {
+				continue
+			}
+			b, err := toBytes(vals[i], "blob")
+			if err != nil {
+				return fmt.Errorf("row %d, column %s: %w", r, qr.Columns[i], err)
+			}
+			vals[i] = b
+		}
+	}
+	return nil
+}
+
 // QueryResultAssoc is an element of QueryResponse.Results, but in an associative form.
 // This is returned by rqlite when the "associative" form is requested.
 type QueryResultAssoc struct {
@@ -156,6 +588,33 @@ type QueryResultAssoc struct {
 	Error string            `json:"error,omitempty"`
 }
 
+// DecodeBlobs replaces the values of the result's BLOB columns with []byte, as
+// QueryResult.DecodeBlobs does.
+func (qr *QueryResultAssoc) DecodeBlobs() error {
+	for col, typ := range qr.Types {
+		if !isBlobType(typ) {
+			continue
+		}
+		for r, row := range qr.Rows {
+			v, ok := row[col]
+			if !ok || v == nil {
+				continue
+			}
+			b, err := toBytes(v, "blob")
+			if err != nil {
+				return fmt.Errorf("row %d, column %s: %w", r, col, err)
+			}
+			row[col] = b
+		}
+	}
+	return nil
+}
+
+// isBlobType returns whether typ, the declared type of a column, is BLOB.
+func isBlobType(typ string) bool {
+	return strings.EqualFold(typ, "blob")
+}
+
 // HasError returns true if any of the results in the response contain an error.
 // If an error is found, the index of the result and the error message are returned.
 func (qr *QueryResponse) HasError() (bool, int, string) {
@@ -194,8 +653,48 @@ func (qr *QueryResponse) GetQueryResultsAssoc() []QueryResultAssoc {
 	return qr.Results.([]QueryResultAssoc)
 }
 
+// statementErrors returns every error in the response.
+func (qr *QueryResponse) statementErrors() []error {
+	if qr.Error != "" {
+		return []error{&StatementError{Index: -1, Message: qr.Error}}
+	}
+	var errs []error
+	switch v := qr.Results.(type) {
+	case []QueryResult:
+		for i, result := range v {
+			if result.Error != "" {
+				errs = append(errs, &StatementError{Index: i, Message: result.Error})
+			}
+		}
+	case []QueryResultAssoc:
+		for i, result := range v {
+			if result.Error != "" {
+				errs = append(errs, &StatementError{Index: i, Message: result.Error})
+			}
+		}
+	}
+	return errs
+}
+
+// numResults returns the number of results in the response.
+func (qr *QueryResponse) numResults() int {
+	switch v := qr.Results.(type) {
+	case []QueryResult:
+		return len(v)
+	case []QueryResultAssoc:
+		return len(v)
+	}
+	return 0
+}
+
 // UnmarshalJSON implements the json.Unmarshaler interface for QueryResponse.
 func (qr *QueryResponse) UnmarshalJSON(data []byte) error {
+	return qr.decode(StdCodec{}, data)
+}
+
+// decode unmarshals data into qr, decoding both the response and its results
+// with codec.
+func (qr *QueryResponse) decode(codec Codec, data []byte) error {
 	// Define an alias to avoid recursion.
 	type Alias QueryResponse
 	aux := &struct {
@@ -206,24 +705,25 @@ func (qr *QueryResponse) UnmarshalJSON(data []byte) error {
 	}
 
 	// Unmarshal into the auxiliary struct.
-	auxDec := json.NewDecoder(bytes.NewReader(data))
-	auxDec.UseNumber()
-	if err := auxDec.Decode(aux); err != nil {
+	if err := decodeJSON(codec, data, aux); err != nil {
 		return err
 	}
 
+	// A response carrying only a top-level error, such as a failed freshness
+	// check, may have no results.
+	if len(aux.Results) == 0 || string(aux.Results) == "null" {
+		qr.Results = []QueryResult{}
+		return nil
+	}
+
 	var res []QueryResult
-	resDec := json.NewDecoder(bytes.NewReader(aux.Results))
-	resDec.UseNumber()
-	if err := resDec.Decode(&res); err == nil {
+	if err := decodeJSON(codec, aux.Results, &res); err == nil {
 		qr.Results = res
 		return nil
 	}
 
 	var resAssoc []QueryResultAssoc
-	resAssocDec := json.NewDecoder(bytes.NewReader(aux.Results))
-	resAssocDec.UseNumber()
-	if err := resAssocDec.Decode(&resAssoc); err == nil {
+	if err := decodeJSON(codec, aux.Results, &resAssoc); err == nil {
 		qr.Results = resAssoc
 		return nil
 	}
@@ -279,6 +779,78 @@ func (rr *RequestResponse) GetRequestResultsAssoc() []RequestResultAssoc {
 	return rr.Results.([]RequestResultAssoc)
 }
 
+// QueryResults returns the results of the read statements in the request, in the
+// order they were sent. The returned value is a []QueryResult, or a []QueryResultAssoc
+// if the associative form was requested. Results which contain only an error are not
+// returned, as it is not known whether the failed statement was a read or a write.
+// Use HasError to check for errors.
+func (rr *RequestResponse) QueryResults() QueryResults {
+	switch v := rr.Results.(type) {
+	case []RequestResultAssoc:
+		results := []QueryResultAssoc{}
+		for _, r := range v {
+			if r.isWrite() || r.Error != "" {
+				continue
+			}
+			results = append(results, QueryResultAssoc{Types: r.Types, Rows: r.Rows, Time: r.Time})
+		}
+		return results
+	case []RequestResult:
+		results := []QueryResult{}
+		for _, r := range v {
+			if r.isWrite() || r.Error != "" {
+				continue
+			}
+			results = append(results, QueryResult{Columns: r.Columns, Types: r.Types, Values: r.Values, Time: r.Time})
+		}
+		return results
+	}
+	return []QueryResult{}
+}
+
+// ExecuteResults returns the results of the write statements in the request, in the
+// order they were sent. Results which contain only an error are not returned, as it
+// is not known whether the failed statement was a read or a write. Use HasError to
+// check for errors.
+func (rr *RequestResponse) ExecuteResults() []ExecuteResult {
+	results := []ExecuteResult{}
+	add := func(lastInsertID, rowsAffected *int64, t float64) {
+		er := ExecuteResult{Time: t}
+		if lastInsertID != nil {
+			er.LastInsertID = *lastInsertID
+		}
+		if rowsAffected != nil {
+			er.RowsAffected = *rowsAffected
+		}
+		results = append(results, er)
+	}
+	switch v := rr.Results.(type) {
+	case []RequestResult:
+		for _, r := range v {
+			if r.isWrite() {
+				add(r.LastInsertID, r.RowsAffected, r.Time)
+			}
+		}
+	case []RequestResultAssoc:
+		for _, r := range v {
+			if r.isWrite() {
+				add(r.LastInsertID, r.RowsAffected, r.Time)
+			}
+		}
+	}
+	return results
+}
+
+// isWrite returns whether the result is that of a write statement.
+func (r *RequestResult) isWrite() bool {
+	return r.LastInsertID != nil || r.RowsAffected != nil
+}
+
+// isWrite returns whether the result is that of a write statement.
+func (r *RequestResultAssoc) isWrite() bool {
+	return r.LastInsertID != nil || r.RowsAffected != nil
+}
+
 // HasError returns true if any of the results in the response contain an error.
 // If an error is found, the index of the result and the error message are returned.
 func (rr *RequestResponse) HasError() (bool, int, string) {
@@ -303,8 +875,70 @@ func (rr *RequestResponse) HasError() (bool, int, string) {
 	return false, -1, ""
 }
 
+// statementErrors returns every error in the response.
+func (rr *RequestResponse) statementErrors() []error {
+	if rr.Error != "" {
+		return []error{&StatementError{Index: -1, Message: rr.Error}}
+	}
+	var errs []error
+	switch v := rr.Results.(type) {
+	case []RequestResult:
+		for i, result := range v {
+			if result.Error != "" {
+				errs = append(errs, &StatementError{Index: i, Message: result.Error})
+			}
+		}
+	case []RequestResultAssoc:
+		for i, result := range v {
+			if result.Error != "" {
+				errs = append(errs, &StatementError{Index: i, Message: result.Error})
+			}
+		}
+	}
+	return errs
+}
+
+// dropResults removes the first n results from the response, returning an error
+// if any of them contains an error.
+func (rr *RequestResponse) dropResults(n int) error {
+	switch v := rr.Results.(type) {
+	case []RequestResult:
+		for i, r := range v[:min(n, len(v))] {
+			if r.Error != "" {
+				return fmt.Errorf("pre-statement %d: %s", i, r.Error)
+			}
+		}
+		rr.Results = v[min(n, len(v)):]
+	case []RequestResultAssoc:
+		for i, r := range v[:min(n, len(v))] {
+			if r.Error != "" {
+				return fmt.Errorf("pre-statement %d: %s", i, r.Error)
+			}
+		}
+		rr.Results = v[min(n, len(v)):]
+	}
+	return nil
+}
+
+// numResults returns the number of results in the response.
+func (rr *RequestResponse) numResults() int {
+	switch v := rr.Results.(type) {
+	case []RequestResult:
+		return len(v)
+	case []RequestResultAssoc:
+		return len(v)
+	}
+	return 0
+}
+
 // UnmarshalJSON implements the json.Unmarshaler interface for RequestResponse.
 func (rr *RequestResponse) UnmarshalJSON(data []byte) error {
+	return rr.decode(StdCodec{}, data)
+}
+
+// decode unmarshals data into rr, decoding both the response and its results
+// with codec.
+func (rr *RequestResponse) decode(codec Codec, data []byte) error {
 	// Define an alias to avoid recursion.
 	type Alias RequestResponse
 	aux := &struct {
@@ -315,24 +949,24 @@ func (rr *RequestResponse) UnmarshalJSON(data []byte) error {
 	}
 
 	// Unmarshal into the auxiliary struct.
-	auxDec := json.NewDecoder(bytes.NewReader(data))
-	auxDec.UseNumber()
-	if err := auxDec.Decode(aux); err != nil {
+	if err := decodeJSON(codec, data, aux); err != nil {
 		return err
 	}
 
+	// A response carrying only a top-level error may have no results.
+	if len(aux.Results) == 0 || string(aux.Results) == "null" {
+		rr.Results = []RequestResult{}
+		return nil
+	}
+
 	var res []RequestResult
-	resDec := json.NewDecoder(bytes.NewReader(aux.Results))
-	resDec.UseNumber()
-	if err := resDec.Decode(&res); err == nil {
+	if err := decodeJSON(codec, aux.Results, &res); err == nil {
 		rr.Results = res
 		return nil
 	}
 
 	var resAssoc []RequestResultAssoc
-	resAssocDec := json.NewDecoder(bytes.NewReader(aux.Results))
-	resAssocDec.UseNumber()
-	if err := resAssocDec.Decode(&resAssoc); err == nil {
+	if err := decodeJSON(codec, aux.Results, &resAssoc); err == nil {
 		rr.Results = resAssoc
 		return nil
 	}
@@ -340,6 +974,16 @@ func (rr *RequestResponse) UnmarshalJSON(data []byte) error {
 	return fmt.Errorf("unable to unmarshal results into either []RequestResult or []RequestResultAssoc")
 }
 
+// jsonContentType is the media type of JSON request and response bodies. It is sent
+// without a charset parameter, as some proxies reject one.
+const jsonContentType = "application/json"
+
+// jsonAcceptHeader is sent with requests whose response body is JSON.
+var jsonAcceptHeader = http.Header{"Accept": {jsonContentType}}
+
+// unixScheme is the URL scheme identifying a Unix domain socket.
+const unixScheme = "unix"
+
 const (
 	executePath = "/db/execute"
 	queryPath   = "/db/query"
@@ -360,111 +1004,811 @@ type LoadBalancer interface {
 	Next() (*url.URL, error)
 }
 
-// Client is the main type through which rqlite is accessed.
-type Client struct {
-	lb         LoadBalancer
-	httpClient *http.Client
+// LeaderAwareBalancer is a LoadBalancer which knows which of its hosts is the Leader.
+// It is required by routing policies other than RoutingPolicyAny.
+type LeaderAwareBalancer interface {
+	LoadBalancer
 
-	promoteErrors atomic.Bool
+	// Leader returns the URL of the Leader.
+	Leader() (*url.URL, error)
 
-	mu            sync.RWMutex
-	basicAuthUser string
-	basicAuthPass string
+	// Follower returns the URL of a healthy node which is not the Leader.
+	Follower() (*url.URL, error)
 }
 
-// NewClient creates a new Client with default settings. If httpClient is nil,
-// the the default client is used.
-func NewClient(baseURL string, httpClient *http.Client) (*Client, error) {
-	lb, err := NewLoopbackBalancer(baseURL)
-	if err != nil {
-		return nil, err
-	}
+// OutcomeRecorder is a LoadBalancer which is told the outcome of each request the
+// client sends to one of its hosts. A request fails if the host could not be reached,
+// or if it responded with a 5xx status code. Requests abandoned because their context
+// was cancelled are not reported.
+type OutcomeRecorder interface {
+	LoadBalancer
 
-	cl := &Client{
-		lb:         lb,
-		httpClient: httpClient,
-	}
-	if cl.httpClient == nil {
-		cl.httpClient = DefaultHTTPClient()
-	}
-	return cl, nil
-}
+	// RecordSuccess records that a request to u succeeded.
+	RecordSuccess(u *url.URL)
 
-// SetBasicAuth configures the client to use Basic Auth for all subsequent requests.
-// Pass empty strings to disable Basic Auth.
-func (c *Client) SetBasicAuth(username, password string) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.basicAuthUser = username
-	c.basicAuthPass = password
+	// RecordFailure records that a request to u failed.
+	RecordFailure(u *url.URL)
 }
 
-// PromoteErrors enables or disables the promotion of statement-level errors to Go errors.
-//
-// By default an operation on the client only returns an error if there is a failure at
-// the HTTP level and it is up to the caller to inspect the response body for statement-level
-// errors.
-//
-// However if this method is called with true, then the client will also inspect the response
-// body and return an error if there is any failure at the statement level, setting the returned
-// error to the first statement-level error encountered.
-func (c *Client) PromoteErrors(b bool) {
-	c.promoteErrors.Store(b)
+// LatencyRecorder is a LoadBalancer which is told how long each request the client
+// sends to one of its hosts took, from sending the request to receiving the response
+// headers. Requests for which no response was received are not reported.
+type LatencyRecorder interface {
+	LoadBalancer
+
+	// RecordLatency records that a request to u took d.
+	RecordLatency(u *url.URL, d time.Duration)
 }
 
-// ExecuteSingle performs a single write operation (INSERT, UPDATE, DELETE) using /db/execute.
-// args should be a single map of named parameters, or a slice of positional parameters.
-// It is the caller's responsibility to ensure the correct number and type of parameters.
-func (c *Client) ExecuteSingle(ctx context.Context, statement string, args ...any) (*ExecuteResponse, error) {
-	stmt, err := NewSQLStatement(statement, args...)
-	if err != nil {
-		return nil, err
-	}
-	return c.Execute(ctx, SQLStatements{stmt}, nil)
+// RedirectRecorder is a LoadBalancer which is told when a request the client sent to
+// one of its hosts was redirected to another node. Nodes redirect requests which must
+// be served by the Leader, so the target of a redirect is usually the Leader.
+type RedirectRecorder interface {
+	LoadBalancer
+
+	// RecordRedirect records that a request to from was redirected to to.
+	RecordRedirect(from, to *url.URL)
 }
 
-// Execute executes one or more SQL statements (INSERT, UPDATE, DELETE) using /db/execute.
+// RoutingPolicy controls how the client chooses the node to which a request is sent.
+type RoutingPolicy int
+
+const (
+	// RoutingPolicyAny sends every request to the host returned by the balancer's Next().
+	RoutingPolicyAny RoutingPolicy = iota
+
+	// RoutingPolicyPreferFollowerReads sends queries which do not require the Leader to
+	// a Follower, and writes to the Leader. Queries at the Strong and Linearizable read
+	// consistency levels are sent to the Leader. If the client's balancer is not a
+	// LeaderAwareBalancer this policy has no effect.
+	RoutingPolicyPreferFollowerReads
+
+	// RoutingPolicyFollowerNoneReads sends queries at the None read consistency level
+	// to a Follower, spreading read load across the cluster, and every other request to
+	// the Leader, including queries at other levels, which the Leader must serve. If the
+	// client's balancer is not a LeaderAwareBalancer this policy has no effect.
+	RoutingPolicyFollowerNoneReads
+)
+
+// route identifies the type of node a request should be sent to.
+type route int
+
+const (
+	routeAny route = iota
+	routeLeader
+	routeFollower
+)
+
+// ErrNoRows is returned by QueryScalar when a query returns no rows.
+var ErrNoRows = errors.New("no rows in result set")
+
+// StatementError is a statement-level error reported by rqlite, and promoted to a
+// Go error by the client.
+type StatementError struct {
+	// Index is the index of the statement which failed, or -1 if the error
+	// applies to the request as a whole.
+	Index int
+
+	// SQL is the SQL of the statement which failed, if known.
+	SQL string
+
+	// Message is the error message returned by rqlite.
+	Message string
+}
+
+// Error implements the error interface.
+func (e *StatementError) Error() string {
+	return fmt.Sprintf("statement %d: %s", e.Index, e.Message)
+}
+
+// Is reports whether the error matches target. Every StatementError matches
+// ErrStatementError, and a StatementError matches ErrNotLeader if the node reported
+// that it is not the Leader.
+func (e *StatementError) Is(target error) bool {
+	switch target {
+	case ErrStatementError:
+		return true
+	case ErrNotLeader:
+		return isNotLeader(e.Message)
+	}
+	return false
+}
+
+var (
+	// ErrStatementError is matched, using errors.Is, by every *StatementError.
+	ErrStatementError = errors.New("statement error")
+
+	// ErrUnexpectedStatusCode is matched, using errors.Is, by every *HTTPError.
+	ErrUnexpectedStatusCode = errors.New("unexpected status code")
+
+	// ErrNotLeader is matched, using errors.Is, by errors reporting that a request
+	// which must be served by the Leader was sent to another node. These include
+	// a *RedirectError, and errors whose message from the node says it is not the
+	// Leader.
+	ErrNotLeader = errors.New("not leader")
+
+	// ErrUnavailable is matched, using errors.Is, by an *HTTPError reporting that the
+	// node, or a proxy in front of it, could not serve the request, with a 502, 503,
+	// or 504 status code. Such requests may succeed if retried.
+	ErrUnavailable = errors.New("node unavailable")
+)
+
+// isNotLeader returns whether msg, an error message from a node, reports that the
+// node is not the Leader.
+func isNotLeader(msg string) bool {
+	return strings.Contains(strings.ToLower(msg), "not leader")
+}
+
+// errExecuteStatementTimeout is returned when statements sent to /db/execute set a Timeout.
+var errExecuteStatementTimeout = errors.New("statement timeouts are not supported by /db/execute")
+
+// ErrTooManyRequests is returned when the client is configured to fail fast and
+// the maximum number of concurrent requests has been reached.
+var ErrTooManyRequests = errors.New("too many concurrent requests")
+
+// ErrQueueFull is matched, using errors.Is, by the error returned when the node rejects
+// a request because it is overloaded, for example because its queue of writes is full.
+// Callers should slow down before sending more requests. If retries are enabled with
+// SetMaxRetries, such requests are retried after a backoff.
+var ErrQueueFull = errors.New("queue full")
+
+// isQueueFull returns whether a response with the given status code and body signals
+// that the node is applying backpressure.
+func isQueueFull(statusCode int, body []byte) bool {
+	if statusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if statusCode != http.StatusServiceUnavailable {
+		return false
+	}
+	b := bytes.ToLower(body)
+	return bytes.Contains(b, []byte("queue")) && bytes.Contains(b, []byte("full"))
+}
+
+// HTTPError is returned when the node responds with an unexpected HTTP status code.
+type HTTPError struct {
+	// StatusCode is the HTTP status code returned by the node.
+	StatusCode int
+
+	// Body is the body of the response.
+	Body []byte
+
+	// RQLiteError is the error message returned by rqlite, if the body contained
+	// a JSON object with an "error" field.
+	RQLiteError string
+
+	// Statements and Params are copies of the statements and URL query parameters
+	// of the request which failed, so that it can be replayed. They are only set for
+	// Execute, Query, and Request, and only if enabled by Client.SetVerboseErrors.
+	// Credentials, which are sent as a header, are never included.
+	Statements SQLStatements
+	Params     url.Values
+}
+
+func newHTTPError(statusCode int, body []byte) *HTTPError {
+	e := &HTTPError{
+		StatusCode: statusCode,
+		Body:       body,
+	}
+	var v struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &v); err == nil {
+		e.RQLiteError = v.Error
+	}
+	return e
+}
+
+// withRequest attaches copies of the statements and parameters of the failed request
+// to e, if verbose errors are enabled.
+func (c *Client) withRequest(e *HTTPError, statements SQLStatements, params url.Values) *HTTPError {
+	if !c.verboseErrors.Load() {
+		return e
+	}
+	e.Statements = make(SQLStatements, len(statements))
+	for i, stmt := range statements {
+		cp := *stmt
+		cp.PositionalParams = slices.Clone(stmt.PositionalParams)
+		cp.NamedParams = maps.Clone(stmt.NamedParams)
+		e.Statements[i] = &cp
+	}
+	e.Params = make(url.Values, len(params))
+	for k, v := range params {
+		e.Params[k] = slices.Clone(v)
+	}
+	return e
+}
+
+// Is reports whether the error matches target. Every HTTPError matches
+// ErrUnexpectedStatusCode. An HTTPError matches ErrQueueFull if the node signaled
+// backpressure, ErrUnavailable if the node, or a proxy in front of it, reported that
+// it could not serve the request, and ErrNotLeader if the node reported that it is not
+// the Leader.
+func (e *HTTPError) Is(target error) bool {
+	switch target {
+	case ErrUnexpectedStatusCode:
+		return true
+	case ErrQueueFull:
+		return isQueueFull(e.StatusCode, e.Body)
+	case ErrUnavailable:
+		switch e.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+	case ErrNotLeader:
+		return isNotLeader(string(e.Body))
+	}
+	return false
+}
+
+// Error implements the error interface.
+func (e *HTTPError) Error() string {
+	if e.RQLiteError != "" {
+		return fmt.Sprintf("unexpected status code: %d, error: %s", e.StatusCode, e.RQLiteError)
+	}
+	return fmt.Sprintf("unexpected status code: %d, body: %s", e.StatusCode, e.Body)
+}
+
+// RedirectError is returned when the node responds with a redirect and the client
+// has been configured not to follow redirects.
+type RedirectError struct {
+	// StatusCode is the HTTP status code returned by the node.
+	StatusCode int
+
+	// Location is the URL to which the node redirected the request.
+	Location string
+}
+
+// Error implements the error interface.
+func (e *RedirectError) Error() string {
+	return fmt.Sprintf("redirected with status code %d to %s", e.StatusCode, e.Location)
+}
+
+// Is reports whether the error matches target. A RedirectError matches ErrNotLeader, as
+// nodes redirect requests which must be served by the Leader.
+func (e *RedirectError) Is(target error) bool {
+	return target == ErrNotLeader
+}
+
+const (
+	// BodyKindRequest is passed to a BodyRecorder with an outgoing request body.
+	BodyKindRequest = "request"
+
+	// BodyKindResponse is passed to a BodyRecorder with an incoming response body.
+	BodyKindResponse = "response"
+)
+
+// BodyRecorder is a function which is passed the body of each outgoing request
+// and incoming response. kind is either BodyKindRequest or BodyKindResponse.
+type BodyRecorder func(kind string, data []byte)
+
+// Client is the main type through which rqlite is accessed.
+type Client struct {
+	lb         LoadBalancer
+	httpClient *http.Client
+
+	promoteErrors       atomic.Bool
+	promoteJoinedErrors atomic.Bool
+	strictResultCount   atomic.Bool
+	noFollowRedirects   atomic.Bool
+	timeoutFromCtx      atomic.Bool
+	inFlight            atomic.Int64
+	routingPolicy       atomic.Int32
+	streamBodies        atomic.Bool
+	retryStaleReads     atomic.Bool
+	verboseErrors       atomic.Bool
+	singleflight        atomic.Bool
+	maxRetries          atomic.Int32
+
+	mu            sync.RWMutex
+	basicAuthUser string
+	basicAuthPass string
+	authProvider  AuthProvider
+	metrics       Metrics
+	middleware    []Middleware
+	logger        *slog.Logger
+	leader        *url.URL
+	mwClient      *http.Client
+	recorder      BodyRecorder
+	recorderLimit int
+	durFormat     DurationFormat
+	classifier    StatementClassifier
+	preStmts      SQLStatements
+	codec         Codec
+	sem           chan struct{}
+	semFailFast   bool
+	defQueryOpts  *QueryOptions
+	defExecOpts   *ExecuteOptions
+	defReqOpts    *RequestOptions
+	retryPolicy   *RetryPolicy
+
+	flights flightGroup
+
+	kaMu     sync.Mutex
+	kaCancel context.CancelFunc
+	kaWg     sync.WaitGroup
+
+	// closeBalancer, if set, closes a balancer created for the client.
+	closeBalancer func()
+}
+
+// NewClient creates a new Client which sends requests to the node at baseURL,
+// configured by opts. Nil options are ignored. Unless the WithHTTPClient option is
+// given, the default HTTP client is used.
+//
+// baseURL may use the "unix" scheme, for example "unix:///var/run/rqlite.sock", to
+// connect to a node listening on a Unix domain socket. In that case, unless the
+// WithHTTPClient option is given, a client created by NewHTTPUnixSocketClient is used.
+func NewClient(baseURL string, opts ...ClientOption) (*Client, error) {
+	cl := &Client{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(cl)
+		}
+	}
+	if cl.lb == nil {
+		lb, err := NewLoopbackBalancer(baseURL)
+		if err != nil {
+			return nil, err
+		}
+		if cl.httpClient == nil && lb.u.Scheme == unixScheme {
+			cl.httpClient = NewHTTPUnixSocketClient(lb.u.Path)
+		}
+		cl.lb = lb
+	}
+	if cl.httpClient == nil {
+		cl.httpClient = DefaultHTTPClient()
+	}
+	return cl, nil
+}
+
+// NewClientWithBalancer creates a new Client which sends requests to the hosts
+// chosen by lb. If httpClient is nil, the default client is used.
+func NewClientWithBalancer(lb LoadBalancer, httpClient *http.Client) (*Client, error) {
+	if lb == nil {
+		return nil, fmt.Errorf("load balancer must not be nil")
+	}
+	return NewClient("", WithBalancer(lb), WithHTTPClient(httpClient))
+}
+
+// SetBasicAuth configures the client to use Basic Auth for all subsequent requests.
+// Pass empty strings to disable Basic Auth.
+func (c *Client) SetBasicAuth(username, password string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.basicAuthUser = username
+	c.basicAuthPass = password
+}
+
+// AuthProvider authenticates requests made by a Client, for example by setting an
+// Authorization header carrying a token which it refreshes as needed.
+type AuthProvider interface {
+	// Authenticate is called before each request is sent, including retries, and
+	// may modify the request's headers. If it returns an error, the request is not
+	// sent and the error is returned.
+	Authenticate(req *http.Request) error
+}
+
+// AuthProviderFunc is an adapter allowing an ordinary function to be used as an
+// AuthProvider.
+type AuthProviderFunc func(req *http.Request) error
+
+// Authenticate calls f(req).
+func (f AuthProviderFunc) Authenticate(req *http.Request) error {
+	return f(req)
+}
+
+// SetAuthProvider configures the client to authenticate all subsequent requests with
+// p. An Authorization header set by p takes precedence over credentials set with
+// SetBasicAuth, and is itself overridden by credentials or headers attached to a
+// request's context with WithCredentials or WithHeaders. Pass nil to remove the
+// provider.
+func (c *Client) SetAuthProvider(p AuthProvider) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.authProvider = p
+}
+
+// SetBearerToken configures the client to send token as a bearer token with all
+// subsequent requests. It replaces any AuthProvider. Pass an empty string to disable
+// bearer authentication.
+func (c *Client) SetBearerToken(token string) {
+	if token == "" {
+		c.SetAuthProvider(nil)
+		return
+	}
+	c.SetAuthProvider(AuthProviderFunc(func(req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}))
+}
+
+// SetDurationFormat sets how durations in options, such as timeouts, are formatted
+// when sent to the node. The default is DurationFormatGo, and DurationFormatSeconds
+// may be used for servers which expect a bare number of seconds.
+func (c *Client) SetDurationFormat(f DurationFormat) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.durFormat = f
+}
+
+// SetTimeoutFromContext controls whether Execute, Query, and Request derive the
+// timeout sent to the node from the context's deadline, when no Timeout option is
+// set. To allow for clock skew and network latency, the derived timeout is somewhat
+// less than the time remaining until the deadline.
+func (c *Client) SetTimeoutFromContext(b bool) {
+	c.timeoutFromCtx.Store(b)
+}
+
+// StreamRequestBodies enables or disables streaming of SQL statements to the node.
+//
+// By default the client marshals all statements into memory before sending a request.
+// If this method is called with true, statements are instead marshaled one at a time
+// as the request body is sent, which reduces peak memory use for very large batches.
+// If a statement cannot be marshaled, the request is aborted and an error returned.
+func (c *Client) StreamRequestBodies(b bool) {
+	c.streamBodies.Store(b)
+}
+
+// SetRoutingPolicy sets the policy the client uses to choose the node to which each
+// request is sent. The default is RoutingPolicyAny.
+func (c *Client) SetRoutingPolicy(p RoutingPolicy) {
+	c.routingPolicy.Store(int32(p))
+}
+
+// SetCodec sets the JSON implementation the client uses to marshal requests and
+// decode responses. Pass nil to restore the default, StdCodec.
+func (c *Client) SetCodec(codec Codec) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.codec = codec
+}
+
+// SetPreStatements sets statements which are sent ahead of the caller's statements in
+// every request made using /db/request, such as "PRAGMA foreign_keys=ON". As the
+// node keeps no state between HTTP requests, this emulates running the statements
+// once per session. Pass nil to stop sending pre-statements.
+//
+// The pre-statements are executed by the node on every request, adding to the cost of
+// each. Their results are removed from the response, so the results correspond to the
+// caller's statements. If a pre-statement fails, an error is returned.
+func (c *Client) SetPreStatements(stmts []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(stmts) == 0 {
+		c.preStmts = nil
+		return
+	}
+	c.preStmts = NewSQLStatementsFromStrings(stmts)
+}
+
+// SetFollowRedirects controls whether the client follows HTTP redirects returned
+// by the node. Redirects are followed by default. If b is false, a redirect is
+// instead returned as a *RedirectError carrying the redirect location.
+func (c *Client) SetFollowRedirects(b bool) {
+	c.noFollowRedirects.Store(!b)
+}
+
+// SetBodyRecorder configures the client to pass the body of every outgoing request
+// and incoming response to fn. This can be useful for debugging, or for capturing
+// fixtures for tests. fn is called once the body has been fully read or closed, and
+// may be called concurrently. Pass nil to disable recording.
+func (c *Client) SetBodyRecorder(fn BodyRecorder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.recorder = fn
+}
+
+// SetBodyRecorderLimit sets the maximum number of bytes of each body passed to
+// the recorder set by SetBodyRecorder. Bodies larger than n are truncated. A value
+// of 0, the default, means no limit.
+func (c *Client) SetBodyRecorderLimit(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.recorderLimit = n
+}
+
+// SetMaxConcurrentRequests limits the number of requests the client will have
+// outstanding to the node at any one time. A request counts against the limit until
+// its response body is closed, so a streamed response, such as from QueryIter or
+// Backup, holds its slot until the caller closes it. If the limit is reached, further requests block
+// until a slot is available or their context is done. If failFast is true, they
+// instead return ErrTooManyRequests immediately. Pass 0 to remove the limit.
+func (c *Client) SetMaxConcurrentRequests(n int, failFast bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sem = nil
+	if n > 0 {
+		c.sem = make(chan struct{}, n)
+	}
+	c.semFailFast = failFast
+}
+
+// SetKeepAlive configures the client to ping the node's /readyz endpoint every d, which
+// keeps idle connections to the node warm and detects dead connections before they are
+// needed. This can be useful for long-lived clients with sparse traffic. Pass 0 to stop
+// pinging. Pinging also stops when the client is closed.
+func (c *Client) SetKeepAlive(d time.Duration) {
+	c.kaMu.Lock()
+	defer c.kaMu.Unlock()
+	c.stopKeepAlive()
+	if d <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.kaCancel = cancel
+	c.kaWg.Add(1)
+	go c.keepAlive(ctx, d)
+}
+
+// InFlight returns the number of requests currently awaiting a response from the node.
+func (c *Client) InFlight() int {
+	return int(c.inFlight.Load())
+}
+
+// PromoteErrors enables or disables the promotion of statement-level errors to Go errors.
+//
+// By default an operation on the client only returns an error if there is a failure at
+// the HTTP level and it is up to the caller to inspect the response body for statement-level
+// errors.
+//
+// However if this method is called with true, then the client will also inspect the response
+// body and return an error if there is any failure at the statement level, setting the returned
+// error to the first statement-level error encountered. The response is still returned
+// alongside the error, so results of statements which succeeded remain accessible.
+func (c *Client) PromoteErrors(b bool) {
+	c.promoteErrors.Store(b)
+}
+
+// PromoteJoinedErrors is like PromoteErrors, but the returned error joins every
+// statement-level error in the response, rather than only the first. Each joined
+// error is a *StatementError. The response is returned alongside the error, so
+// callers can use the results of the statements which succeeded. If both this
+// and PromoteErrors are enabled, this takes precedence.
+func (c *Client) PromoteJoinedErrors(b bool) {
+	c.promoteJoinedErrors.Store(b)
+}
+
+// StrictResultCount enables or disables checking that the number of results in a
+// response matches the number of statements sent.
+//
+// By default the client does not perform this check, and a response which contains
+// fewer results than statements sent (for example because the server failed part way
+// through a batch) is returned to the caller as-is. If this method is called with true,
+// then the client will return an error if the counts do not match. The check is not
+// performed for queued writes, or if the response contains a top-level error.
+func (c *Client) StrictResultCount(b bool) {
+	c.strictResultCount.Store(b)
+}
+
+// SetVerboseErrors enables or disables attaching the statements and URL query
+// parameters of a failed Execute, Query, or Request to the returned *HTTPError, for
+// example so support tooling can replay the request. It is disabled by default, as
+// statements and their parameters may contain sensitive data.
+func (c *Client) SetVerboseErrors(b bool) {
+	c.verboseErrors.Store(b)
+}
+
+// SetMaxRetries sets the number of times the client retries a failed Execute, Query,
+// or Request, each retry being sent to the node chosen by the balancer. By default
+// requests are not retried. SetRetryPolicy allows retries to be configured further,
+// and its policy, if set, takes precedence.
+//
+// Only requests which are safe to retry are retried. A request which may write to the
+// database is only retried if it could not be sent, for example because the connection
+// to the node was refused, or the node signaled backpressure, in which case the retry
+// is delayed by an exponential backoff. A read-only request is also retried if the
+// connection failed after the request was sent, or if a 502, 503, or 504 status code
+// was returned. Queries are always read-only, and a Request is read-only if
+// RequestOptions.ReadOnly is set. Requests whose bodies are streamed, as enabled by
+// StreamRequestBodies, are never retried.
+func (c *Client) SetMaxRetries(n int) {
+	c.maxRetries.Store(int32(max(n, 0)))
+}
+
+// RetryStaleReads enables or disables retrying reads which fail because the node's
+// data is staler than allowed by QueryOptions.Freshness.
+//
+// By default such a read returns the node's "stale read" error. If this method is
+// called with true, the client instead retries the read once at the Weak consistency
+// level, which is served by the Leader, so the caller receives up-to-date results.
+// The retry is sent to the Leader directly if the client's balancer can identify it.
+func (c *Client) RetryStaleReads(b bool) {
+	c.retryStaleReads.Store(b)
+}
+
+// SetDefaultQueryOptions sets options which are merged into the options of every
+// subsequent query, such as to always set Level or Timings. A field of the options
+// passed to a call takes the value set here if it is unset, that is if it has its
+// zero value. The fields selecting the read consistency, Level, LinearizableTimeout,
+// Freshness, FreshnessStrict, and NoLeader, are merged as a unit: if any of them is
+// set for a call, all of them are taken from the call, and otherwise all of them are
+// taken from the defaults. To turn off a bool set here for a single call, set
+// NoDefaults in the call's options, which are then used as given. opts is copied.
+// Pass nil to remove the defaults.
+func (c *Client) SetDefaultQueryOptions(opts *QueryOptions) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.defQueryOpts = clonePtr(opts)
+}
+
+// DefaultQueryOptions returns a copy of the client's default query options, as set by
+// SetDefaultQueryOptions, or the zero value if none are set. It allows a caller which
+// must force some options to start from the defaults, setting NoDefaults on the
+// result so that the forced options are used as given.
+func (c *Client) DefaultQueryOptions() QueryOptions {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.defQueryOpts == nil {
+		return QueryOptions{}
+	}
+	return *c.defQueryOpts
+}
+
+// SetDefaultExecuteOptions is like SetDefaultQueryOptions, but sets options merged
+// into the options of every subsequent write.
+func (c *Client) SetDefaultExecuteOptions(opts *ExecuteOptions) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.defExecOpts = clonePtr(opts)
+}
+
+// SetDefaultRequestOptions is like SetDefaultQueryOptions, but sets options merged
+// into the options of every subsequent Request.
+func (c *Client) SetDefaultRequestOptions(opts *RequestOptions) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.defReqOpts = clonePtr(opts)
+}
+
+// queryOptions returns opts merged with the client's default query options.
+func (c *Client) queryOptions(opts *QueryOptions) *QueryOptions {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return mergeOptions(c.defQueryOpts, opts)
+}
+
+// executeOptions returns opts merged with the client's default execute options.
+func (c *Client) executeOptions(opts *ExecuteOptions) *ExecuteOptions {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return mergeOptions(c.defExecOpts, opts)
+}
+
+// requestOptions returns opts merged with the client's default request options.
+func (c *Client) requestOptions(opts *RequestOptions) *RequestOptions {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return mergeOptions(c.defReqOpts, opts)
+}
+
+// plainQueryOptions returns opts merged with the client's default query options,
+// requesting the default form of results. It is used by methods which decode the
+// results of a query themselves. NoDefaults is set on the options returned, so the
+// defaults are not merged into them again.
+func (c *Client) plainQueryOptions(opts *QueryOptions) *QueryOptions {
+	var o QueryOptions
+	if opts := c.queryOptions(opts); opts != nil {
+		o = *opts
+	}
+	o.Associative = false
+	o.NoDefaults = true
+	return &o
+}
+
+// ExecuteSingle performs a single write operation (INSERT, UPDATE, DELETE) using /db/execute.
+// args should be a single map of named parameters, or a slice of positional parameters.
+// It is the caller's responsibility to ensure the correct number and type of parameters.
+func (c *Client) ExecuteSingle(ctx context.Context, statement string, args ...any) (*ExecuteResponse, error) {
+	stmt, err := NewSQLStatement(statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	return c.Execute(ctx, SQLStatements{stmt}, nil)
+}
+
+// Execute executes one or more SQL statements (INSERT, UPDATE, DELETE) using /db/execute.
 // opts may be nil, in which case default options are used.
-func (c *Client) Execute(ctx context.Context, statements SQLStatements, opts *ExecuteOptions) (retEr *ExecuteResponse, retErr error) {
-	body, err := statements.MarshalJSON()
+func (c *Client) Execute(ctx context.Context, statements SQLStatements, opts *ExecuteOptions) (*ExecuteResponse, error) {
+	return c.execute(ctx, statements, c.executeOptions(opts))
+}
+
+// execute is like Execute, but does not merge opts with the client's default options.
+func (c *Client) execute(ctx context.Context, statements SQLStatements, opts *ExecuteOptions) (retEr *ExecuteResponse, retErr error) {
+	body, closeBody, err := c.statementsBody(statements)
 	if err != nil {
 		return nil, err
 	}
-	queryParams, err := makeURLValues(opts)
+	defer closeBody()
+	if d, _ := statements.timeout(); d != 0 {
+		return nil, errExecuteStatementTimeout
+	}
+	queryParams, err := c.makeURLValues(opts)
 	if err != nil {
 		return nil, err
 	}
+	c.setTimeoutFromContext(ctx, queryParams)
 
-	resp, err := c.doJSONPostRequest(ctx, executePath, queryParams, bytes.NewReader(body))
+	resp, err := c.doJSONPostRequest(ctx, c.routeWrite(), executePath, queryParams, body, false)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err := readAll(ctx, resp.Body)
 	if err != nil {
 		return nil, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, respBody)
+		return nil, c.withRequest(newHTTPError(resp.StatusCode, respBody), statements, queryParams)
 	}
 
 	var executeResp ExecuteResponse
-	execRespDec := json.NewDecoder(bytes.NewReader(respBody))
-	execRespDec.UseNumber()
-	if err := execRespDec.Decode(&executeResp); err != nil {
+	if err := decodeJSON(c.getCodec(), respBody, &executeResp); err != nil {
 		return nil, err
 	}
 
-	if c.promoteErrors.Load() {
-		if f, i, msg := executeResp.HasError(); f {
-			retErr = fmt.Errorf("statement %d: %s", i, msg)
+	c.logStatementErrors(ctx, executePath, &executeResp)
+	if c.strictResultCount.Load() && executeResp.Error == "" && (opts == nil || !opts.Queue) {
+		if err := checkResultCount(len(statements), len(executeResp.Results)); err != nil {
+			return &executeResp, err
 		}
 	}
+	retErr = c.promotedError(&executeResp, statements)
 	return &executeResp, retErr
 }
 
+// QueuedExecute queues one or more SQL statements for execution using /db/execute,
+// returning the sequence number the node assigned to them. opts may be nil, in which
+// case default options are used. Queue is always set, and Wait is ignored.
+//
+// The node responds once the statements are queued, before they are written to the
+// database. WaitForSequenceNumber can be used to wait until they have been persisted.
+func (c *Client) QueuedExecute(ctx context.Context, statements SQLStatements, opts *ExecuteOptions) (int64, error) {
+	var o ExecuteOptions
+	if opts := c.executeOptions(opts); opts != nil {
+		o = *opts
+	}
+	o.Queue = true
+	o.Wait = false
+	resp, err := c.execute(ctx, statements, &o)
+	if err != nil {
+		return 0, err
+	}
+	if resp.Error != "" {
+		return 0, errors.New(resp.Error)
+	}
+	return resp.SequenceNumber, nil
+}
+
+// queueFlushStatement is the statement WaitForQueue queues. It changes nothing.
+const queueFlushStatement = "SELECT 1"
+
+// WaitForQueue waits until every write queued on the node before the call, with
+// QueuedExecute or ExecuteOptions.Queue, has been persisted. It does so by queuing a
+// statement which changes nothing, and waiting for it to be persisted, which the node
+// only reports once all writes queued before it are persisted. If timeout is non-zero,
+// the node returns an error if the queue is not flushed within it.
+//
+// Queues are held by each node, so the client should be connected to the node on
+// which the writes were queued.
+func (c *Client) WaitForQueue(ctx context.Context, timeout time.Duration) error {
+	resp, err := c.Execute(ctx, SQLStatements{{SQL: queueFlushStatement}}, &ExecuteOptions{
+		Queue:   true,
+		Wait:    true,
+		Timeout: timeout,
+	})
+	if err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	return nil
+}
+
 // QuerySingle performs a single read operation (SELECT) using /db/query.
 // args should be a single map of named parameters, or a slice of positional parameters.
 // It is the caller's responsibility to ensure the correct number and type of parameters.
@@ -477,44 +1821,337 @@ func (c *Client) QuerySingle(ctx context.Context, statement string, args ...any)
 }
 
 // Query performs a read operation (SELECT) using /db/query. opts may be nil, in which case default
-// options are used.
-func (c *Client) Query(ctx context.Context, statements SQLStatements, opts *QueryOptions) (retQr *QueryResponse, retErr error) {
-	body, err := statements.MarshalJSON()
+// options are used. opts is not modified, and may be shared between concurrent calls.
+func (c *Client) Query(ctx context.Context, statements SQLStatements, opts *QueryOptions) (*QueryResponse, error) {
+	opts = c.queryOptions(opts)
+	if c.singleflight.Load() {
+		if key, ok := c.flightKey(ctx, statements, opts); ok {
+			return c.flights.do(ctx, key, func() (*QueryResponse, error) {
+				return c.queryRetryStale(ctx, statements, opts)
+			})
+		}
+	}
+	return c.queryRetryStale(ctx, statements, opts)
+}
+
+// queryRetryStale performs a query, retrying a stale read if the client is configured
+// to do so.
+func (c *Client) queryRetryStale(ctx context.Context, statements SQLStatements, opts *QueryOptions) (*QueryResponse, error) {
+	qr, err := c.query(ctx, c.routeQuery(opts), statements, opts)
+	if c.retryStaleReads.Load() && opts != nil && opts.Freshness > 0 && isStaleRead(qr, err) {
+		o := *opts
+		o.Level = ReadConsistencyLevelWeak
+		o.Freshness, o.FreshnessStrict, o.NoLeader = 0, false, false
+		return c.query(ctx, routeLeader, statements, &o)
+	}
+	return qr, err
+}
+
+// query performs a read operation using /db/query, sending it to a node of the
+// type identified by rt.
+func (c *Client) query(ctx context.Context, rt route, statements SQLStatements, opts *QueryOptions) (retQr *QueryResponse, retErr error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	body, closeBody, err := c.statementsBody(statements)
 	if err != nil {
 		return nil, err
 	}
-	queryParams, err := makeURLValues(opts)
+	defer closeBody()
+	queryParams, err := c.makeURLValues(opts)
 	if err != nil {
 		return nil, err
 	}
+	if err := c.setStatementTimeout(statements, queryParams); err != nil {
+		return nil, err
+	}
+	c.setTimeoutFromContext(ctx, queryParams)
 
-	resp, err := c.doJSONPostRequest(ctx, queryPath, queryParams, bytes.NewReader(body))
+	resp, err := c.doJSONPostRequest(ctx, rt, queryPath, queryParams, body, true)
 	if err != nil {
 		return nil, err
 	}
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err := readAll(ctx, resp.Body)
 	if err != nil {
 		return nil, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, respBody)
+		return nil, c.withRequest(newHTTPError(resp.StatusCode, respBody), statements, queryParams)
 	}
 
 	var queryResponse QueryResponse
-	dec := json.NewDecoder(bytes.NewReader(respBody))
-	dec.UseNumber()
-	if err := dec.Decode(&queryResponse); err != nil {
+	if err := queryResponse.decode(c.getCodec(), respBody); err != nil {
 		return nil, err
 	}
-	if c.promoteErrors.Load() {
-		if f, i, msg := queryResponse.HasError(); f {
-			retErr = fmt.Errorf("statement %d: %s", i, msg)
+	c.logStatementErrors(ctx, queryPath, &queryResponse)
+	if c.strictResultCount.Load() && queryResponse.Error == "" {
+		if err := checkResultCount(len(statements), queryResponse.numResults()); err != nil {
+			return &queryResponse, err
 		}
 	}
+	retErr = c.promotedError(&queryResponse, statements)
 	return &queryResponse, retErr
 }
 
+// staleReadError is the error returned by a node when a read fails freshness checks.
+const staleReadError = "stale read"
+
+// isStaleRead returns whether a query failed because the node's data was too stale.
+func isStaleRead(qr *QueryResponse, err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return strings.Contains(httpErr.RQLiteError, staleReadError) || strings.Contains(string(httpErr.Body), staleReadError)
+	}
+	return qr != nil && strings.Contains(qr.Error, staleReadError)
+}
+
+// QueryAssoc is like Query, but always requests the associative form of results,
+// regardless of opts.Associative. opts is not modified.
+func (c *Client) QueryAssoc(ctx context.Context, statements SQLStatements, opts *QueryOptions) (*QueryResponse, error) {
+	var o QueryOptions
+	if opts != nil {
+		o = *opts
+	}
+	o.Associative = true
+	return c.Query(ctx, statements, &o)
+}
+
+// QuerySnapshot performs a read operation using /db/query, such that all statements
+// observe the same state of the database. The statements are sent in a single request,
+// executed within a single transaction, and at the Linearizable read consistency level,
+// so the snapshot reflects all writes acknowledged before the call was made. If opts
+// requests the Strong level, that is used instead. opts may be nil, in which case
+// default options are used. opts is not modified.
+func (c *Client) QuerySnapshot(ctx context.Context, statements SQLStatements, opts *QueryOptions) (*QueryResponse, error) {
+	var o QueryOptions
+	if opts != nil {
+		o = *opts
+	}
+	o.Transaction = true
+	if o.Level != ReadConsistencyLevelStrong {
+		o.Level = ReadConsistencyLevelLinearizable
+	}
+	return c.Query(ctx, statements, &o)
+}
+
+// QueryBatch performs a read operation using /db/query, with each statement identified
+// by a caller-supplied name. The result for each statement is returned under the same
+// name. Statement-level errors are reported in the Error field of each result. opts may
+// be nil, in which case default options are used. The associative form of results is
+// not supported by QueryBatch, and opts.Associative is ignored.
+func (c *Client) QueryBatch(ctx context.Context, statements map[string]*SQLStatement, opts *QueryOptions) (map[string]*QueryResult, error) {
+	names := slices.Sorted(maps.Keys(statements))
+	stmts := make(SQLStatements, len(names))
+	for i, name := range names {
+		stmts[i] = statements[name]
+	}
+
+	qr, err := c.Query(ctx, stmts, c.plainQueryOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	if qr.Error != "" {
+		return nil, fmt.Errorf("query batch: %s", qr.Error)
+	}
+	results, ok := qr.Results.([]QueryResult)
+	if !ok {
+		return nil, fmt.Errorf("unexpected results type %T", qr.Results)
+	}
+	if err := checkResultCount(len(stmts), len(results)); err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]*QueryResult, len(names))
+	for i, name := range names {
+		m[name] = &results[i]
+	}
+	return m, nil
+}
+
+// QueryScalar performs a single read operation using /db/query and returns the first
+// column of the first row, for example the result of "SELECT MAX(id) FROM foo". Numbers
+// are returned as int64 if they are integers and float64 otherwise. If the query returns
+// no rows, ErrNoRows is returned.
+func (c *Client) QueryScalar(ctx context.Context, statement string, args ...any) (any, error) {
+	stmt, err := NewSQLStatement(statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	return c.queryScalar(ctx, stmt, nil)
+}
+
+// queryScalar is like QueryScalar, but performs the query with opts.
+func (c *Client) queryScalar(ctx context.Context, stmt *SQLStatement, opts *QueryOptions) (any, error) {
+	qr, err := c.Query(ctx, SQLStatements{stmt}, c.plainQueryOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	if f, _, msg := qr.HasError(); f {
+		return nil, errors.New(msg)
+	}
+	results, ok := qr.Results.([]QueryResult)
+	if !ok || len(results) != 1 {
+		return nil, fmt.Errorf("unexpected results for scalar query")
+	}
+	if len(results[0].Values) == 0 || len(results[0].Values[0]) == 0 {
+		return nil, ErrNoRows
+	}
+
+	v := results[0].Values[0][0]
+	if n, ok := v.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			return i, nil
+		}
+		return n.Float64()
+	}
+	return v, nil
+}
+
+// ScalarInto is like Client.QueryScalar, but converts the result to type T. Numbers
+// may be converted to any numeric type, as Scan converts them, so converting a number
+// which is not an integer to an integer type, or one which T cannot hold, is an
+// error. If *T implements sql.Scanner, as the sql.Null*
+// types do, the result is passed to its Scan method, with a NULL result passed as nil.
+// Otherwise a NULL result is returned as the zero value of T.
+func ScalarInto[T any](ctx context.Context, c *Client, statement string, args ...any) (T, error) {
+	var zero T
+	v, err := c.QueryScalar(ctx, statement, args...)
+	if err != nil {
+		return zero, err
+	}
+	var t T
+	if s, ok := any(&t).(sql.Scanner); ok {
+		if err := s.Scan(v); err != nil {
+			return zero, err
+		}
+		return t, nil
+	}
+	if v == nil {
+		return zero, nil
+	}
+	if t, ok := v.(T); ok {
+		return t, nil
+	}
+
+	typ := reflect.TypeFor[T]()
+	if isNumericKind(typ.Kind()) {
+		// Convert as Scan does, so a non-integral value or one which overflows T
+		// is an error.
+		rv := reflect.New(typ).Elem()
+		if err := setField(rv, v, ""); err != nil {
+			return zero, err
+		}
+		return rv.Interface().(T), nil
+	}
+	return zero, fmt.Errorf("cannot convert %T to %s", v, typ)
+}
+
+func isNumericKind(k reflect.Kind) bool {
+	return (k >= reflect.Int && k <= reflect.Uint64) || k == reflect.Float32 || k == reflect.Float64
+}
+
+// VerifyReplication performs the same scalar read, as QueryScalar does, against each
+// of hosts, reading each node's local database with ReadConsistencyLevelNone. It
+// returns whether every node returned the same value, along with the value returned
+// by each node, keyed by host. A host for which the query returns no rows has no entry
+// in the map, and only matches other such hosts, so it is distinguished from one which
+// returns NULL. It is intended for testing replication, for example by comparing the
+// result of "SELECT COUNT(*) FROM foo" across the cluster.
+//
+// The hosts are queried concurrently, using the client's HTTP client and credentials.
+// If any host cannot be queried, an error is returned along with the values returned
+// by the other hosts.
+func (c *Client) VerifyReplication(ctx context.Context, query string, hosts []string) (bool, map[string]any, error) {
+	stmt := &SQLStatement{SQL: query}
+	opts := &QueryOptions{Level: ReadConsistencyLevelNone}
+
+	hcs := make([]*Client, len(hosts))
+	for i, host := range hosts {
+		hc, err := c.hostClient(host)
+		if err != nil {
+			return false, nil, err
+		}
+		hcs[i] = hc
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	values := make(map[string]any, len(hosts))
+	errs := make([]error, len(hosts))
+	for i, host := range hosts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := hcs[i].queryScalar(ctx, stmt, opts)
+			if errors.Is(err, ErrNoRows) {
+				return
+			}
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", host, err)
+				return
+			}
+			mu.Lock()
+			values[host] = v
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return false, values, err
+	}
+
+	for _, host := range hosts[min(1, len(hosts)):] {
+		v0, ok0 := values[hosts[0]]
+		v, ok := values[host]
+		if ok != ok0 || !reflect.DeepEqual(v0, v) {
+			return false, values, nil
+		}
+	}
+	return true, values, nil
+}
+
+// hostClient returns a client which sends every request to host, using the same
+// HTTP client and settings as c.
+func (c *Client) hostClient(host string) (*Client, error) {
+	lb, err := NewLoopbackBalancer(host)
+	if err != nil {
+		return nil, err
+	}
+	hc, err := NewClientWithBalancer(lb, c.httpClient)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	hc.basicAuthUser, hc.basicAuthPass = c.basicAuthUser, c.basicAuthPass
+	hc.authProvider = c.authProvider
+	hc.middleware, hc.mwClient = slices.Clone(c.middleware), c.mwClient
+	hc.durFormat = c.durFormat
+	hc.codec = c.codec
+	return hc, nil
+}
+
+// TableExists returns whether a table with the given name exists in the database.
+func (c *Client) TableExists(ctx context.Context, name string) (bool, error) {
+	stmt, err := NewSQLStatement("SELECT name FROM sqlite_master WHERE type='table' AND name=?", name)
+	if err != nil {
+		return false, err
+	}
+	qr, err := c.Query(ctx, SQLStatements{stmt}, c.plainQueryOptions(nil))
+	if err != nil {
+		return false, err
+	}
+	if f, _, msg := qr.HasError(); f {
+		return false, fmt.Errorf("checking table existence: %s", msg)
+	}
+	results, ok := qr.Results.([]QueryResult)
+	if !ok || len(results) != 1 {
+		return false, fmt.Errorf("unexpected results checking table existence")
+	}
+	return len(results[0].Values) > 0, nil
+}
+
 // RequestSingle sends a single statement, which can be either a read or write.
 // args should be a single map of named parameters, or a slice of positional
 // parameters. It is the caller's responsibility to ensure the correct number and
@@ -528,116 +2165,531 @@ func (c *Client) RequestSingle(ctx context.Context, statement string, args ...an
 }
 
 // Request sends both read and write statements in a single request using /db/request.
-// opts may be nil, in which case default options are used.
-func (c *Client) Request(ctx context.Context, statements SQLStatements, opts *RequestOptions) (rr *RequestResponse, retErr error) {
-	body, err := statements.MarshalJSON()
+// opts may be nil, in which case default options are used. opts is not modified, and
+// may be shared between concurrent calls.
+func (c *Client) Request(ctx context.Context, statements SQLStatements, opts *RequestOptions) (*RequestResponse, error) {
+	return c.request(ctx, c.routeWrite(), requestPath, statements, c.requestOptions(opts))
+}
+
+// request sends statements to the endpoint at path, sending it to a node of the
+// type identified by rt, and decodes the response as a RequestResponse.
+func (c *Client) request(ctx context.Context, rt route, path string, statements SQLStatements, opts *RequestOptions) (rr *RequestResponse, retErr error) {
+	var pre SQLStatements
+	if path == requestPath {
+		c.mu.RLock()
+		pre = c.preStmts
+		c.mu.RUnlock()
+	}
+	sent := statements
+	if len(pre) > 0 {
+		sent = append(slices.Clip(pre), statements...)
+	}
+
+	body, closeBody, err := c.statementsBody(sent)
 	if err != nil {
 		return nil, err
 	}
-	reqParams, err := makeURLValues(opts)
+	defer closeBody()
+	reqParams, err := c.makeURLValues(opts)
 	if err != nil {
 		return nil, err
 	}
+	if d, _ := statements.timeout(); d != 0 && path == executePath {
+		return nil, errExecuteStatementTimeout
+	}
+	if err := c.setStatementTimeout(statements, reqParams); err != nil {
+		return nil, err
+	}
+	c.setTimeoutFromContext(ctx, reqParams)
 
-	resp, err := c.doJSONPostRequest(ctx, requestPath, reqParams, bytes.NewReader(body))
+	readOnly := path == queryPath || (opts != nil && opts.ReadOnly)
+	resp, err := c.doJSONPostRequest(ctx, rt, path, reqParams, body, readOnly)
 	if err != nil {
 		return nil, err
 	}
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err := readAll(ctx, resp.Body)
 	if err != nil {
 		return nil, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, respBody)
+		return nil, c.withRequest(newHTTPError(resp.StatusCode, respBody), sent, reqParams)
 	}
 
 	var reqResp RequestResponse
-	dec := json.NewDecoder(bytes.NewReader(respBody))
-	dec.UseNumber()
-	if err := dec.Decode(&reqResp); err != nil {
+	if err := reqResp.decode(c.getCodec(), respBody); err != nil {
 		return nil, err
 	}
-	if c.promoteErrors.Load() {
-		if f, i, msg := reqResp.HasError(); f {
-			retErr = fmt.Errorf("statement %d: %s", i, msg)
+	if len(pre) > 0 && reqResp.Error == "" {
+		if err := reqResp.dropResults(len(pre)); err != nil {
+			return nil, err
+		}
+	}
+	c.logStatementErrors(ctx, path, &reqResp)
+	if c.strictResultCount.Load() && reqResp.Error == "" {
+		if err := checkResultCount(len(statements), reqResp.numResults()); err != nil {
+			return &reqResp, err
 		}
 	}
+	retErr = c.promotedError(&reqResp, statements)
 	return &reqResp, retErr
 }
 
+// RequestAssoc is like Request, but always requests the associative form of results,
+// regardless of opts.Associative. opts is not modified.
+func (c *Client) RequestAssoc(ctx context.Context, statements SQLStatements, opts *RequestOptions) (*RequestResponse, error) {
+	var o RequestOptions
+	if opts != nil {
+		o = *opts
+	}
+	o.Associative = true
+	return c.Request(ctx, statements, &o)
+}
+
 // Backup requests a copy of the SQLite database from the node. opts may be nil, in which case
 // default options are used. The caller is responsible for closing the returned io.ReadCloser
 // when done with it.
-func (c *Client) Backup(ctx context.Context, opts *BackupOptions) (rc io.ReadCloser, retError error) {
+func (c *Client) Backup(ctx context.Context, opts *BackupOptions) (io.ReadCloser, error) {
+	rc, _, err := c.BackupWithMeta(ctx, opts)
+	return rc, err
+}
+
+// BackupMeta describes how a backup was served.
+type BackupMeta struct {
+	// Host is the base URL of the node which served the backup, with any credentials
+	// removed. If a redirect was followed, it is the node redirected to.
+	Host string
+
+	// FromLeader is whether the backup is known to have been produced by the Leader.
+	// It is true unless BackupOptions.NoLeader is set, as Followers forward backup
+	// requests to the Leader. If NoLeader is set, it is only true if the client's
+	// balancer is a LeaderAwareBalancer which identifies Host as the Leader.
+	FromLeader bool
+}
+
+// BackupWithMeta is like Backup, but also returns a description of how the backup
+// was served.
+func (c *Client) BackupWithMeta(ctx context.Context, opts *BackupOptions) (rc io.ReadCloser, meta BackupMeta, retError error) {
 	defer func() {
 		if retError != nil && rc != nil {
 			rc.Close()
 		}
 	}()
-	reqParams, err := makeURLValues(opts)
+	reqParams, err := c.makeURLValues(opts)
 	if err != nil {
-		return nil, err
+		return nil, BackupMeta{}, err
 	}
 
 	resp, err := c.doGetRequest(ctx, backupPath, reqParams)
 	if err != nil {
-		return nil, err
+		return nil, BackupMeta{}, err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		defer resp.Body.Close()
+		b, _ := readAll(ctx, resp.Body)
+		return nil, BackupMeta{}, newHTTPError(resp.StatusCode, b)
 	}
-	return resp.Body, nil
+
+	host := hostURL(resp.Request.URL)
+	meta = BackupMeta{Host: host, FromLeader: opts == nil || !opts.NoLeader}
+	if !meta.FromLeader {
+		if lab, ok := c.lb.(LeaderAwareBalancer); ok {
+			if leader, err := lab.Leader(); err == nil && hostURL(leader) == host {
+				meta.FromLeader = true
+			}
+		}
+	}
+	return resp.Body, meta, nil
 }
 
-// Load streams data from r into the node, to load or restore data. Load automatically
-// detects the format of the data, and can handle both plain text and SQLite binary data.
-// opts may be nil, in which case default options are used.
+// hostURL returns the scheme and host of u, without credentials, path, or query.
+func hostURL(u *url.URL) string {
+	return (&url.URL{Scheme: u.Scheme, Host: u.Host}).String()
+}
+
+const (
+	// backupResumeAttempts is the number of times BackupResume resumes a failed download.
+	backupResumeAttempts = 5
+
+	// backupResumeBackoff is the initial time BackupResume waits before resuming a
+	// failed download. It doubles with each attempt.
+	backupResumeBackoff = 100 * time.Millisecond
+
+	// maxBackupResumeBackoff is the maximum time BackupResume waits before resuming.
+	maxBackupResumeBackoff = 2 * time.Second
+)
+
+// BackupResume downloads a copy of the SQLite database from the node to w, starting at
+// w's current offset. If the download fails, it is retried up to 5 times, after an
+// exponential backoff. A download which failed part way through is resumed using an
+// HTTP Range request for the data after the last byte written. If the node does not
+// honor the Range request, the download restarts from the beginning, and w is
+// truncated if it has a Truncate method. The number of bytes of backup data written to
+// w is returned.
+//
+// The node generates a fresh backup for each request, so a download is only resumed
+// if the node, or a proxy in front of it, identified the backup with a strong ETag,
+// which BackupResume sends in an If-Range header so that a different backup is sent
+// in full. Otherwise the download restarts from the beginning. opts may be nil, in
+// which case default options are used.
+func (c *Client) BackupResume(ctx context.Context, w io.WriteSeeker, opts *BackupOptions) (int64, error) {
+	params, err := c.makeURLValues(opts)
+	if err != nil {
+		return 0, err
+	}
+	start, err := w.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+
+	var written int64
+	var etag string
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			if m := c.getMetrics(); m != nil {
+				m.ObserveRetry(backupPath, attempt)
+			}
+			select {
+			case <-time.After(min(backupResumeBackoff<<(attempt-1), maxBackupResumeBackoff)):
+			case <-ctx.Done():
+				return written, contextError(ctx, ctx.Err())
+			}
+		}
+		header := http.Header{}
+		if written > 0 && etag == "" {
+			// The data written may not belong to the backup the node sends next.
+			if err := rewind(w, start); err != nil {
+				return 0, err
+			}
+			written = 0
+		}
+		if written > 0 {
+			header.Set("Range", fmt.Sprintf("bytes=%d-", written))
+			header.Set("If-Range", etag)
+		}
+		resp, err := c.doRequestRoute(ctx, routeAny, "GET", backupPath, "", header, params, nil)
+		if err != nil {
+			if attempt < backupResumeAttempts && ctx.Err() == nil {
+				continue
+			}
+			return written, err
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusPartialContent && written > 0 && contentRangeStart(resp) == written:
+		case resp.StatusCode == http.StatusOK:
+			if written > 0 {
+				if err := rewind(w, start); err != nil {
+					resp.Body.Close()
+					return 0, err
+				}
+				written = 0
+			}
+			etag = resp.Header.Get("ETag")
+			if strings.HasPrefix(etag, "W/") {
+				// If-Range requires a strong validator.
+				etag = ""
+			}
+		default:
+			b, _ := readAll(ctx, resp.Body)
+			resp.Body.Close()
+			return written, newHTTPError(resp.StatusCode, b)
+		}
+
+		er := &errReader{r: resp.Body}
+		n, err := io.Copy(w, er)
+		resp.Body.Close()
+		written += n
+		if err == nil {
+			return written, nil
+		}
+		if er.err == nil || attempt >= backupResumeAttempts || ctx.Err() != nil {
+			// Writing to w failed, or the download cannot be resumed.
+			return written, contextError(ctx, err)
+		}
+	}
+}
+
+// contentRangeStart returns the offset of the first byte in a 206 response, or -1
+// if it cannot be determined.
+func contentRangeStart(resp *http.Response) int64 {
+	var first, last int64
+	if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-%d", &first, &last); err != nil {
+		return -1
+	}
+	return first
+}
+
+// rewind seeks w back to offset, truncating it there if it supports truncation.
+func rewind(w io.WriteSeeker, offset int64) error {
+	if _, err := w.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	if t, ok := w.(interface{ Truncate(int64) error }); ok {
+		return t.Truncate(offset)
+	}
+	return nil
+}
+
+// errReader records any error returned when reading from r.
+type errReader struct {
+	r   io.Reader
+	err error
+}
+
+func (er *errReader) Read(p []byte) (int, error) {
+	n, err := er.r.Read(p)
+	if err != nil && err != io.EOF {
+		er.err = err
+	}
+	return n, err
+}
+
+// LoadResult represents the JSON returned by /db/load, if any.
+type LoadResult struct {
+	Results []ExecuteResult `json:"results"`
+	Time    float64         `json:"time,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// RowsAffected returns the total number of rows affected by the load.
+func (lr *LoadResult) RowsAffected() int64 {
+	var n int64
+	for _, r := range lr.Results {
+		n += r.RowsAffected
+	}
+	return n
+}
+
+// Load streams data from r into the node, to load or restore data. Load can handle both
+// plain text and SQLite binary data, and detects the format of the data unless it is
+// set by opts.Format. opts may be nil, in which case default options are used.
 func (c *Client) Load(ctx context.Context, r io.Reader, opts *LoadOptions) error {
-	params, err := makeURLValues(opts)
+	_, err := c.LoadWithResult(ctx, r, opts)
+	return err
+}
+
+// LoadWithResult is like Load, but also returns any summary of the load returned by
+// the node. If the node returns no summary, the returned LoadResult is empty.
+func (c *Client) LoadWithResult(ctx context.Context, r io.Reader, opts *LoadOptions) (*LoadResult, error) {
+	params, err := c.makeURLValues(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var o LoadOptions
+	if opts != nil {
+		o = *opts
+	}
+	binary, r, err := isSQLiteData(r, o.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := "text/plain"
+	if binary {
+		contentType = "application/octet-stream"
+		if o.BinaryContentType != "" {
+			contentType = o.BinaryContentType
+		}
+	} else if o.TextContentType != "" {
+		contentType = o.TextContentType
+	}
+	resp, err := c.doRequest(ctx, "POST", loadPath, contentType, params, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := readAll(ctx, resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newHTTPError(resp.StatusCode, respBody)
+	}
+
+	var lr LoadResult
+	if len(bytes.TrimSpace(respBody)) == 0 {
+		return &lr, nil
+	}
+	if err := json.Unmarshal(respBody, &lr); err != nil {
+		return nil, err
+	}
+	if lr.Error != "" {
+		return &lr, errors.New(lr.Error)
+	}
+	return &lr, nil
+}
+
+// Boot streams a raw SQLite file into a single-node system, effectively initializing
+// the underlying SQLite database from scratch. It is an error to call this on anything
+// but a single-node system.
+func (c *Client) Boot(ctx context.Context, r io.Reader) error {
+	resp, err := c.doOctetStreamPostRequest(ctx, bootPath, nil, r)
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
+	b, err := readAll(ctx, resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return newHTTPError(resp.StatusCode, b)
+	}
+	return nil
+}
+
+// bootReadyInterval is how often BootAndVerify checks whether the node is ready.
+const bootReadyInterval = 100 * time.Millisecond
 
-	first13 := make([]byte, 13)
-	_, err = r.Read(first13)
+// BootAndVerify boots a single-node system from the SQLite file read from r, as Boot
+// does, waits for the node to become ready, and then calls verify, which can query
+// the node to confirm the expected data is present. The error returned by verify, if
+// any, is returned. ctx bounds the time spent waiting for the node to become ready.
+func (c *Client) BootAndVerify(ctx context.Context, r io.Reader, verify func(*Client) error) error {
+	if err := c.Boot(ctx, r); err != nil {
+		return fmt.Errorf("boot: %w", err)
+	}
+
+	ticker := time.NewTicker(bootReadyInterval)
+	defer ticker.Stop()
+	for {
+		_, err := c.Ready(ctx, nil)
+		if err == nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for node to become ready: %w", err)
+		case <-ticker.C:
+		}
+	}
+	return verify(c)
+}
+
+// Restore streams data from r into the cluster, choosing how to restore it from the
+// format of the data, determined as by Load, and the size of the cluster. A SQLite
+// database file restoring a single-node system is sent with Boot, which is much faster
+// for large files. Any other data, including SQL text, is sent with Load. opts may be
+// nil, in which case default options are used.
+func (c *Client) Restore(ctx context.Context, r io.Reader, opts *RestoreOptions) error {
+	var o RestoreOptions
+	if opts != nil {
+		o = *opts
+	}
+
+	binary, r, err := isSQLiteData(r, o.Format)
 	if err != nil {
 		return err
 	}
+	if o.Progress != nil {
+		r = &progressReader{r: r, fn: o.Progress}
+	}
+
+	if binary {
+		nodes, err := c.NodeList(ctx, &NodeOptions{NonVoters: true})
+		if err != nil {
+			return err
+		}
+		if len(nodes) == 1 {
+			return c.Boot(ctx, r)
+		}
+	}
+	return c.Load(ctx, r, &o.LoadOptions)
+}
+
+// CopyTo copies the database of the cluster to the cluster dest is connected to, for
+// example to clone a cluster, or to migrate to a new one. The backup is streamed from
+// the source into dest as it is received, as by Restore, so the database is never
+// held in memory. opts may be nil, in which case default options are used.
+func (c *Client) CopyTo(ctx context.Context, dest *Client, opts *CopyOptions) error {
+	var o CopyOptions
+	if opts != nil {
+		o = *opts
+	}
+	rc, err := c.Backup(ctx, &o.Backup)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	var r io.Reader = rc
+	if o.Backup.Compress {
+		gz, err := gzip.NewReader(rc)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	if o.Restore.Format == LoadFormatAuto {
+		o.Restore.Format = LoadFormatSQLite
+		if o.Backup.Format == "sql" {
+			o.Restore.Format = LoadFormatSQLText
+		}
+	}
+	return dest.Restore(ctx, r, &o.Restore)
+}
 
-	if validSQLiteData(first13) {
-		_, err = c.doOctetStreamPostRequest(ctx, loadPath, params, io.MultiReader(bytes.NewReader(first13), r))
-	} else {
-		_, err = c.doPlainPostRequest(ctx, loadPath, params, io.MultiReader(bytes.NewReader(first13), r))
+// progressReader calls fn with the total number of bytes read from r after each read.
+type progressReader struct {
+	r    io.Reader
+	fn   func(int64)
+	read int64
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	if n > 0 {
+		pr.read += int64(n)
+		pr.fn(pr.read)
 	}
-	return err
+	return n, err
 }
 
-// Boot streams a raw SQLite file into a single-node system, effectively initializing
-// the underlying SQLite database from scratch. It is an error to call this on anything
-// but a single-node system.
-func (c *Client) Boot(ctx context.Context, r io.Reader) error {
-	_, err := c.doOctetStreamPostRequest(ctx, bootPath, nil, r)
-	return err
+// RemoveNodeResult describes the outcome of a node removal.
+type RemoveNodeResult struct {
+	// Error is the error message returned by the node, if any.
+	Error string `json:"error,omitempty"`
+
+	// Raw is the unparsed response body, which is empty if the node returned no body.
+	Raw json.RawMessage `json:"-"`
 }
 
-// RemoveNode removes a node from the cluster. The node is identified by its ID.
-func (c *Client) RemoveNode(ctx context.Context, id string) error {
-	body := fmt.Sprintf(`{"id":"%s"}`, id)
-	resp, err := c.doRequest(ctx, "DELETE", removePath, "application/json", nil, bytes.NewReader([]byte(body)))
+// RemoveNode removes a node from the cluster. The node is identified by its ID. If the
+// node returns a JSON body describing the removal, it is parsed into the returned result.
+func (c *Client) RemoveNode(ctx context.Context, id string) (*RemoveNodeResult, error) {
+	body, err := json.Marshal(map[string]string{"id": id})
 	if err != nil {
-		return err
+		return nil, err
+	}
+	resp, err := c.doRequest(ctx, "DELETE", removePath, jsonContentType, nil, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err := readAll(ctx, resp.Body)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, respBody)
+		return nil, newHTTPError(resp.StatusCode, respBody)
 	}
-	return nil
+
+	result := &RemoveNodeResult{Raw: respBody}
+	if len(bytes.TrimSpace(respBody)) == 0 {
+		return result, nil
+	}
+	if err := json.Unmarshal(respBody, result); err != nil {
+		return nil, err
+	}
+	if result.Error != "" {
+		return result, fmt.Errorf("removing node %s: %s", id, result.Error)
+	}
+	return result, nil
 }
 
 // Status returns the status of the node.
@@ -647,12 +2699,12 @@ func (c *Client) Status(ctx context.Context) (json.RawMessage, error) {
 		return nil, err
 	}
 	defer resp.Body.Close()
-	b, err := io.ReadAll(resp.Body)
+	b, err := readAll(ctx, resp.Body)
 	if err != nil {
 		return nil, err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(b))
+		return nil, newHTTPError(resp.StatusCode, b)
 	}
 	return b, nil
 }
@@ -664,19 +2716,20 @@ func (c *Client) Expvar(ctx context.Context) (json.RawMessage, error) {
 		return nil, err
 	}
 	defer resp.Body.Close()
-	b, err := io.ReadAll(resp.Body)
+	b, err := readAll(ctx, resp.Body)
 	if err != nil {
 		return nil, err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(b))
+		return nil, newHTTPError(resp.StatusCode, b)
 	}
 	return b, nil
 }
 
-// Nodes returns the list of known nodes in the cluster.
+// Nodes returns the list of known nodes in the cluster, as the raw JSON returned by
+// the node. NodeList returns the nodes parsed into Node values.
 func (c *Client) Nodes(ctx context.Context, opts *NodeOptions) (json.RawMessage, error) {
-	params, err := makeURLValues(opts)
+	params, err := c.makeURLValues(opts)
 	if err != nil {
 		return nil, err
 	}
@@ -686,19 +2739,166 @@ func (c *Client) Nodes(ctx context.Context, opts *NodeOptions) (json.RawMessage,
 		return nil, err
 	}
 	defer resp.Body.Close()
-	b, err := io.ReadAll(resp.Body)
+	b, err := readAll(ctx, resp.Body)
 	if err != nil {
 		return nil, err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(b))
+		return nil, newHTTPError(resp.StatusCode, b)
 	}
 	return b, nil
 }
 
+// NodeRole is the role of a node in the cluster's Raft consensus.
+type NodeRole int
+
+const (
+	// NodeRoleFollower is a voting node which is not the Leader.
+	NodeRoleFollower NodeRole = iota
+
+	// NodeRoleLeader is the Leader of the cluster.
+	NodeRoleLeader
+
+	// NodeRoleNonVoter is a non-voting, or read-only, node. It receives replicated
+	// changes but takes no part in elections, and is sometimes known as a learner.
+	NodeRoleNonVoter
+)
+
+// String returns the string representation of a NodeRole.
+func (r NodeRole) String() string {
+	switch r {
+	case NodeRoleFollower:
+		return "follower"
+	case NodeRoleLeader:
+		return "leader"
+	case NodeRoleNonVoter:
+		return "nonvoter"
+	default:
+		return "unknown"
+	}
+}
+
+// Node describes a node in the cluster, as returned by /nodes.
+type Node struct {
+	// ID is the node's ID.
+	ID string `json:"id"`
+
+	// APIAddr is the URL of the node's HTTP API.
+	APIAddr string `json:"api_addr"`
+
+	// Addr is the node's Raft address.
+	Addr string `json:"addr"`
+
+	// Voter is whether the node takes part in leader election.
+	Voter bool `json:"voter"`
+
+	// Reachable is whether the node which served the request could contact the node.
+	Reachable bool `json:"reachable"`
+
+	// Leader is whether the node is the Leader.
+	Leader bool `json:"leader"`
+
+	// Time is how long, in seconds, contacting the node took.
+	Time float64 `json:"time,omitempty"`
+
+	// Error describes why the node could not be contacted, if it was not reachable.
+	Error string `json:"error,omitempty"`
+
+	// Role is the node's role, derived from Leader and Voter by ParseNodes.
+	Role NodeRole `json:"-"`
+}
+
+// nodeRole returns the role of n, derived from its Leader and Voter fields.
+func nodeRole(n Node) NodeRole {
+	switch {
+	case n.Leader:
+		return NodeRoleLeader
+	case n.Voter:
+		return NodeRoleFollower
+	default:
+		return NodeRoleNonVoter
+	}
+}
+
+// ParseNodes parses the response returned by Nodes. Both the current form, in which
+// nodes are listed under a "nodes" key, and the legacy form, a JSON object keyed by
+// node ID, are supported. Nodes in the legacy form are returned ordered by ID. The
+// Role of each node is set.
+func ParseNodes(data json.RawMessage) ([]Node, error) {
+	var v2 struct {
+		Nodes []Node `json:"nodes"`
+	}
+	if err := json.Unmarshal(data, &v2); err == nil && v2.Nodes != nil {
+		for i := range v2.Nodes {
+			v2.Nodes[i].Role = nodeRole(v2.Nodes[i])
+		}
+		return v2.Nodes, nil
+	}
+
+	var v1 map[string]Node
+	if err := json.Unmarshal(data, &v1); err != nil {
+		return nil, err
+	}
+	ids := slices.Sorted(maps.Keys(v1))
+	nodes := make([]Node, 0, len(ids))
+	for _, id := range ids {
+		n := v1[id]
+		n.ID = id
+		n.Role = nodeRole(n)
+		nodes = append(nodes, n)
+	}
+	return nodes, nil
+}
+
+// ClusterConfig describes the membership of the cluster. Voters take part in leader
+// election and must acknowledge writes, while non-voters, also known as read-only
+// nodes, only receive replicated changes.
+type ClusterConfig struct {
+	Voters    []Node
+	NonVoters []Node
+}
+
+// Leader returns the voter which is the leader of the cluster, and whether one is
+// known.
+func (cc ClusterConfig) Leader() (Node, bool) {
+	for _, n := range cc.Voters {
+		if n.Leader {
+			return n, true
+		}
+	}
+	return Node{}, false
+}
+
+// NodeList returns the known nodes in the cluster, parsed from the response to Nodes
+// by ParseNodes. opts may be nil, in which case default options are used.
+func (c *Client) NodeList(ctx context.Context, opts *NodeOptions) ([]Node, error) {
+	b, err := c.Nodes(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return ParseNodes(b)
+}
+
+// ClusterConfig returns the membership of the cluster, including non-voting nodes.
+func (c *Client) ClusterConfig(ctx context.Context) (ClusterConfig, error) {
+	nodes, err := c.NodeList(ctx, &NodeOptions{NonVoters: true, Version: "2"})
+	if err != nil {
+		return ClusterConfig{}, err
+	}
+	var cc ClusterConfig
+	for _, n := range nodes {
+		if n.Voter {
+			cc.Voters = append(cc.Voters, n)
+		} else {
+			cc.NonVoters = append(cc.NonVoters, n)
+		}
+	}
+	return cc, nil
+}
+
 // Ready returns the readiness of the node.
 func (c *Client) Ready(ctx context.Context, opts *ReadyOptions) ([]byte, error) {
-	params, err := makeURLValues(opts)
+	params, err := c.makeURLValues(opts)
 	if err != nil {
 		return nil, err
 	}
@@ -707,12 +2907,12 @@ func (c *Client) Ready(ctx context.Context, opts *ReadyOptions) ([]byte, error)
 		return nil, err
 	}
 	defer resp.Body.Close()
-	b, err := io.ReadAll(resp.Body)
+	b, err := readAll(ctx, resp.Body)
 	if err != nil {
 		return nil, err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(b))
+		return nil, newHTTPError(resp.StatusCode, b)
 	}
 	return b, err
 }
@@ -731,33 +2931,182 @@ func (c *Client) Version(ctx context.Context) (string, error) {
 	return version, nil
 }
 
+// BuildInfo describes the build of the software running on a node.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Branch    string `json:"branch"`
+	BuildTime string `json:"build_time"`
+	Compiler  string `json:"compiler"`
+
+	// GoVersion is the version of Go with which the software was built.
+	GoVersion string `json:"-"`
+}
+
+// BuildInfo returns information about the build of the software running on the node.
+func (c *Client) BuildInfo(ctx context.Context) (BuildInfo, error) {
+	b, err := c.Status(ctx)
+	if err != nil {
+		return BuildInfo{}, err
+	}
+	var status struct {
+		Build   BuildInfo `json:"build"`
+		Runtime struct {
+			Version string `json:"version"`
+		} `json:"runtime"`
+	}
+	if err := json.Unmarshal(b, &status); err != nil {
+		return BuildInfo{}, err
+	}
+	status.Build.GoVersion = status.Runtime.Version
+	return status.Build, nil
+}
+
 // Close closes the client and should be called when the client is no longer needed.
+// It stops any keep-alive pings, and closes the balancer created by NewClusterClient.
 func (c *Client) Close() error {
+	c.kaMu.Lock()
+	defer c.kaMu.Unlock()
+	c.stopKeepAlive()
+	if c.closeBalancer != nil {
+		c.closeBalancer()
+	}
 	return nil
 }
 
+// keepAlive pings the node every d until ctx is done.
+func (c *Client) keepAlive(ctx context.Context, d time.Duration) {
+	defer c.kaWg.Done()
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			resp, err := c.doGetRequest(ctx, readyPath, nil)
+			if err != nil {
+				continue
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// stopKeepAlive stops any keepalive goroutine and waits for it to exit. It must
+// be called with kaMu held.
+func (c *Client) stopKeepAlive() {
+	if c.kaCancel == nil {
+		return
+	}
+	c.kaCancel()
+	c.kaWg.Wait()
+	c.kaCancel = nil
+}
+
 func (c *Client) doGetRequest(ctx context.Context, path string, values url.Values) (*http.Response, error) {
 	return c.doRequest(ctx, "GET", path, "", values, nil)
 }
 
-func (c *Client) doJSONPostRequest(ctx context.Context, path string, values url.Values, body io.Reader) (*http.Response, error) {
-	return c.doRequest(ctx, "POST", path, "application/json", values, body)
+// doJSONPostRequest posts a JSON body, retrying failed attempts as configured by
+// SetRetryPolicy or SetMaxRetries. readOnly indicates the request does not write to the database, so
+// may safely be retried even if the node may have received it. The body is only
+// resent if it can be rewound.
+func (c *Client) doJSONPostRequest(ctx context.Context, rt route, path string, values url.Values, body io.Reader, readOnly bool) (*http.Response, error) {
+	policy := c.getRetryPolicy()
+	retries := c.maxRetryCount(policy)
+	seeker, replayable := body.(io.Seeker)
+	for attempt := 0; ; attempt++ {
+		resp, err := c.doRequestRoute(ctx, rt, "POST", path, jsonContentType, jsonAcceptHeader, values, body)
+		var queueFull bool
+		if err == nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) {
+			b, err := readAll(ctx, resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			resp.Body = io.NopCloser(bytes.NewReader(b))
+			queueFull = isQueueFull(resp.StatusCode, b)
+		}
+		if attempt >= retries || !replayable || !(queueFull && ctx.Err() == nil || shouldRetry(ctx, policy, readOnly, resp, err)) {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		delay := policy.backoff(attempt + 1)
+		if queueFull {
+			// The node rejected the request without processing it, so it is safe to
+			// retry even a write, but only after giving the node time to recover.
+			delay = max(delay, min(queueFullBackoff<<attempt, maxQueueFullBackoff))
+		}
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return nil, contextError(ctx, ctx.Err())
+			}
+		}
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if m := c.getMetrics(); m != nil {
+			m.ObserveRetry(path, attempt+1)
+		}
+	}
+}
+
+const (
+	// queueFullBackoff is the initial time to wait before retrying a request rejected
+	// because the node's queue was full. It doubles with each retry.
+	queueFullBackoff = 100 * time.Millisecond
+
+	// maxQueueFullBackoff is the maximum time to wait before retrying such a request.
+	maxQueueFullBackoff = 2 * time.Second
+)
+
+// shouldRetry returns whether a request which returned resp and err should be retried.
+// A request which may write is only retried if it was never sent to a node, while a
+// read-only request is also retried if the connection failed after it was sent, or the
+// node, or a proxy in front of it, returned a status code retryable under policy, which
+// may be nil.
+func shouldRetry(ctx context.Context, policy *RetryPolicy, readOnly bool, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err == nil {
+		return readOnly && policy.retryable(resp.StatusCode)
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var urlErr *url.Error
+	return readOnly && errors.As(err, &urlErr)
 }
 
 func (c *Client) doOctetStreamPostRequest(ctx context.Context, path string, values url.Values, body io.Reader) (*http.Response, error) {
 	return c.doRequest(ctx, "POST", path, "application/octet-stream", values, body)
 }
 
-func (c *Client) doPlainPostRequest(ctx context.Context, path string, values url.Values, body io.Reader) (*http.Response, error) {
-	return c.doRequest(ctx, "POST", path, "text/plain", values, body)
-}
-
 // doRequest builds and executes an HTTP request, returning the response.
 func (c *Client) doRequest(ctx context.Context, method, path string, contentType string, values url.Values, body io.Reader) (*http.Response, error) {
-	baseURL, err := c.lb.Next()
+	return c.doRequestRoute(ctx, routeAny, method, path, contentType, nil, values, body)
+}
+
+// doRequestRoute is like doRequest, but sends the request to a node of the type
+// identified by rt, adding any headers in header.
+func (c *Client) doRequestRoute(ctx context.Context, rt route, method, path string, contentType string, header http.Header, values url.Values, body io.Reader) (*http.Response, error) {
+	baseURL, err := c.nextURL(rt)
 	if err != nil {
 		return nil, err
 	}
+	host := baseURL
+	if baseURL.Scheme == unixScheme {
+		// The socket path is used only by the HTTP client's dialer.
+		baseURL = &url.URL{Scheme: "http", Host: "localhost", RawQuery: baseURL.RawQuery}
+	}
 	fullURL := baseURL.JoinPath(path)
 	currValues := fullURL.Query()
 	maps.Copy(currValues, values)
@@ -768,17 +3117,355 @@ func (c *Client) doRequest(ctx context.Context, method, path string, contentType
 	if err != nil {
 		return nil, err
 	}
+	c.mu.RLock()
+	authProvider := c.authProvider
+	c.mu.RUnlock()
+	if authProvider != nil {
+		if err := authProvider.Authenticate(req); err != nil {
+			return nil, err
+		}
+	}
+	applyContext(ctx, req)
 	if contentType != "" {
 		req.Header.Set("Content-Type", contentType)
 	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	c.mu.RLock()
+	recorder, recorderLimit := c.recorder, c.recorderLimit
+	sem, semFailFast := c.sem, c.semFailFast
+	httpClient := c.httpClient
+	if c.mwClient != nil {
+		httpClient = c.mwClient
+	}
+	c.mu.RUnlock()
+	if recorder != nil && req.Body != nil {
+		req.Body = newRecordingReadCloser(req.Body, BodyKindRequest, recorder, recorderLimit)
+	}
+
+	releaseSem := func() {}
+	if sem != nil {
+		if semFailFast {
+			select {
+			case sem <- struct{}{}:
+			default:
+				return nil, ErrTooManyRequests
+			}
+		} else {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return nil, contextError(ctx, ctx.Err())
+			}
+		}
+		var once sync.Once
+		releaseSem = func() { once.Do(func() { <-sem }) }
+	}
+
+	if c.noFollowRedirects.Load() {
+		cl := *httpClient
+		cl.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+		httpClient = &cl
+	} else if method != http.MethodGet {
+		cl := *httpClient
+		checkRedirect := cl.CheckRedirect
+		cl.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if err := preserveMethod(req, via[0]); err != nil {
+				return err
+			}
+			if checkRedirect != nil {
+				return checkRedirect(req, via)
+			}
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return nil
+		}
+		httpClient = &cl
+	}
 
-	resp, err := c.httpClient.Do(req)
+	metrics := c.getMetrics()
+	start := time.Now()
+	c.inFlight.Add(1)
+	resp, err := httpClient.Do(req)
+	c.inFlight.Add(-1)
+	elapsed := time.Since(start)
+	if metrics != nil {
+		observeRequest(metrics, path, host, req, resp, err, elapsed)
+	}
+	c.logRequest(ctx, path, host, req, resp, err, elapsed)
+	c.recordOutcome(ctx, host, resp, err, elapsed)
+	if err != nil && ctx.Err() == nil {
+		c.markBad(host)
+	}
+	c.recordRedirect(host, req, resp)
 	if err != nil {
-		return nil, err
+		releaseSem()
+		return nil, contextError(ctx, err)
+	}
+	if c.noFollowRedirects.Load() && resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		resp.Body.Close()
+		releaseSem()
+		return nil, &RedirectError{
+			StatusCode: resp.StatusCode,
+			Location:   resp.Header.Get("Location"),
+		}
+	}
+	if recorder != nil {
+		resp.Body = newRecordingReadCloser(resp.Body, BodyKindResponse, recorder, recorderLimit)
+	}
+	if sem != nil {
+		// The connection is held until the body is closed, as are streamed responses.
+		resp.Body = &releasingReadCloser{ReadCloser: resp.Body, release: releaseSem}
 	}
 	return resp, nil
 }
 
+// releasingReadCloser calls release once it is closed.
+type releasingReadCloser struct {
+	io.ReadCloser
+	release func()
+}
+
+func (rc *releasingReadCloser) Close() error {
+	err := rc.ReadCloser.Close()
+	rc.release()
+	return err
+}
+
+// getCodec returns the Codec the client should use.
+func (c *Client) getCodec() Codec {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.codec == nil {
+		return StdCodec{}
+	}
+	return c.codec
+}
+
+// statementsBody returns a reader for the JSON form of statements, suitable for use
+// as a request body. The returned function must be called once the request is complete.
+func (c *Client) statementsBody(statements SQLStatements) (io.Reader, func(), error) {
+	codec := c.getCodec()
+	if !c.streamBodies.Load() {
+		b, err := codec.Marshal([]*SQLStatement(statements))
+		if err != nil {
+			return nil, nil, err
+		}
+		return bytes.NewReader(b), func() {}, nil
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(statements.writeJSON(pw, codec.Marshal))
+	}()
+	return pr, func() { pr.Close() }, nil
+}
+
+// recordOutcome reports the outcome and latency of a request to host, if the balancer
+// records them.
+func (c *Client) recordOutcome(ctx context.Context, host *url.URL, resp *http.Response, err error, d time.Duration) {
+	if ctx.Err() != nil {
+		return
+	}
+	if lr, ok := c.lb.(LatencyRecorder); ok && err == nil {
+		lr.RecordLatency(host, d)
+	}
+	or, ok := c.lb.(OutcomeRecorder)
+	if !ok {
+		return
+	}
+	if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+		or.RecordFailure(host)
+		return
+	}
+	or.RecordSuccess(host)
+}
+
+// recordRedirect tells the client's balancer, if it is a RedirectRecorder, whether
+// the request to host was redirected. Redirects are detected whether or not the client
+// follows them, by comparing where the response came from with req, the request as
+// sent, whose URL differs from host if host is a Unix socket.
+func (c *Client) recordRedirect(host *url.URL, req *http.Request, resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	var to *url.URL
+	if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		loc, err := resp.Location()
+		if err != nil {
+			return
+		}
+		to = loc
+	} else if resp.Request != nil {
+		to = resp.Request.URL
+	}
+	if to == nil || hostURL(to) == hostURL(req.URL) {
+		return
+	}
+	leader := &url.URL{Scheme: to.Scheme, Host: to.Host}
+	c.mu.Lock()
+	c.leader = leader
+	c.mu.Unlock()
+	if rr, ok := c.lb.(RedirectRecorder); ok {
+		rr.RecordRedirect(host, leader)
+	}
+}
+
+// maxRedirects is the number of redirects the client follows for a request, unless
+// its http.Client sets its own redirect policy.
+const maxRedirects = 10
+
+// preserveMethod makes req, a request following a redirect of orig, use the method
+// and body of orig. Nodes redirect requests to the Leader with 301 Moved Permanently,
+// following which the http package would otherwise send a GET without a body.
+func preserveMethod(req, orig *http.Request) error {
+	if req.Method == orig.Method {
+		return nil
+	}
+	req.Method = orig.Method
+	if orig.GetBody == nil {
+		if orig.Body == nil || orig.Body == http.NoBody {
+			return nil
+		}
+		return errors.New("cannot follow redirect: request body cannot be replayed")
+	}
+	body, err := orig.GetBody()
+	if err != nil {
+		return err
+	}
+	req.Body = body
+	req.GetBody = orig.GetBody
+	req.ContentLength = orig.ContentLength
+	if ct := orig.Header.Get("Content-Type"); ct != "" {
+		req.Header.Set("Content-Type", ct)
+	}
+	return nil
+}
+
+// Leader returns the base URL of the Leader, as learned from the last redirect the
+// client received, and whether it is known. Nodes redirect requests which must be
+// served by the Leader, so once the client has followed such a redirect, or received
+// a RedirectError with SetFollowRedirects(false), the Leader is known. If no redirect
+// has been received, the Leader known to the client's balancer, if it is a
+// LeaderAwareBalancer, is returned.
+func (c *Client) Leader() (*url.URL, bool) {
+	c.mu.RLock()
+	leader := c.leader
+	c.mu.RUnlock()
+	if leader != nil {
+		return leader, true
+	}
+	if lab, ok := c.lb.(LeaderAwareBalancer); ok {
+		if u, err := lab.Leader(); err == nil {
+			return u, true
+		}
+	}
+	return nil, false
+}
+
+// routeWrite returns the route for a request which may write to the database.
+func (c *Client) routeWrite() route {
+	if RoutingPolicy(c.routingPolicy.Load()) != RoutingPolicyAny {
+		return routeLeader
+	}
+	return routeAny
+}
+
+// routeQuery returns the route for a query made with opts.
+func (c *Client) routeQuery(opts *QueryOptions) route {
+	var level ReadConsistencyLevel
+	if opts != nil {
+		level = opts.Level
+	}
+	switch RoutingPolicy(c.routingPolicy.Load()) {
+	case RoutingPolicyPreferFollowerReads:
+		if level == ReadConsistencyLevelStrong || level == ReadConsistencyLevelLinearizable {
+			return routeLeader
+		}
+		return routeFollower
+	case RoutingPolicyFollowerNoneReads:
+		if level == ReadConsistencyLevelNone {
+			return routeFollower
+		}
+		return routeLeader
+	default:
+		return routeAny
+	}
+}
+
+// nextURL returns the base URL of the node to which a request with route rt
+// should be sent. If the balancer cannot identify a suitable node, the result
+// of its Next() is used.
+func (c *Client) nextURL(rt route) (*url.URL, error) {
+	lab, ok := c.lb.(LeaderAwareBalancer)
+	if !ok || rt == routeAny {
+		return c.lb.Next()
+	}
+
+	var u *url.URL
+	var err error
+	if rt == routeLeader {
+		u, err = lab.Leader()
+	} else {
+		u, err = lab.Follower()
+	}
+	if err != nil {
+		return c.lb.Next()
+	}
+	return u, nil
+}
+
+// makeURLValues converts opts to a url.Values, using the client's duration format.
+func (c *Client) makeURLValues(opts any) (url.Values, error) {
+	c.mu.RLock()
+	df := c.durFormat
+	c.mu.RUnlock()
+	return makeURLValuesWithFormat(opts, df)
+}
+
+// setStatementTimeout sets the timeout query parameter from the Timeout of statements,
+// returning an error if the statements' timeouts conflict with each other, or with the
+// timeout already set in vals.
+func (c *Client) setStatementTimeout(statements SQLStatements, vals url.Values) error {
+	d, err := statements.timeout()
+	if err != nil || d == 0 {
+		return err
+	}
+	c.mu.RLock()
+	df := c.durFormat
+	c.mu.RUnlock()
+	if vals.Has("timeout") && vals.Get("timeout") != df.format(d) {
+		return fmt.Errorf("statement timeout %s differs from request timeout %s", d, vals.Get("timeout"))
+	}
+	vals.Set("timeout", df.format(d))
+	return nil
+}
+
+// setTimeoutFromContext sets the timeout parameter in vals from the deadline of ctx,
+// if the client is configured to do so and the timeout is not already set.
+func (c *Client) setTimeoutFromContext(ctx context.Context, vals url.Values) {
+	if !c.timeoutFromCtx.Load() || vals.Has("timeout") {
+		return
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return
+	}
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		return
+	}
+	c.mu.RLock()
+	df := c.durFormat
+	c.mu.RUnlock()
+	vals.Set("timeout", df.format(remaining*9/10))
+}
+
 func (c *Client) addUserinfoToURL(u *url.URL) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -787,6 +3474,137 @@ func (c *Client) addUserinfoToURL(u *url.URL) {
 	}
 }
 
+// recordingReadCloser wraps an io.ReadCloser, capturing the data read through it
+// and passing that data to a BodyRecorder when the underlying reader is exhausted
+// or closed, whichever comes first.
+type recordingReadCloser struct {
+	rc    io.ReadCloser
+	kind  string
+	fn    BodyRecorder
+	limit int
+
+	buf  bytes.Buffer
+	once sync.Once
+}
+
+func newRecordingReadCloser(rc io.ReadCloser, kind string, fn BodyRecorder, limit int) *recordingReadCloser {
+	return &recordingReadCloser{
+		rc:    rc,
+		kind:  kind,
+		fn:    fn,
+		limit: limit,
+	}
+}
+
+// Read implements io.Reader.
+func (r *recordingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.rc.Read(p)
+	b := p[:n]
+	if r.limit > 0 {
+		b = b[:min(len(b), max(r.limit-r.buf.Len(), 0))]
+	}
+	r.buf.Write(b)
+	if err == io.EOF {
+		r.record()
+	}
+	return n, err
+}
+
+// Close implements io.Closer.
+func (r *recordingReadCloser) Close() error {
+	r.record()
+	return r.rc.Close()
+}
+
+func (r *recordingReadCloser) record() {
+	r.once.Do(func() {
+		r.fn(r.kind, r.buf.Bytes())
+	})
+}
+
+// promotedError returns the error, if any, which should be returned alongside resp
+// given the client's error promotion settings. statements are those the response
+// answers, which identify the SQL of any failed statement.
+func (c *Client) promotedError(resp interface{ statementErrors() []error }, statements SQLStatements) error {
+	if !c.promoteJoinedErrors.Load() && !c.promoteErrors.Load() {
+		return nil
+	}
+	errs := resp.statementErrors()
+	attachSQL(errs, statements)
+	if len(errs) == 0 {
+		return nil
+	}
+	if c.promoteJoinedErrors.Load() {
+		return errors.Join(errs...)
+	}
+	return errs[0]
+}
+
+// attachSQL sets the SQL of each StatementError in errs which identifies one of
+// statements.
+func attachSQL(errs []error, statements SQLStatements) {
+	for _, err := range errs {
+		if se, ok := err.(*StatementError); ok && se.Index >= 0 && se.Index < len(statements) {
+			se.SQL = statements[se.Index].SQL
+		}
+	}
+}
+
+// readAll reads all of r, ensuring any error caused by ctx being done satisfies
+// errors.Is for the context's error.
+func readAll(ctx context.Context, r io.Reader) ([]byte, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, contextError(ctx, err)
+	}
+	return b, nil
+}
+
+// contextError returns err, wrapped if necessary so that errors.Is reports true for
+// context.Canceled or context.DeadlineExceeded if ctx is done.
+func contextError(ctx context.Context, err error) error {
+	ctxErr := ctx.Err()
+	if ctxErr == nil || errors.Is(err, ctxErr) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ctxErr, err)
+}
+
+// checkResultCount returns an error if the number of results does not match the
+// number of statements sent.
+func checkResultCount(nStmts, nResults int) error {
+	if nStmts != nResults {
+		return fmt.Errorf("result count mismatch: sent %d statements, got %d results", nStmts, nResults)
+	}
+	return nil
+}
+
+// sqliteHeader is the magic string at the start of every SQLite database file.
+const sqliteHeader = "SQLite format 3\x00"
+
 func validSQLiteData(b []byte) bool {
-	return len(b) >= 13 && string(b[0:13]) == "SQLite format"
+	return bytes.HasPrefix(b, []byte(sqliteHeader))
+}
+
+// isSQLiteData returns whether the data read from r is a SQLite database file, given
+// its format, and a reader for the data. If the format is LoadFormatAuto, the format
+// is detected from the header of the data, which is read in full even if r returns it
+// in several reads.
+func isSQLiteData(r io.Reader, format LoadFormat) (bool, io.Reader, error) {
+	switch format {
+	case LoadFormatSQLite:
+		return true, r, nil
+	case LoadFormatSQLText:
+		return false, r, nil
+	case LoadFormatAuto:
+	default:
+		return false, nil, fmt.Errorf("unknown load format %d", format)
+	}
+	header := make([]byte, len(sqliteHeader))
+	n, err := io.ReadFull(r, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false, nil, err
+	}
+	header = header[:n]
+	return validSQLiteData(header), io.MultiReader(bytes.NewReader(header), r), nil
 }
@@ -2,15 +2,35 @@ package http
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"database/sql"
+	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
 	"io"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"testing/iotest"
 	"time"
 )
 
@@ -27,6 +47,345 @@ func Test_NewClient(t *testing.T) {
 	}
 }
 
+func Test_UnixSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "rqlite-go-http")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	socketPath := filepath.Join(dir, "rqlite.sock")
+	ln, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("failed to listen on Unix socket: %v", err)
+	}
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/status" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"foo":"bar"}`))
+	}))
+	ts.Listener = ln
+	ts.Start()
+	defer ts.Close()
+
+	client, err := NewClient("unix://"+socketPath, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+	b, err := client.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if exp, got := `{"foo":"bar"}`, string(b); exp != got {
+		t.Fatalf("Expected %s, got %s", exp, got)
+	}
+	if u, ok := client.Leader(); ok {
+		t.Fatalf("Expected Leader to be unknown without a redirect, got %s", u)
+	}
+
+	client, err = NewClient("unix://"+socketPath, WithHTTPClient(NewHTTPUnixSocketClient(socketPath)))
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+	if _, err := client.Status(context.Background()); err != nil {
+		t.Fatalf("Expected nil error with explicit HTTP client, got %v", err)
+	}
+}
+
+func Test_NewHTTPTLSClientWithServerName(t *testing.T) {
+	certPEM, keyPEM := mustGenerateCert(t, "rqlite.local")
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("failed to load key pair: %v", err)
+	}
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caPath, certPEM, 0600); err != nil {
+		t.Fatalf("failed to write CA cert: %v", err)
+	}
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{}`))
+	}))
+	ts.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	ts.StartTLS()
+	defer ts.Close()
+	if !strings.HasPrefix(ts.URL, "https://127.0.0.1:") {
+		t.Fatalf("Expected server on 127.0.0.1, got %s", ts.URL)
+	}
+
+	httpClient, err := NewHTTPTLSClient(caPath)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	client, err := NewClient(ts.URL, WithHTTPClient(httpClient))
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+	if _, err := client.Status(context.Background()); err == nil {
+		t.Fatalf("Expected certificate verification to fail against IP address")
+	}
+
+	httpClient, err = NewHTTPTLSClientWithServerName(caPath, "rqlite.local")
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	client, err = NewClient(ts.URL, WithHTTPClient(httpClient))
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+	if _, err := client.Status(context.Background()); err != nil {
+		t.Fatalf("Expected nil error with server name override, got %v", err)
+	}
+}
+
+func Test_NewHTTPTLSClientWithOptions(t *testing.T) {
+	certPEM, keyPEM := mustGenerateCert(t, "rqlite.local")
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("failed to load key pair: %v", err)
+	}
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caPath, certPEM, 0600); err != nil {
+		t.Fatalf("failed to write CA cert: %v", err)
+	}
+
+	var proto atomic.Int32
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proto.Store(int32(r.ProtoMajor))
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{}`))
+	}))
+	ts.EnableHTTP2 = true
+	ts.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	ts.StartTLS()
+	defer ts.Close()
+
+	for _, http2 := range []bool{false, true} {
+		httpClient, err := NewHTTPTLSClientWithOptions(&TLSClientOptions{
+			CACertPath: caPath,
+			ServerName: "rqlite.local",
+			MinVersion: tls.VersionTLS13,
+			HTTP2:      http2,
+		})
+		if err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+		client, err := NewClient(ts.URL, WithHTTPClient(httpClient))
+		if err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+		defer client.Close()
+		if _, err := client.Status(context.Background()); err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+		exp := int32(1)
+		if http2 {
+			exp = 2
+		}
+		if got := proto.Load(); exp != got {
+			t.Fatalf("Expected HTTP/%d with HTTP2 %t, got HTTP/%d", exp, http2, got)
+		}
+	}
+
+	httpClient, err := NewHTTPTLSClientWithOptions(nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	client, err := NewClient(ts.URL, WithHTTPClient(httpClient))
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+	if _, err := client.Status(context.Background()); err == nil {
+		t.Fatalf("Expected certificate verification to fail against system pool")
+	}
+
+	if _, err := NewHTTPTLSClientWithOptions(&TLSClientOptions{CACertPath: filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+		t.Fatalf("Expected error for missing CA cert")
+	}
+}
+
+func Test_NewHighThroughputClient(t *testing.T) {
+	hc := NewHighThroughputClient(nil)
+	tr := hc.Transport.(*http.Transport)
+	if exp, got := 100, tr.MaxIdleConnsPerHost; exp != got {
+		t.Fatalf("Expected MaxIdleConnsPerHost %d, got %d", exp, got)
+	}
+	if exp, got := 90*time.Second, tr.IdleConnTimeout; exp != got {
+		t.Fatalf("Expected IdleConnTimeout %s, got %s", exp, got)
+	}
+	if exp, got := 5*time.Second, hc.Timeout; exp != got {
+		t.Fatalf("Expected timeout %s, got %s", exp, got)
+	}
+	if tr.ForceAttemptHTTP2 {
+		t.Fatalf("Expected HTTP/2 not to be forced by default")
+	}
+
+	hc = NewHighThroughputClient(&HighThroughputOptions{
+		MaxIdleConnsPerHost: 10,
+		MaxConnsPerHost:     20,
+		IdleConnTimeout:     time.Second,
+		ForceHTTP2:          true,
+		Timeout:             time.Minute,
+	})
+	tr = hc.Transport.(*http.Transport)
+	if tr.MaxIdleConnsPerHost != 10 || tr.MaxConnsPerHost != 20 || tr.IdleConnTimeout != time.Second || !tr.ForceAttemptHTTP2 || hc.Timeout != time.Minute {
+		t.Fatalf("Expected options to be applied, got %+v", tr)
+	}
+	if tr == http.DefaultTransport {
+		t.Fatalf("Expected DefaultTransport not to be modified")
+	}
+}
+
+// Benchmark_HTTPClient compares making concurrent requests with the client returned
+// by DefaultHTTPClient, which keeps at most 2 idle connections per host, and the
+// client returned by NewHighThroughputClient.
+func Benchmark_HTTPClient(b *testing.B) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"results":[{}]}`))
+	}))
+	defer ts.Close()
+
+	for _, bb := range []struct {
+		name string
+		hc   *http.Client
+	}{
+		{"Default", DefaultHTTPClient()},
+		{"HighThroughput", NewHighThroughputClient(nil)},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			client, err := NewClient(ts.URL, WithHTTPClient(bb.hc))
+			if err != nil {
+				b.Fatalf("failed to create client: %s", err)
+			}
+			defer client.Close()
+			b.SetParallelism(16)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := client.ExecuteSingle(context.Background(), "INSERT INTO foo VALUES(1)"); err != nil {
+						b.Errorf("unexpected error: %s", err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+func Test_NewHTTPMutualTLSClientFromPEM(t *testing.T) {
+	serverCertPEM, serverKeyPEM := mustGenerateCert(t, "127.0.0.1")
+	serverCert, err := tls.X509KeyPair(serverCertPEM, serverKeyPEM)
+	if err != nil {
+		t.Fatalf("failed to load key pair: %v", err)
+	}
+	clientCertPEM, clientKeyPEM := mustGenerateCert(t, "client")
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM(clientCertPEM)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{}`))
+	}))
+	ts.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	ts.StartTLS()
+	defer ts.Close()
+
+	status := func(httpClient *http.Client) error {
+		client, err := NewClient(ts.URL, WithHTTPClient(httpClient))
+		if err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+		defer client.Close()
+		_, err = client.Status(context.Background())
+		return err
+	}
+
+	httpClient, err := NewHTTPTLSClientFromPEM(serverCertPEM)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if err := status(httpClient); err == nil {
+		t.Fatalf("Expected error without client certificate")
+	}
+
+	httpClient, err = NewHTTPMutualTLSClientFromPEM(clientCertPEM, clientKeyPEM, serverCertPEM)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if err := status(httpClient); err != nil {
+		t.Fatalf("Expected nil error with client certificate, got %v", err)
+	}
+
+	clientCert, err := tls.X509KeyPair(clientCertPEM, clientKeyPEM)
+	if err != nil {
+		t.Fatalf("failed to load key pair: %v", err)
+	}
+	httpClient, err = NewHTTPTLSClientWithOptions(&TLSClientOptions{
+		CACertPEM:          serverCertPEM,
+		SystemRoots:        true,
+		ClientCertificates: []tls.Certificate{clientCert},
+	})
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if err := status(httpClient); err != nil {
+		t.Fatalf("Expected nil error extending system pool, got %v", err)
+	}
+
+	if _, err := NewHTTPTLSClientFromPEM([]byte("not a certificate")); err == nil {
+		t.Fatalf("Expected error for invalid CA PEM")
+	}
+	if _, err := NewHTTPMutualTLSClientFromPEM(clientCertPEM, serverKeyPEM, serverCertPEM); err == nil {
+		t.Fatalf("Expected error for mismatched client key")
+	}
+}
+
+// mustGenerateCert returns a PEM-encoded self-signed certificate for host, and its key.
+func mustGenerateCert(t *testing.T, host string) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: host},
+		DNSNames:              []string{host},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		tmpl.DNSNames = nil
+		tmpl.IPAddresses = []net.IP{ip}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
 func Test_BasicAuth(t *testing.T) {
 	username := "user"
 	password := "pass"
@@ -85,6 +444,72 @@ func Test_BasicAuth(t *testing.T) {
 	}
 }
 
+func Test_AuthProvider(t *testing.T) {
+	var auths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auths = append(auths, r.Header.Get("Authorization"))
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(ts.URL, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+	client.SetBasicAuth("user", "pass")
+
+	client.SetBearerToken("abc")
+	if _, err := client.Status(context.Background()); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	// A provider is called before each request, so can refresh its token.
+	var n int
+	client.SetAuthProvider(AuthProviderFunc(func(req *http.Request) error {
+		n++
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer token-%d", n))
+		return nil
+	}))
+	for range 2 {
+		if _, err := client.Status(context.Background()); err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+	}
+
+	// Per-call credentials take precedence over the provider.
+	if _, err := client.Status(WithCredentials(context.Background(), "tenant", "pw")); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	client.SetBearerToken("")
+	if _, err := client.Status(context.Background()); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	exp := []string{
+		"Bearer abc",
+		"Bearer token-1",
+		"Bearer token-2",
+		"Basic " + base64.StdEncoding.EncodeToString([]byte("tenant:pw")),
+		"Basic " + base64.StdEncoding.EncodeToString([]byte("user:pass")),
+	}
+	if !reflect.DeepEqual(exp, auths) {
+		t.Fatalf("unexpected Authorization headers\nwant: %q\ngot:  %q", exp, auths)
+	}
+
+	errAuth := errors.New("token unavailable")
+	client.SetAuthProvider(AuthProviderFunc(func(req *http.Request) error {
+		return errAuth
+	}))
+	if _, err := client.Status(context.Background()); !errors.Is(err, errAuth) {
+		t.Fatalf("expected provider error, got %v", err)
+	}
+	if len(auths) != len(exp) {
+		t.Fatalf("expected no request to be sent when provider fails")
+	}
+}
+
 func Test_Execute(t *testing.T) {
 	for _, tt := range []struct {
 		name         string
@@ -189,6 +614,29 @@ func Test_Execute(t *testing.T) {
 	}
 }
 
+func Test_Execute_Headers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if exp, got := []string{"application/json"}, r.Header.Values("Content-Type"); !reflect.DeepEqual(exp, got) {
+			t.Errorf("expected Content-Type exactly %q, got %q", exp, got)
+		}
+		if exp, got := []string{"application/json"}, r.Header.Values("Accept"); !reflect.DeepEqual(exp, got) {
+			t.Errorf("expected Accept exactly %q, got %q", exp, got)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"results": []}`))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+	if _, err := cl.ExecuteSingle(context.Background(), "INSERT INTO foo VALUES(1)"); err != nil {
+		t.Fatalf("unexpected error from Execute: %v", err)
+	}
+}
+
 func Test_Query(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -615,51 +1063,259 @@ func Test_RequestAssoc(t *testing.T) {
 	}
 }
 
-func Test_RaftIndex(t *testing.T) {
-	t.Run("ExecuteWithRaftIndex", func(t *testing.T) {
-		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if r.URL.Path != "/db/execute" {
-				t.Fatalf("Unexpected path: %s", r.URL.Path)
-			}
-			q := r.URL.Query()
-			if _, ok := q["raft_index"]; !ok {
-				t.Fatalf("expected ?raft_index=... to be present, but not found")
-			}
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte(`{"results": [{"last_insert_id": 123, "rows_affected": 1}], "raft_index": 6}`))
-		}))
-		defer ts.Close()
+func Test_RequestResponse_FilteredResults(t *testing.T) {
+	t.Run("Default", func(t *testing.T) {
+		var resp RequestResponse
+		if err := json.Unmarshal([]byte(`{"results": [
+			{"last_insert_id": 1, "rows_affected": 1, "time": 0.1},
+			{"columns": ["id", "name"], "types": ["integer", "text"], "values": [[1, "alice"]]},
+			{"error": "no such table: bar"},
+			{"columns": ["COUNT(*)"], "types": ["integer"], "values": [[1]]},
+			{"last_insert_id": 1, "rows_affected": 0}
+		]}`), &resp); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
 
-		client, err := NewClient(ts.URL, nil)
-		if err != nil {
-			t.Fatalf("Expected nil error, got %v", err)
+		expQR := []QueryResult{
+			{Columns: []string{"id", "name"}, Types: []string{"integer", "text"}, Values: [][]any{{json.Number("1"), "alice"}}},
+			{Columns: []string{"COUNT(*)"}, Types: []string{"integer"}, Values: [][]any{{json.Number("1")}}},
 		}
-		defer client.Close()
+		if got := resp.QueryResults(); !reflect.DeepEqual(expQR, got) {
+			t.Fatalf("unexpected query results\nwant: %+v\ngot:  %+v", expQR, got)
+		}
+		expER := []ExecuteResult{
+			{LastInsertID: 1, RowsAffected: 1, Time: 0.1},
+			{LastInsertID: 1, RowsAffected: 0},
+		}
+		if got := resp.ExecuteResults(); !reflect.DeepEqual(expER, got) {
+			t.Fatalf("unexpected execute results\nwant: %+v\ngot:  %+v", expER, got)
+		}
+	})
 
-		statements := NewSQLStatementsFromStrings([]string{"INSERT INTO foo VALUES(?, ?)"})
-		opts := &ExecuteOptions{RaftIndex: true}
+	t.Run("Associative", func(t *testing.T) {
+		var resp RequestResponse
+		if err := json.Unmarshal([]byte(`{"results": [
+			{"types": {"id": "integer"}, "rows": [{"id": 1}]},
+			{"last_insert_id": 2, "rows_affected": 1}
+		]}`), &resp); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
 
-		resp, err := client.Execute(context.Background(), statements, opts)
-		if err != nil {
-			t.Fatalf("Expected nil error, got %v", err)
+		expQR := []QueryResultAssoc{
+			{Types: map[string]string{"id": "integer"}, Rows: []map[string]any{{"id": json.Number("1")}}},
 		}
-		if resp.RaftIndex != 6 {
-			t.Errorf("Expected RaftIndex=6, got %d", resp.RaftIndex)
+		if got := resp.QueryResults(); !reflect.DeepEqual(expQR, got) {
+			t.Fatalf("unexpected query results\nwant: %+v\ngot:  %+v", expQR, got)
+		}
+		expER := []ExecuteResult{{LastInsertID: 2, RowsAffected: 1}}
+		if got := resp.ExecuteResults(); !reflect.DeepEqual(expER, got) {
+			t.Fatalf("unexpected execute results\nwant: %+v\ngot:  %+v", expER, got)
 		}
 	})
+}
 
-	t.Run("QueryWithRaftIndex", func(t *testing.T) {
-		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if r.URL.Path != "/db/query" {
-				t.Fatalf("Unexpected path: %s", r.URL.Path)
-			}
-			q := r.URL.Query()
-			if _, ok := q["raft_index"]; !ok {
-				t.Fatalf("expected ?raft_index=... to be present, but not found")
-			}
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte(`{"results": [{"columns": ["id", "name"], "values": [[1, "Alice"]]}], "raft_index": 10}`))
-		}))
+func Test_SetPreStatements(t *testing.T) {
+	var gotStmts SQLStatements
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotStmts = nil
+		if err := json.NewDecoder(r.Body).Decode(&gotStmts); err != nil {
+			t.Errorf("failed to decode posted JSON: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+		if r.URL.Path == "/db/request" && len(gotStmts) == 3 {
+			w.Write([]byte(`{"results": [{}, {}, {"columns": ["id"], "types": ["integer"], "values": [[1]]}]}`))
+			return
+		}
+		w.Write([]byte(`{"results": [{"columns": ["id"], "types": ["integer"], "values": [[1]]}]}`))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+	cl.SetPreStatements([]string{"PRAGMA foreign_keys=ON", "PRAGMA case_sensitive_like=ON"})
+	cl.StrictResultCount(true)
+
+	stmts := NewSQLStatementsFromStrings([]string{"SELECT id FROM foo"})
+	resp, err := cl.Request(context.Background(), stmts, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from Request: %v", err)
+	}
+	exp := NewSQLStatementsFromStrings([]string{"PRAGMA foreign_keys=ON", "PRAGMA case_sensitive_like=ON", "SELECT id FROM foo"})
+	if !reflect.DeepEqual(exp, gotStmts) {
+		t.Fatalf("unexpected statements sent\nwant: %v\ngot:  %v", exp, gotStmts)
+	}
+	results := resp.GetRequestResults()
+	if len(results) != 1 || len(results[0].Columns) != 1 {
+		t.Fatalf("expected only the result of the caller's statement, got %+v", results)
+	}
+	if len(stmts) != 1 {
+		t.Fatalf("caller's statements were modified: %v", stmts)
+	}
+
+	// Pre-statements are not sent to other endpoints.
+	if _, err := cl.Query(context.Background(), stmts, nil); err != nil {
+		t.Fatalf("unexpected error from Query: %v", err)
+	}
+	if !reflect.DeepEqual(stmts, gotStmts) {
+		t.Fatalf("unexpected statements sent to /db/query: %v", gotStmts)
+	}
+
+	cl.SetPreStatements(nil)
+	if _, err := cl.Request(context.Background(), stmts, nil); err != nil {
+		t.Fatalf("unexpected error from Request: %v", err)
+	}
+	if !reflect.DeepEqual(stmts, gotStmts) {
+		t.Fatalf("expected no pre-statements after clearing, got %v", gotStmts)
+	}
+}
+
+func Test_SetPreStatements_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"results": [{"error": "near \"PRAGMAX\": syntax error"}, {"columns": ["id"], "types": ["integer"], "values": [[1]]}]}`))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+	cl.SetPreStatements([]string{"PRAGMAX foreign_keys=ON"})
+
+	_, err = cl.Request(context.Background(), NewSQLStatementsFromStrings([]string{"SELECT id FROM foo"}), nil)
+	if err == nil || !strings.Contains(err.Error(), "pre-statement 0") {
+		t.Fatalf("expected pre-statement error, got %v", err)
+	}
+}
+
+func Test_MissingResults(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		body   string
+		expErr string
+	}{
+		{"empty object", `{}`, ""},
+		{"error only", `{"error": "x"}`, "x"},
+		{"null results", `{"results": null}`, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var qr QueryResponse
+			if err := json.Unmarshal([]byte(tt.body), &qr); err != nil {
+				t.Fatalf("unexpected error unmarshalling QueryResponse: %v", err)
+			}
+			if res, ok := qr.Results.([]QueryResult); !ok || res == nil || len(res) != 0 {
+				t.Fatalf("expected empty []QueryResult, got %#v", qr.Results)
+			}
+			if has, idx, msg := qr.HasError(); has != (tt.expErr != "") || msg != tt.expErr || (has && idx != -1) {
+				t.Fatalf("unexpected HasError result: %v, %d, %q", has, idx, msg)
+			}
+
+			var rr RequestResponse
+			if err := json.Unmarshal([]byte(tt.body), &rr); err != nil {
+				t.Fatalf("unexpected error unmarshalling RequestResponse: %v", err)
+			}
+			if res, ok := rr.Results.([]RequestResult); !ok || res == nil || len(res) != 0 {
+				t.Fatalf("expected empty []RequestResult, got %#v", rr.Results)
+			}
+			if has, idx, msg := rr.HasError(); has != (tt.expErr != "") || msg != tt.expErr || (has && idx != -1) {
+				t.Fatalf("unexpected HasError result: %v, %d, %q", has, idx, msg)
+			}
+		})
+	}
+}
+
+func Test_StatementTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if exp, got := "5s", r.URL.Query().Get("timeout"); exp != got {
+			t.Errorf("expected timeout %s, got %s", exp, got)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"results": [{}, {}]}`))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	ctx := context.Background()
+	stmts := SQLStatements{
+		{SQL: "SELECT * FROM foo"},
+		{SQL: "SELECT * FROM big", Timeout: 5 * time.Second},
+	}
+	if _, err := cl.Query(ctx, stmts, nil); err != nil {
+		t.Fatalf("unexpected error from Query: %v", err)
+	}
+	if _, err := cl.Request(ctx, stmts, &RequestOptions{Timeout: 5 * time.Second}); err != nil {
+		t.Fatalf("unexpected error from Request: %v", err)
+	}
+	if _, err := cl.Query(ctx, stmts, &QueryOptions{Timeout: time.Second}); err == nil {
+		t.Fatalf("expected error for statement timeout conflicting with options")
+	}
+	if _, err := cl.Execute(ctx, stmts, nil); err == nil {
+		t.Fatalf("expected error for statement timeout with Execute")
+	}
+
+	mixed := SQLStatements{
+		{SQL: "SELECT * FROM foo", Timeout: time.Second},
+		{SQL: "SELECT * FROM big", Timeout: 5 * time.Second},
+	}
+	if _, err := cl.Query(ctx, mixed, nil); err == nil {
+		t.Fatalf("expected error for mixed statement timeouts")
+	}
+}
+
+func Test_RaftIndex(t *testing.T) {
+	t.Run("ExecuteWithRaftIndex", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/db/execute" {
+				t.Fatalf("Unexpected path: %s", r.URL.Path)
+			}
+			q := r.URL.Query()
+			if _, ok := q["raft_index"]; !ok {
+				t.Fatalf("expected ?raft_index=... to be present, but not found")
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"results": [{"last_insert_id": 123, "rows_affected": 1}], "raft_index": 6}`))
+		}))
+		defer ts.Close()
+
+		client, err := NewClient(ts.URL, nil)
+		if err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+		defer client.Close()
+
+		statements := NewSQLStatementsFromStrings([]string{"INSERT INTO foo VALUES(?, ?)"})
+		opts := &ExecuteOptions{RaftIndex: true}
+
+		resp, err := client.Execute(context.Background(), statements, opts)
+		if err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+		if resp.RaftIndex != 6 {
+			t.Errorf("Expected RaftIndex=6, got %d", resp.RaftIndex)
+		}
+	})
+
+	t.Run("QueryWithRaftIndex", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/db/query" {
+				t.Fatalf("Unexpected path: %s", r.URL.Path)
+			}
+			q := r.URL.Query()
+			if _, ok := q["raft_index"]; !ok {
+				t.Fatalf("expected ?raft_index=... to be present, but not found")
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"results": [{"columns": ["id", "name"], "values": [[1, "Alice"]]}], "raft_index": 10}`))
+		}))
 		defer ts.Close()
 
 		client, err := NewClient(ts.URL, nil)
@@ -680,50 +1336,1510 @@ func Test_RaftIndex(t *testing.T) {
 		}
 	})
 
-	t.Run("RequestWithRaftIndex", func(t *testing.T) {
-		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if r.URL.Path != "/db/request" {
-				t.Fatalf("Unexpected path: %s", r.URL.Path)
+	t.Run("RequestWithRaftIndex", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/db/request" {
+				t.Fatalf("Unexpected path: %s", r.URL.Path)
+			}
+			q := r.URL.Query()
+			if _, ok := q["raft_index"]; !ok {
+				t.Fatalf("expected ?raft_index=... to be present, but not found")
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"results": [{"last_insert_id": 1, "rows_affected": 1}], "raft_index": 15}`))
+		}))
+		defer ts.Close()
+
+		client, err := NewClient(ts.URL, nil)
+		if err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+		defer client.Close()
+
+		statements := NewSQLStatementsFromStrings([]string{"INSERT INTO foo VALUES(?, ?)"})
+		opts := &RequestOptions{
+			RaftIndex: true,
+		}
+
+		resp, err := client.Request(context.Background(), statements, opts)
+		if err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+		if resp.RaftIndex != 15 {
+			t.Errorf("Expected RaftIndex=15, got %d", resp.RaftIndex)
+		}
+	})
+}
+
+func Test_PromoteErrors(t *testing.T) {
+	topLevelErr := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		if topLevelErr {
+			w.Write([]byte(`{"error": "top level error"}`))
+			return
+		}
+		w.Write([]byte(`{"results": [{"error": "some error"}]}`))
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(ts.URL, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+
+	_, err = client.Execute(context.Background(), nil, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	_, err = client.Query(context.Background(), nil, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	_, err = client.Request(context.Background(), nil, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	testFn := func() {
+		_, err = client.Execute(context.Background(), nil, nil)
+		if err == nil {
+			t.Fatalf("Expected non-nil error after promoting errors, got nil")
+		}
+		_, err = client.Query(context.Background(), nil, nil)
+		if err == nil {
+			t.Fatalf("Expected non-nil error after promoting errors, got nil")
+		}
+		_, err = client.Request(context.Background(), nil, nil)
+		if err == nil {
+			t.Fatalf("Expected non-nil error after promoting errors, got nil")
+		}
+	}
+
+	client.PromoteErrors(true)
+	testFn()
+
+	topLevelErr = true
+	testFn()
+}
+
+func Test_PromoteJoinedErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"results": [{"last_insert_id": 1, "rows_affected": 1}, {"error": "UNIQUE constraint failed"}, {"last_insert_id": 3, "rows_affected": 1}]}`))
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(ts.URL, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+	client.PromoteJoinedErrors(true)
+
+	stmts := NewSQLStatementsFromStrings([]string{"INSERT 1", "INSERT 2", "INSERT 3"})
+	er, err := client.Execute(context.Background(), stmts, nil)
+	if err == nil {
+		t.Fatalf("Expected error, got nil")
+	}
+	var stmtErr *StatementError
+	if !errors.As(err, &stmtErr) {
+		t.Fatalf("Expected StatementError, got %v", err)
+	}
+	if exp, got := 1, stmtErr.Index; exp != got {
+		t.Fatalf("Expected error for statement %d, got %d", exp, got)
+	}
+	if er == nil {
+		t.Fatalf("Expected partial results alongside error")
+	}
+	if exp, got := 3, len(er.Results); exp != got {
+		t.Fatalf("Expected %d results, got %d", exp, got)
+	}
+	if er.Results[0].LastInsertID != 1 || er.Results[2].LastInsertID != 3 {
+		t.Fatalf("Expected successful results to be accessible, got %+v", er.Results)
+	}
+}
+
+func Test_StrictResultCount(t *testing.T) {
+	respBody := ""
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(respBody))
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(ts.URL, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+	stmts := NewSQLStatementsFromStrings([]string{"SELECT 1", "SELECT 2"})
+
+	// Mismatched counts are not an error by default.
+	respBody = `{"results": [{"columns": ["1"], "values": [[1]]}]}`
+	if _, err := client.Query(context.Background(), stmts, nil); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	client.StrictResultCount(true)
+	respBody = `{"results": [{"columns": ["1"], "values": [[1]]}, {"columns": ["2"], "values": [[2]]}]}`
+	if _, err := client.Query(context.Background(), stmts, nil); err != nil {
+		t.Fatalf("Expected nil error for matching counts, got %v", err)
+	}
+	if _, err := client.Request(context.Background(), stmts, nil); err != nil {
+		t.Fatalf("Expected nil error for matching counts, got %v", err)
+	}
+
+	respBody = `{"results": [{"columns": ["1"], "values": [[1]]}]}`
+	if _, err := client.Query(context.Background(), stmts, nil); err == nil {
+		t.Fatalf("Expected error for mismatched counts, got nil")
+	}
+	if _, err := client.Request(context.Background(), stmts, nil); err == nil {
+		t.Fatalf("Expected error for mismatched counts, got nil")
+	}
+
+	respBody = `{"results": [{"last_insert_id": 1, "rows_affected": 1}]}`
+	if _, err := client.Execute(context.Background(), stmts, nil); err == nil {
+		t.Fatalf("Expected error for mismatched counts, got nil")
+	}
+
+	// Queued writes are not checked.
+	respBody = `{"results": [], "sequence_number": 1}`
+	if _, err := client.Execute(context.Background(), stmts, &ExecuteOptions{Queue: true}); err != nil {
+		t.Fatalf("Expected nil error for queued write, got %v", err)
+	}
+}
+
+func Test_SharedOptions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var stmts SQLStatements
+		if err := json.NewDecoder(r.Body).Decode(&stmts); err != nil {
+			t.Errorf("Unexpected error decoding body: %v", err)
+		}
+		expAssoc := len(stmts) > 0 && stmts[0].SQL == "assoc"
+		if gotAssoc := r.URL.Query().Has("associative"); expAssoc != gotAssoc {
+			t.Errorf("Expected associative=%v for %s, got %v", expAssoc, stmts[0].SQL, gotAssoc)
+		}
+		w.WriteHeader(http.StatusOK)
+		if expAssoc {
+			w.Write([]byte(`{"results": [{"types": {"id": "integer"}, "rows": [{"id": 1}]}]}`))
+			return
+		}
+		w.Write([]byte(`{"results": [{"columns": ["id"], "types": ["integer"], "values": [[1]]}]}`))
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(ts.URL, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	positional := NewSQLStatementsFromStrings([]string{"positional"})
+	assoc := NewSQLStatementsFromStrings([]string{"assoc"})
+	qOpts := &QueryOptions{Timings: true}
+	rOpts := &RequestOptions{Timings: true}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(6)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Query(ctx, positional, qOpts); err != nil {
+				t.Errorf("Expected nil error, got %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := client.QueryAssoc(ctx, assoc, qOpts); err != nil {
+				t.Errorf("Expected nil error, got %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := client.QueryBatch(ctx, map[string]*SQLStatement{"a": positional[0]}, qOpts); err != nil {
+				t.Errorf("Expected nil error, got %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := client.QuerySnapshot(ctx, positional, qOpts); err != nil {
+				t.Errorf("Expected nil error, got %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := client.Request(ctx, positional, rOpts); err != nil {
+				t.Errorf("Expected nil error, got %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := client.RequestAssoc(ctx, assoc, rOpts); err != nil {
+				t.Errorf("Expected nil error, got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if exp := (QueryOptions{Timings: true}); *qOpts != exp {
+		t.Fatalf("Expected shared query options to be unmodified, got %+v", *qOpts)
+	}
+	if exp := (RequestOptions{Timings: true}); *rOpts != exp {
+		t.Fatalf("Expected shared request options to be unmodified, got %+v", *rOpts)
+	}
+}
+
+func Test_QuerySnapshot(t *testing.T) {
+	statements := NewSQLStatementsFromStrings([]string{"SELECT * FROM foo", "SELECT * FROM bar"})
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/db/query" {
+			t.Fatalf("Unexpected path: %s", r.URL.Path)
+		}
+		expValues := url.Values{
+			"transaction": []string{"true"},
+			"level":       []string{"linearizable"},
+			"timings":     []string{"true"},
+		}
+		if !reflect.DeepEqual(expValues, r.URL.Query()) {
+			t.Fatalf("Expected URL values %v, got %v", expValues, r.URL.Query())
+		}
+		var gotStmts SQLStatements
+		if err := json.NewDecoder(r.Body).Decode(&gotStmts); err != nil {
+			t.Fatalf("Unexpected error decoding body: %v", err)
+		}
+		if !reflect.DeepEqual(statements, gotStmts) {
+			t.Fatalf("Expected statements %v, got %v", statements, gotStmts)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"results": [{"columns": ["id"], "values": [[1]]}, {"columns": ["id"], "values": [[2]]}]}`))
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(ts.URL, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+
+	opts := &QueryOptions{Timings: true, Level: ReadConsistencyLevelWeak}
+	qr, err := client.QuerySnapshot(context.Background(), statements, opts)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if exp, got := 1, requests; exp != got {
+		t.Fatalf("Expected %d request, got %d", exp, got)
+	}
+	if exp, got := 2, len(qr.GetQueryResults()); exp != got {
+		t.Fatalf("Expected %d results, got %d", exp, got)
+	}
+	if opts.Level != ReadConsistencyLevelWeak || opts.Transaction {
+		t.Fatalf("Expected caller's options to be unmodified, got %+v", opts)
+	}
+}
+
+func Test_QueryBatch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("Unexpected error reading body: %v", err)
+		}
+		var stmts SQLStatements
+		if err := json.Unmarshal(body, &stmts); err != nil {
+			t.Fatalf("Unexpected error unmarshalling body: %v", err)
+		}
+
+		// Echo each statement back as its result, so the test can check the mapping.
+		var results []string
+		for _, stmt := range stmts {
+			if stmt.SQL == "SELECT bad" {
+				results = append(results, `{"error": "no such column: bad"}`)
+				continue
+			}
+			results = append(results, fmt.Sprintf(`{"columns": ["sql"], "values": [[%q]]}`, stmt.SQL))
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{"results": [%s]}`, strings.Join(results, ","))
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(ts.URL, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+
+	batch := map[string]*SQLStatement{
+		"users":  {SQL: "SELECT * FROM users"},
+		"orders": {SQL: "SELECT * FROM orders"},
+		"broken": {SQL: "SELECT bad"},
+		"count":  {SQL: "SELECT COUNT(*) FROM users"},
+	}
+	results, err := client.QueryBatch(context.Background(), batch, &QueryOptions{Associative: true})
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if exp, got := len(batch), len(results); exp != got {
+		t.Fatalf("Expected %d results, got %d", exp, got)
+	}
+	for name, stmt := range batch {
+		res, ok := results[name]
+		if !ok {
+			t.Fatalf("Missing result for %s", name)
+		}
+		if name == "broken" {
+			if res.Error == "" {
+				t.Fatalf("Expected error result for %s", name)
+			}
+			continue
+		}
+		if exp, got := stmt.SQL, res.Values[0][0]; exp != got {
+			t.Fatalf("Expected result %s for %s, got %v", exp, name, got)
+		}
+	}
+}
+
+func Test_QueryScalar(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("Unexpected error reading body: %v", err)
+		}
+		var stmts SQLStatements
+		if err := json.Unmarshal(body, &stmts); err != nil {
+			t.Fatalf("Unexpected error unmarshalling body: %v", err)
+		}
+
+		w.WriteHeader(http.StatusOK)
+		switch stmts[0].SQL {
+		case "SELECT MAX(id) FROM foo":
+			w.Write([]byte(`{"results": [{"columns": ["MAX(id)"], "types": ["integer"], "values": [[42]]}]}`))
+		case "SELECT COUNT(*) FROM bar":
+			w.Write([]byte(`{"results": [{"columns": ["COUNT(*)"], "types": ["integer"], "values": [[300]]}]}`))
+		case "SELECT AVG(id) FROM foo":
+			w.Write([]byte(`{"results": [{"columns": ["AVG(id)"], "types": ["real"], "values": [[1.5]]}]}`))
+		case "SELECT name FROM foo":
+			w.Write([]byte(`{"results": [{"columns": ["name"], "types": ["text"], "values": [["fiona"]]}]}`))
+		case "SELECT nickname FROM foo":
+			w.Write([]byte(`{"results": [{"columns": ["nickname"], "types": ["text"], "values": [[null]]}]}`))
+		default:
+			w.Write([]byte(`{"results": [{"columns": ["name"], "types": ["text"]}]}`))
+		}
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(ts.URL, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	v, err := client.QueryScalar(ctx, "SELECT MAX(id) FROM foo")
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if exp, got := int64(42), v; exp != got {
+		t.Fatalf("Expected %v (%T), got %v (%T)", exp, exp, got, got)
+	}
+	v, err = client.QueryScalar(ctx, "SELECT AVG(id) FROM foo")
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if exp, got := 1.5, v; exp != got {
+		t.Fatalf("Expected %v (%T), got %v (%T)", exp, exp, got, got)
+	}
+	v, err = client.QueryScalar(ctx, "SELECT name FROM foo")
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if exp, got := "fiona", v; exp != got {
+		t.Fatalf("Expected %v, got %v", exp, got)
+	}
+	if _, err := client.QueryScalar(ctx, "SELECT name FROM foo WHERE id=?", 99); err != ErrNoRows {
+		t.Fatalf("Expected ErrNoRows, got %v", err)
+	}
+
+	i, err := ScalarInto[int](ctx, client, "SELECT MAX(id) FROM foo")
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if exp, got := 42, i; exp != got {
+		t.Fatalf("Expected %d, got %d", exp, got)
+	}
+	name, err := ScalarInto[string](ctx, client, "SELECT name FROM foo")
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if exp, got := "fiona", name; exp != got {
+		t.Fatalf("Expected %s, got %s", exp, got)
+	}
+	if _, err := ScalarInto[int](ctx, client, "SELECT name FROM foo"); err == nil {
+		t.Fatalf("Expected error converting text to int, got nil")
+	}
+	if _, err := ScalarInto[int](ctx, client, "SELECT AVG(id) FROM foo"); err == nil {
+		t.Fatalf("Expected error converting 1.5 to int, got nil")
+	}
+	if _, err := ScalarInto[int8](ctx, client, "SELECT COUNT(*) FROM bar"); err == nil {
+		t.Fatalf("Expected error converting 300 to int8, got nil")
+	}
+	f, err := ScalarInto[float32](ctx, client, "SELECT MAX(id) FROM foo")
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if exp, got := float32(42), f; exp != got {
+		t.Fatalf("Expected %v, got %v", exp, got)
+	}
+	if _, err := ScalarInto[int](ctx, client, "SELECT name FROM foo WHERE id=?", 99); err != ErrNoRows {
+		t.Fatalf("Expected ErrNoRows, got %v", err)
+	}
+
+	ns, err := ScalarInto[sql.NullString](ctx, client, "SELECT nickname FROM foo")
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if exp := (sql.NullString{}); ns != exp {
+		t.Fatalf("Expected %+v, got %+v", exp, ns)
+	}
+	ns, err = ScalarInto[sql.NullString](ctx, client, "SELECT name FROM foo")
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if exp := (sql.NullString{String: "fiona", Valid: true}); ns != exp {
+		t.Fatalf("Expected %+v, got %+v", exp, ns)
+	}
+	ni, err := ScalarInto[sql.NullInt64](ctx, client, "SELECT MAX(id) FROM foo")
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if exp := (sql.NullInt64{Int64: 42, Valid: true}); ni != exp {
+		t.Fatalf("Expected %+v, got %+v", exp, ni)
+	}
+	if _, err := ScalarInto[sql.NullInt64](ctx, client, "SELECT name FROM foo"); err == nil {
+		t.Fatalf("Expected error scanning text into sql.NullInt64, got nil")
+	}
+}
+
+func Test_VerifyReplication(t *testing.T) {
+	newServer := func(count int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if exp, got := "none", r.URL.Query().Get("level"); exp != got {
+				t.Errorf("expected level %s, got %s", exp, got)
+			}
+			if u, p, _ := r.BasicAuth(); u != "user" || p != "pass" {
+				t.Errorf("expected basic auth credentials, got %s:%s", u, p)
+			}
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, `{"results": [{"columns": ["COUNT(*)"], "types": ["integer"], "values": [[%d]]}]}`, count)
+		}))
+	}
+	a, b, c := newServer(10), newServer(10), newServer(9)
+	defer a.Close()
+	defer b.Close()
+	defer c.Close()
+
+	cl, err := NewClient(a.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+	cl.SetBasicAuth("user", "pass")
+
+	ok, values, err := cl.VerifyReplication(context.Background(), "SELECT COUNT(*) FROM foo", []string{a.URL, b.URL})
+	if err != nil {
+		t.Fatalf("unexpected error calling VerifyReplication: %v", err)
+	}
+	if !ok {
+		t.Fatalf("expected matching values, got %v", values)
+	}
+	if exp := map[string]any{a.URL: int64(10), b.URL: int64(10)}; !reflect.DeepEqual(exp, values) {
+		t.Fatalf("expected values %v, got %v", exp, values)
+	}
+
+	ok, values, err = cl.VerifyReplication(context.Background(), "SELECT COUNT(*) FROM foo", []string{a.URL, b.URL, c.URL})
+	if err != nil {
+		t.Fatalf("unexpected error calling VerifyReplication: %v", err)
+	}
+	if ok {
+		t.Fatalf("expected mismatched values, got %v", values)
+	}
+	if exp, got := int64(9), values[c.URL]; exp != got {
+		t.Fatalf("expected %d from lagging host, got %v", exp, got)
+	}
+
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	if _, _, err := cl.VerifyReplication(context.Background(), "SELECT COUNT(*) FROM foo", []string{a.URL, down.URL}); err == nil {
+		t.Fatalf("expected error for unreachable host")
+	}
+
+	empty := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"results": [{"columns": ["x"], "types": ["integer"]}]}`))
+	}))
+	defer empty.Close()
+	null := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"results": [{"columns": ["x"], "types": ["integer"], "values": [[null]]}]}`))
+	}))
+	defer null.Close()
+	ok, values, err = cl.VerifyReplication(context.Background(), "SELECT x FROM foo", []string{empty.URL, null.URL})
+	if err != nil {
+		t.Fatalf("unexpected error calling VerifyReplication: %v", err)
+	}
+	if ok {
+		t.Fatalf("expected no rows not to match NULL, got %v", values)
+	}
+	if _, found := values[empty.URL]; found {
+		t.Fatalf("expected no value for host without rows, got %v", values)
+	}
+	if ok, values, err = cl.VerifyReplication(context.Background(), "SELECT x FROM foo", []string{empty.URL, empty.URL}); err != nil || !ok {
+		t.Fatalf("expected hosts without rows to match, got %v, %v", values, err)
+	}
+
+	var requests atomic.Int32
+	counted := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer counted.Close()
+	if _, _, err := cl.VerifyReplication(context.Background(), "SELECT COUNT(*) FROM foo", []string{counted.URL, "http://[::1"}); err == nil {
+		t.Fatalf("expected error for invalid host")
+	}
+	if n := requests.Load(); n != 0 {
+		t.Fatalf("expected no requests when a host is invalid, got %d", n)
+	}
+}
+
+func Test_TableExists(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/db/query" {
+			t.Fatalf("Unexpected path: %s", r.URL.Path)
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("Unexpected error reading body: %v", err)
+		}
+		var stmts SQLStatements
+		if err := json.Unmarshal(body, &stmts); err != nil {
+			t.Fatalf("Unexpected error unmarshalling body: %v", err)
+		}
+		if len(stmts) != 1 || len(stmts[0].PositionalParams) != 1 {
+			t.Fatalf("Expected single parameterized statement, got %v", stmts)
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if stmts[0].PositionalParams[0] == "foo" {
+			w.Write([]byte(`{"results": [{"columns": ["name"], "types": ["text"], "values": [["foo"]]}]}`))
+			return
+		}
+		w.Write([]byte(`{"results": [{"columns": ["name"], "types": ["text"]}]}`))
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(ts.URL, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+
+	exists, err := client.TableExists(context.Background(), "foo")
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if !exists {
+		t.Fatalf("Expected table foo to exist")
+	}
+
+	exists, err = client.TableExists(context.Background(), "bar")
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if exists {
+		t.Fatalf("Expected table bar to not exist")
+	}
+}
+
+func Test_BodyRecorder(t *testing.T) {
+	respBody := `{"results": [{"last_insert_id": 1, "rows_affected": 1}]}`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(respBody))
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(ts.URL, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+
+	var mu sync.Mutex
+	recorded := make(map[string][]byte)
+	client.SetBodyRecorder(func(kind string, data []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		recorded[kind] = bytes.Clone(data)
+	})
+
+	stmts := NewSQLStatementsFromStrings([]string{"INSERT INTO foo VALUES(1)"})
+	if _, err := client.Execute(context.Background(), stmts, nil); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	expReq, err := stmts.MarshalJSON()
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	mu.Lock()
+	if exp, got := string(expReq), string(recorded[BodyKindRequest]); exp != got {
+		t.Fatalf("Expected recorded request %s, got %s", exp, got)
+	}
+	if exp, got := respBody, string(recorded[BodyKindResponse]); exp != got {
+		t.Fatalf("Expected recorded response %s, got %s", exp, got)
+	}
+	mu.Unlock()
+
+	client.SetBodyRecorderLimit(5)
+	if _, err := client.Execute(context.Background(), stmts, nil); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	mu.Lock()
+	if exp, got := string(expReq[:5]), string(recorded[BodyKindRequest]); exp != got {
+		t.Fatalf("Expected truncated recorded request %s, got %s", exp, got)
+	}
+	if exp, got := respBody[:5], string(recorded[BodyKindResponse]); exp != got {
+		t.Fatalf("Expected truncated recorded response %s, got %s", exp, got)
+	}
+	mu.Unlock()
+}
+
+func Test_MaxConcurrentRequests(t *testing.T) {
+	var mu sync.Mutex
+	curr, maxSeen := 0, 0
+	arrived := make(chan struct{}, 10)
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		curr++
+		maxSeen = max(maxSeen, curr)
+		mu.Unlock()
+		arrived <- struct{}{}
+
+		<-release
+		mu.Lock()
+		curr--
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("{}"))
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(ts.URL, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+	client.SetMaxConcurrentRequests(2, false)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Status(context.Background()); err != nil {
+				t.Errorf("Expected nil error, got %v", err)
+			}
+		}()
+	}
+	<-arrived
+	<-arrived
+	select {
+	case <-arrived:
+		t.Fatalf("More than 2 requests reached the server concurrently")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if exp, got := 2, client.InFlight(); exp != got {
+		t.Fatalf("Expected %d requests in flight, got %d", exp, got)
+	}
+
+	// A waiting request should respect context cancellation.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := client.Status(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	// A fail-fast client should not wait.
+	client.SetMaxConcurrentRequests(1, true)
+	go client.Status(context.Background())
+	<-arrived
+	if _, err := client.Status(context.Background()); err != ErrTooManyRequests {
+		t.Fatalf("Expected ErrTooManyRequests, got %v", err)
+	}
+
+	close(release)
+	wg.Wait()
+	mu.Lock()
+	defer mu.Unlock()
+	if maxSeen > 3 {
+		t.Fatalf("Expected at most 3 concurrent requests, got %d", maxSeen)
+	}
+}
+
+func Test_SetMaxConcurrentRequests_StreamedBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(ts.URL, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+	client.SetMaxConcurrentRequests(1, true)
+
+	rc, err := client.Backup(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if _, err := client.Status(context.Background()); err != ErrTooManyRequests {
+		t.Fatalf("Expected ErrTooManyRequests while backup is open, got %v", err)
+	}
+	rc.Close()
+	rc.Close()
+	if _, err := client.Status(context.Background()); err != nil {
+		t.Fatalf("Expected nil error once backup is closed, got %v", err)
+	}
+}
+
+func Test_HTTPError(t *testing.T) {
+	for _, tt := range []struct {
+		name           string
+		statusCode     int
+		body           string
+		expRQLiteError string
+	}{
+		{
+			name:           "400 with JSON error",
+			statusCode:     http.StatusBadRequest,
+			body:           `{"error": "near \"SELEC\": syntax error"}`,
+			expRQLiteError: `near "SELEC": syntax error`,
+		},
+		{
+			name:           "500 with plain text",
+			statusCode:     http.StatusInternalServerError,
+			body:           "internal error",
+			expRQLiteError: "",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte(tt.body))
+			}))
+			defer ts.Close()
+
+			client, err := NewClient(ts.URL, nil)
+			if err != nil {
+				t.Fatalf("Expected nil error, got %v", err)
+			}
+			defer client.Close()
+
+			_, err = client.QuerySingle(context.Background(), "SELECT 1")
+			var httpErr *HTTPError
+			if !errors.As(err, &httpErr) {
+				t.Fatalf("Expected HTTPError, got %v", err)
+			}
+			if exp, got := tt.statusCode, httpErr.StatusCode; exp != got {
+				t.Fatalf("Expected status code %d, got %d", exp, got)
+			}
+			if exp, got := tt.body, string(httpErr.Body); exp != got {
+				t.Fatalf("Expected body %s, got %s", exp, got)
+			}
+			if exp, got := tt.expRQLiteError, httpErr.RQLiteError; exp != got {
+				t.Fatalf("Expected rqlite error %s, got %s", exp, got)
+			}
+		})
+	}
+}
+
+func Test_SetVerboseErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "bad request"}`))
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(ts.URL, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+
+	stmts := SQLStatements{{SQL: "INSERT INTO foo VALUES(?)", PositionalParams: []any{"fiona"}}}
+	opts := &ExecuteOptions{Transaction: true}
+
+	_, err = client.Execute(context.Background(), stmts, opts)
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("Expected HTTPError, got %v", err)
+	}
+	if httpErr.Statements != nil || httpErr.Params != nil {
+		t.Fatalf("Expected no request attached by default, got %v %v", httpErr.Statements, httpErr.Params)
+	}
+
+	client.SetVerboseErrors(true)
+	for _, fn := range []func() error{
+		func() error { _, err := client.Execute(context.Background(), stmts, opts); return err },
+		func() error {
+			_, err := client.Request(context.Background(), stmts, &RequestOptions{Transaction: true})
+			return err
+		},
+	} {
+		if err := fn(); !errors.As(err, &httpErr) {
+			t.Fatalf("Expected HTTPError, got %v", err)
+		}
+		if !reflect.DeepEqual(stmts, httpErr.Statements) {
+			t.Fatalf("Expected statements %v attached, got %v", stmts, httpErr.Statements)
+		}
+		if httpErr.Statements[0] == stmts[0] {
+			t.Fatalf("Expected attached statements to be a copy")
+		}
+		if !httpErr.Params.Has("transaction") {
+			t.Fatalf("Expected transaction parameter attached, got %v", httpErr.Params)
+		}
+	}
+
+	_, err = client.QuerySingle(context.Background(), "SELECT * FROM foo WHERE name = ?", "fiona")
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("Expected HTTPError, got %v", err)
+	}
+	if len(httpErr.Statements) != 1 || httpErr.Statements[0].SQL != "SELECT * FROM foo WHERE name = ?" {
+		t.Fatalf("Expected query attached, got %v", httpErr.Statements)
+	}
+}
+
+func Test_KeepAlive(t *testing.T) {
+	var pings atomic.Int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/readyz" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		pings.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(ts.URL, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	client.SetKeepAlive(20 * time.Millisecond)
+	time.Sleep(110 * time.Millisecond)
+	if err := client.Close(); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+
+	n := pings.Load()
+	if n < 2 || n > 5 {
+		t.Fatalf("Expected between 2 and 5 pings, got %d", n)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if exp, got := n, pings.Load(); exp != got {
+		t.Fatalf("Expected pings to stop after Close, got %d more", got-exp)
+	}
+}
+
+func Test_FollowRedirects(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("{}"))
+	}))
+	defer target.Close()
+	location := target.URL + "/status"
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, location, http.StatusFound)
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(ts.URL, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.Status(context.Background()); err != nil {
+		t.Fatalf("Expected nil error following redirect, got %v", err)
+	}
+
+	client.SetFollowRedirects(false)
+	_, err = client.Status(context.Background())
+	var redirectErr *RedirectError
+	if !errors.As(err, &redirectErr) {
+		t.Fatalf("Expected RedirectError, got %v", err)
+	}
+	if exp, got := http.StatusFound, redirectErr.StatusCode; exp != got {
+		t.Fatalf("Expected status code %d, got %d", exp, got)
+	}
+	if exp, got := location, redirectErr.Location; exp != got {
+		t.Fatalf("Expected location %s, got %s", exp, got)
+	}
+}
+
+func Test_FollowRedirects_Leader(t *testing.T) {
+	var gotMethod, gotBody, gotCT string
+	leader := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotCT = r.Method, r.Header.Get("Content-Type")
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		w.Write([]byte(`{"results": [{"rows_affected": 1}]}`))
+	}))
+	defer leader.Close()
+
+	follower := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, leader.URL+r.URL.RequestURI(), http.StatusMovedPermanently)
+	}))
+	defer follower.Close()
+
+	client, err := NewClient(follower.URL, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+	if _, ok := client.Leader(); ok {
+		t.Fatalf("Expected Leader to be unknown")
+	}
+
+	// The write is sent to the Leader as a POST with its body, despite the 301.
+	if _, err := client.ExecuteSingle(context.Background(), "INSERT INTO foo VALUES(1)"); err != nil {
+		t.Fatalf("Expected nil error following redirect, got %v", err)
+	}
+	if gotMethod != http.MethodPost || gotCT != "application/json" || !strings.Contains(gotBody, "INSERT INTO foo VALUES(1)") {
+		t.Fatalf("Expected write to be replayed to leader, got %s %s %q", gotMethod, gotCT, gotBody)
+	}
+	u, ok := client.Leader()
+	if !ok || u.String() != leader.URL {
+		t.Fatalf("Expected leader %s, got %v", leader.URL, u)
+	}
+
+	// The Leader is also learned from redirects which are not followed.
+	client2, err := NewClient(follower.URL, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client2.Close()
+	client2.SetFollowRedirects(false)
+	if _, err := client2.ExecuteSingle(context.Background(), "INSERT INTO foo VALUES(1)"); !errors.Is(err, ErrNotLeader) {
+		t.Fatalf("Expected ErrNotLeader, got %v", err)
+	}
+	if u, ok := client2.Leader(); !ok || u.String() != leader.URL {
+		t.Fatalf("Expected leader %s, got %v", leader.URL, u)
+	}
+}
+
+func Test_DurationFormat(t *testing.T) {
+	var gotTimeout string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTimeout = r.URL.Query().Get("timeout")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"results": []}`))
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(ts.URL, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+
+	opts := &QueryOptions{Timeout: time.Second}
+	if _, err := client.Query(context.Background(), nil, opts); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if exp, got := "1s", gotTimeout; exp != got {
+		t.Fatalf("Expected timeout=%s, got %s", exp, got)
+	}
+
+	client.SetDurationFormat(DurationFormatSeconds)
+	if _, err := client.Query(context.Background(), nil, opts); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if exp, got := "1", gotTimeout; exp != got {
+		t.Fatalf("Expected timeout=%s, got %s", exp, got)
+	}
+}
+
+func Test_TimeoutFromContext(t *testing.T) {
+	var gotTimeout string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTimeout = r.URL.Query().Get("timeout")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"results": []}`))
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(ts.URL, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 4*time.Second)
+	defer cancel()
+
+	// Not derived by default.
+	if _, err := client.Query(ctx, nil, nil); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if gotTimeout != "" {
+		t.Fatalf("Expected no timeout, got %s", gotTimeout)
+	}
+
+	client.SetTimeoutFromContext(true)
+	for name, fn := range map[string]func() error{
+		"Execute": func() error { _, err := client.Execute(ctx, nil, nil); return err },
+		"Query":   func() error { _, err := client.Query(ctx, nil, nil); return err },
+		"Request": func() error { _, err := client.Request(ctx, nil, nil); return err },
+	} {
+		gotTimeout = ""
+		if err := fn(); err != nil {
+			t.Fatalf("%s: expected nil error, got %v", name, err)
+		}
+		d, err := time.ParseDuration(gotTimeout)
+		if err != nil {
+			t.Fatalf("%s: failed to parse timeout %q: %v", name, gotTimeout, err)
+		}
+		if d < 3*time.Second || d >= 4*time.Second {
+			t.Fatalf("%s: expected timeout between 3s and 4s, got %s", name, d)
+		}
+	}
+
+	// An explicit timeout takes precedence.
+	if _, err := client.Query(ctx, nil, &QueryOptions{Timeout: time.Second}); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if exp, got := "1s", gotTimeout; exp != got {
+		t.Fatalf("Expected timeout=%s, got %s", exp, got)
+	}
+
+	// No deadline means no timeout.
+	if _, err := client.Query(context.Background(), nil, nil); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if gotTimeout != "" {
+		t.Fatalf("Expected no timeout, got %s", gotTimeout)
+	}
+}
+
+func Test_RoutingPolicy(t *testing.T) {
+	var mu sync.Mutex
+	var hits []string
+	newServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			hits = append(hits, name)
+			mu.Unlock()
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"results": []}`))
+		}))
+	}
+	leader := newServer("leader")
+	defer leader.Close()
+	follower := newServer("follower")
+	defer follower.Close()
+
+	lb := &staticLeaderBalancer{
+		leader:   mustParseURL(leader.URL),
+		follower: mustParseURL(follower.URL),
+	}
+	client, err := NewClientWithBalancer(lb, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	for _, tt := range []struct {
+		name    string
+		fn      func() error
+		exp     string
+		expNone string
+	}{
+		{"execute", func() error { _, err := client.Execute(ctx, nil, nil); return err }, "leader", "leader"},
+		{"request", func() error { _, err := client.Request(ctx, nil, nil); return err }, "leader", "leader"},
+		{"query default", func() error { _, err := client.Query(ctx, nil, nil); return err }, "follower", "leader"},
+		{"query none", func() error {
+			_, err := client.Query(ctx, nil, &QueryOptions{Level: ReadConsistencyLevelNone})
+			return err
+		}, "follower", "follower"},
+		{"query weak", func() error {
+			_, err := client.Query(ctx, nil, &QueryOptions{Level: ReadConsistencyLevelWeak})
+			return err
+		}, "follower", "leader"},
+		{"query strong", func() error {
+			_, err := client.Query(ctx, nil, &QueryOptions{Level: ReadConsistencyLevelStrong})
+			return err
+		}, "leader", "leader"},
+		{"query linearizable", func() error {
+			_, err := client.Query(ctx, nil, &QueryOptions{Level: ReadConsistencyLevelLinearizable})
+			return err
+		}, "leader", "leader"},
+	} {
+		for _, policy := range []RoutingPolicy{RoutingPolicyPreferFollowerReads, RoutingPolicyFollowerNoneReads} {
+			exp := tt.exp
+			if policy == RoutingPolicyFollowerNoneReads {
+				exp = tt.expNone
+			}
+			t.Run(fmt.Sprintf("%s policy %d", tt.name, policy), func(t *testing.T) {
+				client.SetRoutingPolicy(policy)
+				mu.Lock()
+				hits = nil
+				mu.Unlock()
+				if err := tt.fn(); err != nil {
+					t.Fatalf("Expected nil error, got %v", err)
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				if len(hits) != 1 || hits[0] != exp {
+					t.Fatalf("Expected request to hit %s, got %v", exp, hits)
+				}
+			})
+		}
+	}
+}
+
+func Test_RetryStaleReads(t *testing.T) {
+	var followerHits, leaderHits atomic.Int32
+	follower := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		followerHits.Add(1)
+		q := r.URL.Query()
+		if q.Get("level") != "none" || q.Get("freshness") == "" || q.Get("freshness_strict") != "true" {
+			t.Errorf("Unexpected query parameters on follower: %s", r.URL.RawQuery)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"error": "stale read"}`))
+	}))
+	defer follower.Close()
+	leader := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaderHits.Add(1)
+		q := r.URL.Query()
+		if q.Get("level") != "weak" || q.Has("freshness") || q.Has("freshness_strict") {
+			t.Errorf("Unexpected query parameters on leader: %s", r.URL.RawQuery)
+		}
+		if !q.Has("timings") {
+			t.Errorf("Expected other options to be preserved, got %s", r.URL.RawQuery)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"results": [{"columns": ["id"], "types": ["integer"], "values": [[1]]}]}`))
+	}))
+	defer leader.Close()
+
+	lb := &staticLeaderBalancer{
+		leader:   mustParseURL(leader.URL),
+		follower: mustParseURL(follower.URL),
+	}
+	client, err := NewClientWithBalancer(lb, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+	client.SetRoutingPolicy(RoutingPolicyPreferFollowerReads)
+	client.PromoteErrors(true)
+
+	stmts := SQLStatements{{SQL: "SELECT id FROM foo"}}
+	opts := (&QueryOptions{Timings: true}).WithMaxStaleness(time.Second, true)
+
+	// Without retries enabled, the stale read error is returned.
+	if _, err := client.Query(context.Background(), stmts, opts); err == nil || !strings.Contains(err.Error(), "stale read") {
+		t.Fatalf("Expected stale read error, got %v", err)
+	}
+	if followerHits.Load() != 1 || leaderHits.Load() != 0 {
+		t.Fatalf("Expected only the follower to be queried, got %d follower and %d leader hits", followerHits.Load(), leaderHits.Load())
+	}
+
+	client.RetryStaleReads(true)
+	qr, err := client.Query(context.Background(), stmts, opts)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if exp, got := 1, len(qr.GetQueryResults()); exp != got {
+		t.Fatalf("Expected %d results, got %d", exp, got)
+	}
+	if followerHits.Load() != 2 || leaderHits.Load() != 1 {
+		t.Fatalf("Expected retry against leader, got %d follower and %d leader hits", followerHits.Load(), leaderHits.Load())
+	}
+	if opts.Level != ReadConsistencyLevelNone || opts.Freshness != time.Second {
+		t.Fatalf("Caller's options were modified: %+v", opts)
+	}
+}
+
+func Test_SetMaxRetries(t *testing.T) {
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		if hits.Add(1) == 1 {
+			// Fail the first attempt after the request has been received.
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Errorf("Unexpected error hijacking connection: %v", err)
+				return
+			}
+			conn.Close()
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"results": []}`))
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(ts.URL, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+	client.SetMaxRetries(2)
+
+	ctx := context.Background()
+	stmts := NewSQLStatementsFromStrings([]string{"SELECT 1"})
+	for _, tt := range []struct {
+		name    string
+		fn      func() error
+		expHits int32
+		expErr  bool
+	}{
+		{"query", func() error { _, err := client.Query(ctx, stmts, nil); return err }, 2, false},
+		{"request read-only", func() error {
+			_, err := client.Request(ctx, stmts, &RequestOptions{ReadOnly: true})
+			return err
+		}, 2, false},
+		{"request", func() error { _, err := client.Request(ctx, stmts, nil); return err }, 1, true},
+		{"execute", func() error { _, err := client.Execute(ctx, stmts, nil); return err }, 1, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			hits.Store(0)
+			err := tt.fn()
+			if tt.expErr != (err != nil) {
+				t.Fatalf("Expected error %v, got %v", tt.expErr, err)
+			}
+			if exp, got := tt.expHits, hits.Load(); exp != got {
+				t.Fatalf("Expected %d attempts, got %d", exp, got)
+			}
+		})
+	}
+}
+
+func Test_NewClientWithBalancer_RoundRobin(t *testing.T) {
+	var hits [2]atomic.Int32
+	var servers [2]*httptest.Server
+	for i := range servers {
+		servers[i] = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits[i].Add(1)
+			w.Write([]byte(`{}`))
+		}))
+		defer servers[i].Close()
+	}
+
+	lb, err := NewRoundRobinBalancer([]string{servers[0].URL, servers[1].URL}, func(*url.URL) bool { return true }, time.Hour)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer lb.Close()
+	client, err := NewClientWithBalancer(lb, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+
+	for range 4 {
+		if _, err := client.Status(context.Background()); err != nil {
+			t.Fatalf("Expected nil error, got %v", err)
+		}
+	}
+	if hits[0].Load() != 2 || hits[1].Load() != 2 {
+		t.Fatalf("Expected requests spread evenly, got %d and %d", hits[0].Load(), hits[1].Load())
+	}
+}
+
+func Test_SetMaxRetries_WriteNotSent(t *testing.T) {
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"results": [{"last_insert_id": 1, "rows_affected": 1}]}`))
+	}))
+	defer ts.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	lb := &sequenceBalancer{urls: []*url.URL{mustParseURL(down.URL), mustParseURL(ts.URL)}}
+	client, err := NewClientWithBalancer(lb, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+	client.SetMaxRetries(1)
+
+	if _, err := client.ExecuteSingle(context.Background(), "INSERT INTO foo VALUES(1)"); err != nil {
+		t.Fatalf("Expected write to be retried against next node, got %v", err)
+	}
+	if exp, got := int32(1), hits.Load(); exp != got {
+		t.Fatalf("Expected %d request to reach server, got %d", exp, got)
+	}
+}
+
+func Test_ErrQueueFull(t *testing.T) {
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("queue is full"))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"results": [{"last_insert_id": 1, "rows_affected": 1}]}`))
+	}))
+	defer ts.Close()
+
+	client, err := NewClient(ts.URL, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	defer client.Close()
+
+	_, err = client.ExecuteSingle(context.Background(), "INSERT INTO foo VALUES(1)")
+	if !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("Expected ErrQueueFull, got %v", err)
+	}
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Expected HTTPError with status 503, got %v", err)
+	}
+
+	hits.Store(0)
+	client.SetMaxRetries(1)
+	if _, err := client.ExecuteSingle(context.Background(), "INSERT INTO foo VALUES(1)"); err != nil {
+		t.Fatalf("Expected write to be retried after backpressure, got %v", err)
+	}
+	if exp, got := int32(2), hits.Load(); exp != got {
+		t.Fatalf("Expected %d requests, got %d", exp, got)
+	}
+
+	if !errors.Is(&HTTPError{StatusCode: http.StatusTooManyRequests}, ErrQueueFull) {
+		t.Fatalf("Expected 429 to match ErrQueueFull")
+	}
+	if errors.Is(&HTTPError{StatusCode: http.StatusServiceUnavailable, Body: []byte("leader not found")}, ErrQueueFull) {
+		t.Fatalf("Expected unrelated 503 not to match ErrQueueFull")
+	}
+}
+
+func Test_TypedErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		err     error
+		targets []error
+		not     []error
+	}{
+		{
+			name:    "bad request",
+			err:     newHTTPError(http.StatusBadRequest, []byte(`{"error": "bad statement"}`)),
+			targets: []error{ErrUnexpectedStatusCode},
+			not:     []error{ErrUnavailable, ErrNotLeader, ErrQueueFull, ErrStatementError},
+		},
+		{
+			name:    "unavailable",
+			err:     newHTTPError(http.StatusBadGateway, nil),
+			targets: []error{ErrUnexpectedStatusCode, ErrUnavailable},
+			not:     []error{ErrNotLeader},
+		},
+		{
+			name:    "not leader",
+			err:     newHTTPError(http.StatusServiceUnavailable, []byte("not leader")),
+			targets: []error{ErrUnexpectedStatusCode, ErrUnavailable, ErrNotLeader},
+			not:     []error{ErrQueueFull},
+		},
+		{
+			name:    "redirect",
+			err:     &RedirectError{StatusCode: http.StatusMovedPermanently, Location: "http://localhost:4003"},
+			targets: []error{ErrNotLeader},
+			not:     []error{ErrUnexpectedStatusCode},
+		},
+		{
+			name:    "statement",
+			err:     fmt.Errorf("wrapped: %w", &StatementError{Index: 0, Message: "no such table: foo"}),
+			targets: []error{ErrStatementError},
+			not:     []error{ErrNotLeader, ErrUnexpectedStatusCode},
+		},
+		{
+			name:    "statement not leader",
+			err:     &StatementError{Index: -1, Message: "not leader"},
+			targets: []error{ErrStatementError, ErrNotLeader},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, target := range tt.targets {
+				if !errors.Is(tt.err, target) {
+					t.Fatalf("expected %v to match %v", tt.err, target)
+				}
 			}
-			q := r.URL.Query()
-			if _, ok := q["raft_index"]; !ok {
-				t.Fatalf("expected ?raft_index=... to be present, but not found")
+			for _, target := range tt.not {
+				if errors.Is(tt.err, target) {
+					t.Fatalf("expected %v not to match %v", tt.err, target)
+				}
 			}
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte(`{"results": [{"last_insert_id": 1, "rows_affected": 1}], "raft_index": 15}`))
-		}))
-		defer ts.Close()
+		})
+	}
+}
 
-		client, err := NewClient(ts.URL, nil)
-		if err != nil {
-			t.Fatalf("Expected nil error, got %v", err)
-		}
-		defer client.Close()
+func Test_StatementError_SQL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"results": [{"rows_affected": 1}, {"error": "no such table: bar"}]}`))
+	}))
+	defer server.Close()
 
-		statements := NewSQLStatementsFromStrings([]string{"INSERT INTO foo VALUES(?, ?)"})
-		opts := &RequestOptions{
-			RaftIndex: true,
-		}
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+	cl.PromoteErrors(true)
 
-		resp, err := client.Request(context.Background(), statements, opts)
-		if err != nil {
-			t.Fatalf("Expected nil error, got %v", err)
-		}
-		if resp.RaftIndex != 15 {
-			t.Errorf("Expected RaftIndex=15, got %d", resp.RaftIndex)
-		}
-	})
+	_, err = cl.Execute(context.Background(), SQLStatements{{SQL: "DELETE FROM foo"}, {SQL: "DELETE FROM bar"}}, nil)
+	var se *StatementError
+	if !errors.As(err, &se) {
+		t.Fatalf("expected StatementError, got %v", err)
+	}
+	if se.Index != 1 || se.SQL != "DELETE FROM bar" || se.Message != "no such table: bar" {
+		t.Fatalf("unexpected statement error: %+v", se)
+	}
 }
 
-func Test_PromoteErrors(t *testing.T) {
-	topLevelErr := false
+func Test_StreamRequestBodies(t *testing.T) {
+	const n = 10000
+	var expAbort atomic.Bool
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		if topLevelErr {
-			w.Write([]byte(`{"error": "top level error"}`))
+		var stmts SQLStatements
+		if err := json.NewDecoder(r.Body).Decode(&stmts); err != nil {
+			if !expAbort.Load() {
+				t.Errorf("Unexpected error decoding body: %v", err)
+			}
+			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
-		w.Write([]byte(`{"results": [{"error": "some error"}]}`))
+		if len(stmts) != n {
+			t.Errorf("Expected %d statements, got %d", n, len(stmts))
+		}
+		for i, stmt := range stmts {
+			if exp, got := fmt.Sprintf("INSERT INTO foo VALUES(%d)", i), stmt.SQL; exp != got {
+				t.Errorf("Expected statement %s, got %s", exp, got)
+				break
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"results": []}`))
 	}))
 	defer ts.Close()
 
@@ -732,40 +2848,64 @@ func Test_PromoteErrors(t *testing.T) {
 		t.Fatalf("Expected nil error, got %v", err)
 	}
 	defer client.Close()
+	client.StreamRequestBodies(true)
 
-	_, err = client.Execute(context.Background(), nil, nil)
-	if err != nil {
-		t.Fatalf("Expected nil error, got %v", err)
+	stmts := make(SQLStatements, n)
+	for i := range stmts {
+		stmts[i] = &SQLStatement{SQL: fmt.Sprintf("INSERT INTO foo VALUES(%d)", i)}
 	}
-	_, err = client.Query(context.Background(), nil, nil)
-	if err != nil {
+	if _, err := client.Execute(context.Background(), stmts, nil); err != nil {
 		t.Fatalf("Expected nil error, got %v", err)
 	}
-	_, err = client.Request(context.Background(), nil, nil)
-	if err != nil {
-		t.Fatalf("Expected nil error, got %v", err)
+
+	// A statement which cannot be marshaled aborts the request.
+	stmts[n-1] = &SQLStatement{SQL: "INSERT INTO foo VALUES(?)", PositionalParams: []any{make(chan int)}}
+	expAbort.Store(true)
+	if _, err := client.Execute(context.Background(), stmts, nil); err == nil {
+		t.Fatalf("Expected error for unmarshalable statement, got nil")
 	}
+}
 
-	testFn := func() {
-		_, err = client.Execute(context.Background(), nil, nil)
-		if err == nil {
-			t.Fatalf("Expected non-nil error after promoting errors, got nil")
-		}
-		_, err = client.Query(context.Background(), nil, nil)
-		if err == nil {
-			t.Fatalf("Expected non-nil error after promoting errors, got nil")
+func Test_ContextErrors(t *testing.T) {
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/db/request" {
+			// Send part of the body, so the client fails while reading it.
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"results": [`))
+			w.(http.Flusher).Flush()
 		}
-		_, err = client.Request(context.Background(), nil, nil)
-		if err == nil {
-			t.Fatalf("Expected non-nil error after promoting errors, got nil")
+		select {
+		case <-r.Context().Done():
+		case <-done:
 		}
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	client, err := NewClient(ts.URL, nil)
+	if err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
 	}
+	defer client.Close()
 
-	client.PromoteErrors(true)
-	testFn()
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if _, err := client.Query(ctx, nil, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
 
-	topLevelErr = true
-	testFn()
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.Execute(ctx, nil, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if _, err := client.Request(ctx, nil, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled while reading body, got %v", err)
+	}
 }
 
 func Test_Load_SQL(t *testing.T) {
@@ -855,6 +2995,147 @@ func Test_Load_Binary(t *testing.T) {
 	}
 }
 
+func Test_Load_BinarySplitReads(t *testing.T) {
+	expectedData, err := os.ReadFile("testdata/simple.db")
+	if err != nil {
+		t.Fatalf("failed to read test data: %s", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/octet-stream" {
+			t.Fatalf("wrong Content-Type header: %s", ct)
+		}
+		postedData, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("failed reading request body: %v", err)
+		}
+		if !bytes.Equal(postedData, expectedData) {
+			t.Fatalf("posted data does not match")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	dataReader := iotest.OneByteReader(bytes.NewReader(expectedData))
+	if err := cl.Load(context.Background(), dataReader, nil); err != nil {
+		t.Fatalf("unexpected error calling Load: %v", err)
+	}
+}
+
+func Test_ValidSQLiteData(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		data []byte
+		exp  bool
+	}{
+		{"exact header", []byte("SQLite format 3\x00"), true},
+		{"header with data", []byte("SQLite format 3\x00\x10\x00"), true},
+		{"truncated header", []byte("SQLite format"), false},
+		{"wrong version", []byte("SQLite format 2\x00"), false},
+		{"text", []byte("CREATE TABLE foo (id INTEGER)"), false},
+		{"empty", nil, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validSQLiteData(tt.data); got != tt.exp {
+				t.Fatalf("expected %v, got %v", tt.exp, got)
+			}
+		})
+	}
+}
+
+func Test_LoadWithResult(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/db/load" {
+			t.Fatalf("expected path /db/load, got %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"results":[{"last_insert_id":1,"rows_affected":1},{"last_insert_id":2,"rows_affected":2}],"time":0.5}`))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	data := []byte("INSERT INTO foo VALUES(1);INSERT INTO foo VALUES(2),(3);")
+	lr, err := cl.LoadWithResult(context.Background(), bytes.NewReader(data), nil)
+	if err != nil {
+		t.Fatalf("unexpected error calling LoadWithResult: %v", err)
+	}
+	if exp, got := 2, len(lr.Results); exp != got {
+		t.Fatalf("expected %d results, got %d", exp, got)
+	}
+	if exp, got := int64(3), lr.RowsAffected(); exp != got {
+		t.Fatalf("expected %d rows affected, got %d", exp, got)
+	}
+	if exp, got := 0.5, lr.Time; exp != got {
+		t.Fatalf("expected time %v, got %v", exp, got)
+	}
+}
+
+func Test_Load_ContentType(t *testing.T) {
+	var gotCT []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotCT = r.Header.Values("Content-Type")
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	binary := append([]byte(sqliteHeader), make([]byte, 100)...)
+	text := []byte("CREATE TABLE foo (id INTEGER)")
+	opts := &LoadOptions{
+		BinaryContentType: "application/vnd.sqlite3",
+		TextContentType:   "application/sql",
+	}
+
+	for _, tt := range []struct {
+		name string
+		data []byte
+		opts *LoadOptions
+		exp  string
+	}{
+		{"binary default", binary, nil, "application/octet-stream"},
+		{"text default", text, nil, "text/plain"},
+		{"binary override", binary, opts, "application/vnd.sqlite3"},
+		{"text override", text, opts, "application/sql"},
+		{"binary forced", text, &LoadOptions{Format: LoadFormatSQLite}, "application/octet-stream"},
+		{"text forced", binary, &LoadOptions{Format: LoadFormatSQLText}, "text/plain"},
+		{"short text", []byte("SQL"), nil, "text/plain"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := cl.Load(context.Background(), bytes.NewReader(tt.data), tt.opts); err != nil {
+				t.Fatalf("unexpected error calling Load: %v", err)
+			}
+			if len(gotCT) != 1 || gotCT[0] != tt.exp {
+				t.Fatalf("expected Content-Type exactly %q, got %q", tt.exp, gotCT)
+			}
+		})
+	}
+}
+
+func Test_Load_UnknownFormat(t *testing.T) {
+	cl, err := NewClient("http://localhost:4001", nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+	err = cl.Load(context.Background(), bytes.NewReader(nil), &LoadOptions{Format: LoadFormat(99)})
+	if err == nil || err.Error() != "unknown load format 99" {
+		t.Fatalf("expected unknown format error, got %v", err)
+	}
+}
+
 func Test_Boot(t *testing.T) {
 	expectedData := []byte("some raw SQLite bytes")
 
@@ -879,14 +3160,226 @@ func Test_Boot(t *testing.T) {
 	}))
 	defer server.Close()
 
-	cl, err := NewClient(server.URL, nil)
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	dataReader := bytes.NewReader(expectedData)
+	err = cl.Boot(context.Background(), dataReader)
+	if err != nil {
+		t.Fatalf("unexpected error calling Boot: %v", err)
+	}
+}
+
+func Test_BootAndVerify(t *testing.T) {
+	var booted atomic.Bool
+	var readyChecks atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/boot":
+			booted.Store(true)
+			w.WriteHeader(http.StatusOK)
+		case "/readyz":
+			if !booted.Load() {
+				t.Errorf("readiness checked before boot")
+			}
+			if readyChecks.Add(1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte("[+]node ok\n[-]leader not found"))
+				return
+			}
+			w.Write([]byte("[+]node ok\n[+]leader ok"))
+		case "/db/query":
+			w.Write([]byte(`{"results": [{"columns": ["COUNT(*)"], "types": ["integer"], "values": [[3]]}]}`))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	err = cl.BootAndVerify(context.Background(), strings.NewReader("some raw SQLite bytes"), func(c *Client) error {
+		n, err := ScalarInto[int64](context.Background(), c, "SELECT COUNT(*) FROM foo")
+		if err != nil {
+			return err
+		}
+		if n != 3 {
+			return fmt.Errorf("expected 3 rows, got %d", n)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error calling BootAndVerify: %v", err)
+	}
+	if exp, got := int32(2), readyChecks.Load(); exp != got {
+		t.Fatalf("expected %d readiness checks, got %d", exp, got)
+	}
+}
+
+func Test_BootAndVerify_BootFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/boot" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("not a single-node system"))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	err = cl.BootAndVerify(context.Background(), strings.NewReader("some raw SQLite bytes"), func(c *Client) error {
+		t.Fatalf("verify called after failed boot")
+		return nil
+	})
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected HTTPError with status 503, got %v", err)
+	}
+}
+
+func Test_Restore(t *testing.T) {
+	binary, err := os.ReadFile("testdata/simple.db")
+	if err != nil {
+		t.Fatalf("failed to read test data: %s", err)
+	}
+	text := []byte("CREATE TABLE foo (id INTEGER NOT NULL PRIMARY KEY, name TEXT)")
+	single := `{"nodes": [{"id": "1", "api_addr": "http://localhost:4001", "voter": true, "leader": true}]}`
+	multi := `{"nodes": [
+		{"id": "1", "api_addr": "http://localhost:4001", "voter": true, "leader": true},
+		{"id": "2", "api_addr": "http://localhost:4003", "voter": true}
+	]}`
+
+	for _, tt := range []struct {
+		name    string
+		data    []byte
+		nodes   string
+		expPath string
+	}{
+		{"binary single node", binary, single, "/boot"},
+		{"binary cluster", binary, multi, "/db/load"},
+		{"text single node", text, single, "/db/load"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var paths []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				paths = append(paths, r.URL.Path)
+				if r.URL.Path == "/nodes" {
+					w.Write([]byte(tt.nodes))
+					return
+				}
+				postedData, err := io.ReadAll(r.Body)
+				if err != nil {
+					t.Errorf("failed reading request body: %v", err)
+				}
+				if !bytes.Equal(postedData, tt.data) {
+					t.Errorf("posted data does not match")
+				}
+			}))
+			defer server.Close()
+
+			cl, err := NewClient(server.URL, nil)
+			if err != nil {
+				t.Fatalf("unexpected error from NewClient: %v", err)
+			}
+			defer cl.Close()
+
+			var sent int64
+			opts := &RestoreOptions{Progress: func(n int64) { sent = n }}
+			if err := cl.Restore(context.Background(), bytes.NewReader(tt.data), opts); err != nil {
+				t.Fatalf("unexpected error calling Restore: %v", err)
+			}
+			if got := paths[len(paths)-1]; got != tt.expPath {
+				t.Fatalf("expected data sent to %s, got %v", tt.expPath, paths)
+			}
+			if sent != int64(len(tt.data)) {
+				t.Fatalf("expected progress of %d bytes, got %d", len(tt.data), sent)
+			}
+		})
+	}
+}
+
+func Test_CopyTo(t *testing.T) {
+	binary, err := os.ReadFile("testdata/simple.db")
 	if err != nil {
-		t.Fatalf("unexpected error from NewClient: %v", err)
+		t.Fatalf("failed to read test data: %s", err)
 	}
-	dataReader := bytes.NewReader(expectedData)
-	err = cl.Boot(context.Background(), dataReader)
-	if err != nil {
-		t.Fatalf("unexpected error calling Boot: %v", err)
+	text := []byte("CREATE TABLE foo (id INTEGER NOT NULL PRIMARY KEY, name TEXT)")
+
+	for _, tt := range []struct {
+		name     string
+		opts     *CopyOptions
+		data     []byte
+		expQuery string
+		expType  string
+	}{
+		{"binary", nil, binary, "", "application/octet-stream"},
+		{"compressed", &CopyOptions{Backup: BackupOptions{Compress: true}}, binary, "compress=true", "application/octet-stream"},
+		{"sql", &CopyOptions{Backup: BackupOptions{Format: "sql"}}, text, "fmt=sql", "text/plain"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			src := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/db/backup" {
+					t.Errorf("unexpected path on source: %s", r.URL.Path)
+				}
+				if r.URL.RawQuery != tt.expQuery {
+					t.Errorf("expected backup query %q, got %q", tt.expQuery, r.URL.RawQuery)
+				}
+				if r.URL.Query().Get("compress") == "true" {
+					gw := gzip.NewWriter(w)
+					gw.Write(tt.data)
+					gw.Close()
+					return
+				}
+				w.Write(tt.data)
+			}))
+			defer src.Close()
+
+			dst := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/nodes" {
+					w.Write([]byte(`{"nodes": [{"id": "1", "voter": true, "leader": true}, {"id": "2", "voter": true}]}`))
+					return
+				}
+				if r.URL.Path != "/db/load" {
+					t.Errorf("unexpected path on destination: %s", r.URL.Path)
+				}
+				if got := r.Header.Get("Content-Type"); got != tt.expType {
+					t.Errorf("expected content type %s, got %s", tt.expType, got)
+				}
+				b, err := io.ReadAll(r.Body)
+				if err != nil {
+					t.Errorf("failed reading request body: %v", err)
+				}
+				if !bytes.Equal(b, tt.data) {
+					t.Errorf("loaded data does not match backup")
+				}
+			}))
+			defer dst.Close()
+
+			srcClient, err := NewClient(src.URL)
+			if err != nil {
+				t.Fatalf("unexpected error from NewClient: %v", err)
+			}
+			defer srcClient.Close()
+			dstClient, err := NewClient(dst.URL)
+			if err != nil {
+				t.Fatalf("unexpected error from NewClient: %v", err)
+			}
+			defer dstClient.Close()
+
+			if err := srcClient.CopyTo(context.Background(), dstClient, tt.opts); err != nil {
+				t.Fatalf("unexpected error calling CopyTo: %v", err)
+			}
+		})
 	}
 }
 
@@ -924,6 +3417,176 @@ func Test_Backup(t *testing.T) {
 	}
 }
 
+func Test_BackupWithMeta(t *testing.T) {
+	newServer := func(data string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(data))
+		}))
+	}
+	leader := newServer("leader backup")
+	defer leader.Close()
+	follower := newServer("follower backup")
+	defer follower.Close()
+
+	followerURL := mustParseURL(follower.URL)
+	followerURL.User = url.UserPassword("user", "secret")
+	lb := &sequenceBalancer{urls: []*url.URL{followerURL, mustParseURL(leader.URL)}}
+	cl, err := NewClientWithBalancer(lb, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	rc, meta, err := cl.BackupWithMeta(context.Background(), &BackupOptions{NoLeader: true})
+	if err != nil {
+		t.Fatalf("unexpected error calling BackupWithMeta: %v", err)
+	}
+	b, _ := io.ReadAll(rc)
+	rc.Close()
+	if exp, got := "follower backup", string(b); exp != got {
+		t.Fatalf("expected backup %q, got %q", exp, got)
+	}
+	if exp := (BackupMeta{Host: follower.URL}); meta != exp {
+		t.Fatalf("expected meta %+v, got %+v", exp, meta)
+	}
+
+	rc, meta, err = cl.BackupWithMeta(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error calling BackupWithMeta: %v", err)
+	}
+	rc.Close()
+	if exp := (BackupMeta{Host: leader.URL, FromLeader: true}); meta != exp {
+		t.Fatalf("expected meta %+v, got %+v", exp, meta)
+	}
+
+	// A leader-aware balancer identifies a NoLeader backup served by the Leader.
+	lcl, err := NewClientWithBalancer(&staticLeaderBalancer{
+		leader:   mustParseURL(leader.URL),
+		follower: mustParseURL(follower.URL),
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer lcl.Close()
+	rc, meta, err = lcl.BackupWithMeta(context.Background(), &BackupOptions{NoLeader: true})
+	if err != nil {
+		t.Fatalf("unexpected error calling BackupWithMeta: %v", err)
+	}
+	rc.Close()
+	if exp := (BackupMeta{Host: leader.URL, FromLeader: true}); meta != exp {
+		t.Fatalf("expected meta %+v, got %+v", exp, meta)
+	}
+}
+
+func Test_BackupResume(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+
+	for _, tt := range []struct {
+		name          string
+		etag          string
+		supportsRange bool
+		expRanges     []string
+	}{
+		{"range supported", `"backup-1"`, true, []string{"", "bytes=4000-"}},
+		{"range not supported", `"backup-1"`, false, []string{"", "bytes=4000-"}},
+		{"no etag", "", true, []string{"", ""}},
+		{"weak etag", `W/"backup-1"`, true, []string{"", ""}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var ranges []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				ranges = append(ranges, r.Header.Get("Range"))
+				first := len(ranges) == 1
+				mu.Unlock()
+				if r.Header.Get("Range") != "" && r.Header.Get("If-Range") != tt.etag {
+					t.Errorf("expected If-Range %s, got %s", tt.etag, r.Header.Get("If-Range"))
+				}
+
+				if tt.etag != "" {
+					w.Header().Set("ETag", tt.etag)
+				}
+				if first {
+					// Send part of the backup, then fail the connection.
+					w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+					w.WriteHeader(http.StatusOK)
+					w.Write(data[:4000])
+					w.(http.Flusher).Flush()
+					conn, _, err := w.(http.Hijacker).Hijack()
+					if err != nil {
+						t.Errorf("failed to hijack connection: %v", err)
+						return
+					}
+					conn.Close()
+					return
+				}
+				if !tt.supportsRange {
+					w.WriteHeader(http.StatusOK)
+					w.Write(data)
+					return
+				}
+				http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+			}))
+			defer server.Close()
+
+			cl, err := NewClient(server.URL, nil)
+			if err != nil {
+				t.Fatalf("unexpected error from NewClient: %v", err)
+			}
+			defer cl.Close()
+
+			f, err := os.Create(filepath.Join(t.TempDir(), "backup"))
+			if err != nil {
+				t.Fatalf("failed to create file: %v", err)
+			}
+			defer f.Close()
+
+			n, err := cl.BackupResume(context.Background(), f, nil)
+			if err != nil {
+				t.Fatalf("unexpected error calling BackupResume: %v", err)
+			}
+			if exp := int64(len(data)); n != exp {
+				t.Fatalf("expected %d bytes written, got %d", exp, n)
+			}
+			got, err := os.ReadFile(f.Name())
+			if err != nil {
+				t.Fatalf("failed to read file: %v", err)
+			}
+			if !bytes.Equal(data, got) {
+				t.Fatalf("mismatched backup data, got %d bytes", len(got))
+			}
+			if !reflect.DeepEqual(tt.expRanges, ranges) {
+				t.Fatalf("expected Range headers %q, got %q", tt.expRanges, ranges)
+			}
+		})
+	}
+}
+
+func Test_BackupResume_Backoff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	// Without a backoff, every attempt would fail before the deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+	defer cancel()
+	f, err := os.Create(filepath.Join(t.TempDir(), "backup"))
+	if err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	defer f.Close()
+	if _, err := cl.BackupResume(ctx, f, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded error, got %v", err)
+	}
+}
+
 func Test_Status(t *testing.T) {
 	expectedData := []byte(`{"foo":"bar"}`)
 
@@ -1012,6 +3675,53 @@ func Test_Nodes(t *testing.T) {
 	}
 }
 
+func Test_ClusterConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/nodes" {
+			t.Errorf("expected path /nodes, got %s", r.URL.Path)
+		}
+		if exp, got := "nonvoters=true&ver=2", r.URL.RawQuery; exp != got {
+			t.Errorf("expected query %s, got %s", exp, got)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"nodes": [
+			{"id": "1", "api_addr": "http://10.0.0.1:4001", "addr": "10.0.0.1:4002", "voter": true, "reachable": true, "leader": true},
+			{"id": "2", "api_addr": "http://10.0.0.2:4001", "addr": "10.0.0.2:4002", "voter": true, "reachable": true},
+			{"id": "3", "api_addr": "http://10.0.0.3:4001", "addr": "10.0.0.3:4002", "voter": false, "reachable": true},
+			{"id": "4", "api_addr": "http://10.0.0.4:4001", "addr": "10.0.0.4:4002", "voter": true, "reachable": false, "error": "connection refused"}
+		]}`))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	cc, err := cl.ClusterConfig(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error calling ClusterConfig: %v", err)
+	}
+	exp := ClusterConfig{
+		Voters: []Node{
+			{ID: "1", APIAddr: "http://10.0.0.1:4001", Addr: "10.0.0.1:4002", Voter: true, Reachable: true, Leader: true, Role: NodeRoleLeader},
+			{ID: "2", APIAddr: "http://10.0.0.2:4001", Addr: "10.0.0.2:4002", Voter: true, Reachable: true, Role: NodeRoleFollower},
+			{ID: "4", APIAddr: "http://10.0.0.4:4001", Addr: "10.0.0.4:4002", Voter: true, Error: "connection refused", Role: NodeRoleFollower},
+		},
+		NonVoters: []Node{
+			{ID: "3", APIAddr: "http://10.0.0.3:4001", Addr: "10.0.0.3:4002", Reachable: true, Role: NodeRoleNonVoter},
+		},
+	}
+	if !reflect.DeepEqual(exp, cc) {
+		t.Fatalf("unexpected cluster config\nwant: %+v\ngot:  %+v", exp, cc)
+	}
+	leader, ok := cc.Leader()
+	if !ok || leader.ID != "1" {
+		t.Fatalf("expected leader 1, got %+v (ok=%v)", leader, ok)
+	}
+}
+
 func Test_RemoveNode(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/remove" {
@@ -1035,9 +3745,59 @@ func Test_RemoveNode(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error from NewClient: %v", err)
 	}
-	if err := cl.RemoveNode(context.Background(), "id1"); err != nil {
+	res, err := cl.RemoveNode(context.Background(), "id1")
+	if err != nil {
 		t.Fatalf("unexpected error calling RemoveNode: %v", err)
 	}
+	if len(res.Raw) != 0 {
+		t.Fatalf("expected empty raw body, got %q", res.Raw)
+	}
+}
+
+func Test_RemoveNode_Body(t *testing.T) {
+	t.Run("success with body", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"id":"id1"}`))
+		}))
+		defer server.Close()
+
+		cl, err := NewClient(server.URL, nil)
+		if err != nil {
+			t.Fatalf("unexpected error from NewClient: %v", err)
+		}
+		res, err := cl.RemoveNode(context.Background(), "id1")
+		if err != nil {
+			t.Fatalf("unexpected error calling RemoveNode: %v", err)
+		}
+		if exp, got := `{"id":"id1"}`, string(res.Raw); exp != got {
+			t.Fatalf("expected raw body %s, got %s", exp, got)
+		}
+		if res.Error != "" {
+			t.Fatalf("expected no error, got %s", res.Error)
+		}
+	})
+
+	t.Run("failure with error body", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error":"node id1 not found"}`))
+		}))
+		defer server.Close()
+
+		cl, err := NewClient(server.URL, nil)
+		if err != nil {
+			t.Fatalf("unexpected error from NewClient: %v", err)
+		}
+		_, err = cl.RemoveNode(context.Background(), "id1")
+		var httpErr *HTTPError
+		if !errors.As(err, &httpErr) {
+			t.Fatalf("expected HTTPError, got %v", err)
+		}
+		if exp, got := "node id1 not found", httpErr.RQLiteError; exp != got {
+			t.Fatalf("expected error %s, got %s", exp, got)
+		}
+	})
 }
 
 func Test_Version(t *testing.T) {
@@ -1061,6 +3821,50 @@ func Test_Version(t *testing.T) {
 	}
 }
 
+func Test_BuildInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/status" {
+			t.Errorf("expected path /status, got %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"build": {
+				"branch": "master",
+				"build_time": "2024-12-01T10:00:00-0500",
+				"commit": "64d7b4c2e1e3b5c2f7b0f4c0a3a5d2b1e0f9c8d7",
+				"compiler": "gc",
+				"version": "v8.36.1"
+			},
+			"runtime": {
+				"GOARCH": "amd64",
+				"GOOS": "linux",
+				"version": "go1.23.3"
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	bi, err := cl.BuildInfo(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error calling BuildInfo: %v", err)
+	}
+	exp := BuildInfo{
+		Version:   "v8.36.1",
+		Commit:    "64d7b4c2e1e3b5c2f7b0f4c0a3a5d2b1e0f9c8d7",
+		Branch:    "master",
+		BuildTime: "2024-12-01T10:00:00-0500",
+		Compiler:  "gc",
+		GoVersion: "go1.23.3",
+	}
+	if exp != bi {
+		t.Fatalf("mismatched BuildInfo.\nwant: %+v\ngot:  %+v", exp, bi)
+	}
+}
+
 func Test_Ready(t *testing.T) {
 	expectedData := []byte(`[+]node ok`)
 	expectedRawQuery := "sync=true"
@@ -1091,6 +3895,191 @@ func Test_Ready(t *testing.T) {
 	}
 }
 
+// staticLeaderBalancer is a LeaderAwareBalancer with a fixed Leader and Follower.
+type staticLeaderBalancer struct {
+	leader   *url.URL
+	follower *url.URL
+}
+
+func (b *staticLeaderBalancer) Next() (*url.URL, error)     { return b.leader, nil }
+func (b *staticLeaderBalancer) Leader() (*url.URL, error)   { return b.leader, nil }
+func (b *staticLeaderBalancer) Follower() (*url.URL, error) { return b.follower, nil }
+
+// sequenceBalancer is a LoadBalancer which returns its URLs in order, repeating the last.
+type sequenceBalancer struct {
+	mu   sync.Mutex
+	urls []*url.URL
+}
+
+func (b *sequenceBalancer) Next() (*url.URL, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	u := b.urls[0]
+	if len(b.urls) > 1 {
+		b.urls = b.urls[1:]
+	}
+	return u, nil
+}
+
+func Test_QueryResult_IndexBy(t *testing.T) {
+	resp := mustUnmarshalQueryResponse(`{"results": [{
+		"columns": ["id", "name", "team"],
+		"types": ["integer", "text", "text"],
+		"values": [[1, "fiona", "red"], [2, "declan", "blue"], [3, "sinead", "red"], [4, "aoife", null]]
+	}]}`)
+	qr := resp.GetQueryResults()[0]
+
+	m, err := qr.IndexBy("team")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exp := map[any][]map[string]any{
+		"red": {
+			{"id": json.Number("1"), "name": "fiona", "team": "red"},
+			{"id": json.Number("3"), "name": "sinead", "team": "red"},
+		},
+		"blue": {
+			{"id": json.Number("2"), "name": "declan", "team": "blue"},
+		},
+		nil: {
+			{"id": json.Number("4"), "name": "aoife", "team": nil},
+		},
+	}
+	if !reflect.DeepEqual(exp, m) {
+		t.Fatalf("unexpected index\nwant: %v\ngot:  %v", exp, m)
+	}
+
+	m, err = qr.IndexBy("id")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rows := m[json.Number("2")]; len(rows) != 1 || rows[0]["name"] != "declan" {
+		t.Fatalf("unexpected rows for id 2: %v", rows)
+	}
+
+	if _, err := qr.IndexBy("age"); err == nil {
+		t.Fatalf("expected error for missing column")
+	}
+}
+
+func Test_QueryResult_DuplicateColumns(t *testing.T) {
+	resp := mustUnmarshalQueryResponse(`{"results": [{
+		"columns": ["id", "name", "id", "id_1", "id"],
+		"types": ["integer", "text", "integer", "text", "integer"],
+		"values": [[1, "fiona", 10, "x", 100], [2, "declan", 20, "y", 200]]
+	}]}`)
+	qr := resp.GetQueryResults()[0]
+
+	cols, err := qr.UniqueColumns(DuplicateColumnsSuffix)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exp := []string{"id", "name", "id_2", "id_1", "id_3"}; !reflect.DeepEqual(exp, cols) {
+		t.Fatalf("expected columns %v, got %v", exp, cols)
+	}
+
+	rows, err := qr.AsMaps(DuplicateColumnsSuffix)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expRows := []map[string]any{
+		{"id": json.Number("1"), "name": "fiona", "id_2": json.Number("10"), "id_1": "x", "id_3": json.Number("100")},
+		{"id": json.Number("2"), "name": "declan", "id_2": json.Number("20"), "id_1": "y", "id_3": json.Number("200")},
+	}
+	if !reflect.DeepEqual(expRows, rows) {
+		t.Fatalf("unexpected rows\nwant: %v\ngot:  %v", expRows, rows)
+	}
+
+	assoc, err := qr.AsAssoc(DuplicateColumnsSuffix)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expAssoc := QueryResultAssoc{
+		Types: map[string]string{"id": "integer", "name": "text", "id_2": "integer", "id_1": "text", "id_3": "integer"},
+		Rows:  expRows,
+	}
+	if !reflect.DeepEqual(expAssoc, assoc) {
+		t.Fatalf("unexpected associative result\nwant: %v\ngot:  %v", expAssoc, assoc)
+	}
+
+	if _, err := qr.AsMaps(DuplicateColumnsError); err == nil || err.Error() != "duplicate column name: id" {
+		t.Fatalf("expected duplicate column error from AsMaps, got %v", err)
+	}
+	if _, err := qr.AsAssoc(DuplicateColumnsError); err == nil {
+		t.Fatalf("expected duplicate column error from AsAssoc")
+	}
+
+	unique := QueryResult{Columns: []string{"id", "name"}, Values: [][]any{{1, "fiona"}}}
+	rows, err = unique.AsMaps(DuplicateColumnsError)
+	if err != nil {
+		t.Fatalf("unexpected error for unique columns: %v", err)
+	}
+	if exp := []map[string]any{{"id": 1, "name": "fiona"}}; !reflect.DeepEqual(exp, rows) {
+		t.Fatalf("unexpected rows\nwant: %v\ngot:  %v", exp, rows)
+	}
+}
+
+func Test_QueryResult_Columnar(t *testing.T) {
+	resp := mustUnmarshalQueryResponse(`{"results": [{
+		"columns": ["id", "name", "age"],
+		"types": ["integer", "text", "integer"],
+		"values": [[1, "fiona", 20], [2, "declan", null], [3, "sinead", 30]]
+	}]}`)
+	cols, data := resp.GetQueryResults()[0].Columnar()
+	if exp := []string{"id", "name", "age"}; !reflect.DeepEqual(exp, cols) {
+		t.Fatalf("expected columns %v, got %v", exp, cols)
+	}
+	exp := [][]any{
+		{json.Number("1"), json.Number("2"), json.Number("3")},
+		{"fiona", "declan", "sinead"},
+		{json.Number("20"), nil, json.Number("30")},
+	}
+	if !reflect.DeepEqual(exp, data) {
+		t.Fatalf("unexpected columnar data\nwant: %v\ngot:  %v", exp, data)
+	}
+
+	cols, data = QueryResult{Columns: []string{"a", "b"}, Values: [][]any{{1}}}.Columnar()
+	if exp := [][]any{{1}, {nil}}; len(cols) != 2 || !reflect.DeepEqual(exp, data) {
+		t.Fatalf("expected short row padded with nil, got %v", data)
+	}
+
+	cols, data = QueryResult{Columns: []string{"a"}}.Columnar()
+	if exp := [][]any{{}}; len(cols) != 1 || !reflect.DeepEqual(exp, data) {
+		t.Fatalf("expected empty column for empty result, got %v", data)
+	}
+}
+
+func Test_QueryResult_DecodeBlobs(t *testing.T) {
+	for _, body := range []string{
+		`{"results": [{"columns": ["id", "data"], "types": ["integer", "BLOB"], "values": [[1, "AAH/"], [2, null]]}]}`,
+		`{"results": [{"columns": ["id", "data"], "types": ["integer", "BLOB"], "values": [[1, [0, 1, 255]], [2, null]]}]}`,
+	} {
+		resp := mustUnmarshalQueryResponse(body)
+		qr := resp.GetQueryResults()[0]
+		if err := qr.DecodeBlobs(); err != nil {
+			t.Fatalf("unexpected error decoding blobs: %s", err)
+		}
+		exp := [][]any{{json.Number("1"), []byte{0, 1, 255}}, {json.Number("2"), nil}}
+		if !reflect.DeepEqual(exp, qr.Values) {
+			t.Fatalf("expected values %v, got %v", exp, qr.Values)
+		}
+	}
+
+	resp := mustUnmarshalQueryResponse(`{"results": [{"types": {"id": "integer", "data": "blob"}, "rows": [{"id": 1, "data": "AAH/"}]}]}`)
+	qra := resp.GetQueryResultsAssoc()[0]
+	if err := qra.DecodeBlobs(); err != nil {
+		t.Fatalf("unexpected error decoding blobs: %s", err)
+	}
+	if exp := []byte{0, 1, 255}; !reflect.DeepEqual(exp, qra.Rows[0]["data"]) {
+		t.Fatalf("expected %v, got %v", exp, qra.Rows[0]["data"])
+	}
+
+	qr := QueryResult{Columns: []string{"data"}, Types: []string{"blob"}, Values: [][]any{{"not base64!"}}}
+	if err := qr.DecodeBlobs(); err == nil {
+		t.Fatalf("expected error decoding invalid base64")
+	}
+}
+
 func mustUnmarshalQueryResponse(s string) QueryResponse {
 	var qr QueryResponse
 	if err := json.Unmarshal([]byte(s), &qr); err != nil {
@@ -1114,3 +4103,38 @@ func mustParseDuration(s string) time.Duration {
 	}
 	return d
 }
+
+func Test_NodeList(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/nodes" {
+			t.Errorf("expected path /nodes, got %s", r.URL.Path)
+		}
+		if exp, got := "ver=2", r.URL.RawQuery; exp != got {
+			t.Errorf("expected query %s, got %s", exp, got)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"nodes": [
+			{"id": "1", "api_addr": "http://10.0.0.1:4001", "addr": "10.0.0.1:4002", "voter": true, "reachable": true, "leader": true, "time": 0.5},
+			{"id": "2", "api_addr": "http://10.0.0.2:4001", "addr": "10.0.0.2:4002", "voter": true, "reachable": false, "error": "connection refused"}
+		]}`))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	nodes, err := cl.NodeList(context.Background(), &NodeOptions{Version: "2"})
+	if err != nil {
+		t.Fatalf("unexpected error calling NodeList: %v", err)
+	}
+	exp := []Node{
+		{ID: "1", APIAddr: "http://10.0.0.1:4001", Addr: "10.0.0.1:4002", Voter: true, Reachable: true, Leader: true, Time: 0.5, Role: NodeRoleLeader},
+		{ID: "2", APIAddr: "http://10.0.0.2:4001", Addr: "10.0.0.2:4002", Voter: true, Error: "connection refused", Role: NodeRoleFollower},
+	}
+	if !reflect.DeepEqual(exp, nodes) {
+		t.Fatalf("unexpected nodes\nwant: %+v\ngot:  %+v", exp, nodes)
+	}
+}
@@ -0,0 +1,210 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// leaderDiscoveryInterval is the minimum time between attempts to discover the
+// Leader from /nodes.
+const leaderDiscoveryInterval = time.Second
+
+// leaderDiscoveryTimeout bounds each attempt to discover the Leader from /nodes.
+const leaderDiscoveryTimeout = 5 * time.Second
+
+// NewLeaderRoutingClient returns a Client for the cluster whose nodes are at the given
+// addresses, which sends writes to the Leader and spreads reads across the other nodes.
+// If httpClient is nil, the default client is used.
+//
+// The client's balancer is a LeaderBalancer, which learns the Leader from the
+// cluster's /nodes endpoint, as requested through the returned client, and from any
+// redirect the client receives. Reads are routed as with
+// RoutingPolicyPreferFollowerReads. The returned client's routing policy may be
+// changed to RoutingPolicyFollowerNoneReads, but should not be set to RoutingPolicyAny.
+func NewLeaderRoutingClient(addresses []string, httpClient *http.Client) (*Client, error) {
+	lb, err := newLeaderBalancer(addresses)
+	if err != nil {
+		return nil, err
+	}
+	c, err := NewClientWithBalancer(lb, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	c.SetRoutingPolicy(RoutingPolicyPreferFollowerReads)
+	lb.discover = func(ctx context.Context) ([]Node, error) {
+		return c.NodeList(ctx, &NodeOptions{NonVoters: true, Version: "2"})
+	}
+	return c, nil
+}
+
+// LeaderBalancer is a LeaderAwareBalancer which pins requests to the Leader of the
+// cluster. It discovers the Leader from the /nodes endpoint of its hosts, and learns
+// of changes of Leadership from the redirects the client receives. If a request to the
+// Leader fails, the Leader is forgotten and discovered again.
+//
+// Next returns the Leader, so that a client using a LeaderBalancer sends all requests
+// to the Leader by default. If the Leader is not known, and cannot be discovered,
+// Next returns a randomly chosen host.
+type LeaderBalancer struct {
+	hosts    []*url.URL
+	discover func(ctx context.Context) ([]Node, error)
+
+	mu            sync.Mutex
+	leader        *url.URL
+	lastDiscovery time.Time
+}
+
+// NewLeaderBalancer returns a LeaderBalancer for the cluster whose nodes are at the
+// given addresses. httpClient is used to request /nodes, and if it is nil, the default
+// client is used. Basic Auth credentials included in an address are used when
+// requesting /nodes from that node.
+func NewLeaderBalancer(addresses []string, httpClient *http.Client) (*LeaderBalancer, error) {
+	lb, err := newLeaderBalancer(addresses)
+	if err != nil {
+		return nil, err
+	}
+	if httpClient == nil {
+		httpClient = DefaultHTTPClient()
+	}
+	lb.discover = func(ctx context.Context) ([]Node, error) {
+		var errs []error
+		for _, h := range lb.hosts {
+			cl, err := NewClient(h.String(), WithHTTPClient(httpClient))
+			if err != nil {
+				return nil, err
+			}
+			if h.User != nil {
+				pw, _ := h.User.Password()
+				cl.SetBasicAuth(h.User.Username(), pw)
+			}
+			nodes, err := cl.NodeList(ctx, &NodeOptions{NonVoters: true, Version: "2"})
+			cl.Close()
+			if err == nil {
+				return nodes, nil
+			}
+			errs = append(errs, err)
+		}
+		return nil, errors.Join(errs...)
+	}
+	return lb, nil
+}
+
+func newLeaderBalancer(addresses []string) (*LeaderBalancer, error) {
+	hosts, err := parseHosts(addresses)
+	if err != nil {
+		return nil, err
+	}
+	lb := &LeaderBalancer{}
+	for _, h := range hosts {
+		lb.hosts = append(lb.hosts, h.URL)
+	}
+	return lb, nil
+}
+
+// Next returns the Leader, or a random host if the Leader is not known.
+func (b *LeaderBalancer) Next() (*url.URL, error) {
+	if u, err := b.Leader(); err == nil {
+		return u, nil
+	}
+	return b.hosts[rand.IntN(len(b.hosts))], nil
+}
+
+// Leader returns the last known Leader, attempting to discover it if it is not
+// known. Discovery is attempted at most once every second.
+func (b *LeaderBalancer) Leader() (*url.URL, error) {
+	b.mu.Lock()
+	if b.leader != nil {
+		defer b.mu.Unlock()
+		return b.leader, nil
+	}
+	if b.discover == nil || time.Since(b.lastDiscovery) < leaderDiscoveryInterval {
+		b.mu.Unlock()
+		return nil, ErrNoLeader
+	}
+	b.lastDiscovery = time.Now()
+	b.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), leaderDiscoveryTimeout)
+	defer cancel()
+	nodes, err := b.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	u, err := b.leaderURL(nodes)
+	if err != nil {
+		return nil, err
+	}
+	b.setLeader(u)
+	return u, nil
+}
+
+// leaderURL returns the URL of the Leader among nodes.
+func (b *LeaderBalancer) leaderURL(nodes []Node) (*url.URL, error) {
+	for _, n := range nodes {
+		if !n.Leader || n.APIAddr == "" {
+			continue
+		}
+		addr := n.APIAddr
+		if !strings.Contains(addr, "://") {
+			addr = b.hosts[0].Scheme + "://" + addr
+		}
+		return url.Parse(addr)
+	}
+	return nil, ErrNoLeader
+}
+
+// Follower returns a random host which is not the last known Leader.
+func (b *LeaderBalancer) Follower() (*url.URL, error) {
+	b.mu.Lock()
+	leader := b.leader
+	b.mu.Unlock()
+
+	var followers []*url.URL
+	for _, u := range b.hosts {
+		if leader == nil || hostURL(u) != hostURL(leader) {
+			followers = append(followers, u)
+		}
+	}
+	if len(followers) == 0 {
+		return nil, ErrNoHostsAvailable
+	}
+	return followers[rand.IntN(len(followers))], nil
+}
+
+// RecordSuccess implements OutcomeRecorder.
+func (b *LeaderBalancer) RecordSuccess(u *url.URL) {}
+
+// RecordFailure implements OutcomeRecorder, forgetting the Leader if the request
+// to it failed.
+func (b *LeaderBalancer) RecordFailure(u *url.URL) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.leader != nil && hostURL(u) == hostURL(b.leader) {
+		b.leader = nil
+	}
+}
+
+// RecordRedirect implements RedirectRecorder, recording to as the Leader.
+func (b *LeaderBalancer) RecordRedirect(from, to *url.URL) {
+	b.setLeader(to)
+}
+
+// setLeader records u as the Leader. If u identifies one of the balancer's hosts,
+// that host's URL is used.
+func (b *LeaderBalancer) setLeader(u *url.URL) {
+	for _, h := range b.hosts {
+		if hostURL(h) == hostURL(u) {
+			u = h
+			break
+		}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.leader = u
+}
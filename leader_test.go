@@ -0,0 +1,181 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func Test_NewLeaderRoutingClient(t *testing.T) {
+	var moved atomic.Bool
+	var aWrites, bWrites, aReads, bReads atomic.Int32
+	var a, b *httptest.Server
+
+	nodes := func(w http.ResponseWriter) {
+		fmt.Fprintf(w, `{"nodes": [
+			{"id": "a", "api_addr": %q, "voter": true, "reachable": true, "leader": true},
+			{"id": "b", "api_addr": %q, "voter": true, "reachable": true}
+		]}`, a.URL, b.URL)
+	}
+	a = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/nodes":
+			nodes(w)
+		case "/db/execute":
+			aWrites.Add(1)
+			if moved.Load() {
+				http.Redirect(w, r, b.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+				return
+			}
+			w.Write([]byte(`{"results": [{"last_insert_id": 1, "rows_affected": 1}]}`))
+		case "/db/query":
+			aReads.Add(1)
+			w.Write([]byte(`{"results": [{"columns": ["1"], "types": ["integer"], "values": [[1]]}]}`))
+		default:
+			t.Errorf("unexpected path on a: %s", r.URL.Path)
+		}
+	}))
+	defer a.Close()
+	b = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/nodes":
+			nodes(w)
+		case "/db/execute":
+			bWrites.Add(1)
+			w.Write([]byte(`{"results": [{"last_insert_id": 1, "rows_affected": 1}]}`))
+		case "/db/query":
+			bReads.Add(1)
+			w.Write([]byte(`{"results": [{"columns": ["1"], "types": ["integer"], "values": [[1]]}]}`))
+		default:
+			t.Errorf("unexpected path on b: %s", r.URL.Path)
+		}
+	}))
+	defer b.Close()
+
+	client, err := NewLeaderRoutingClient([]string{a.URL, b.URL}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewLeaderRoutingClient: %v", err)
+	}
+	defer client.Close()
+
+	write := func() {
+		t.Helper()
+		if _, err := client.ExecuteSingle(context.Background(), "INSERT INTO foo VALUES(1)"); err != nil {
+			t.Fatalf("unexpected error from ExecuteSingle: %v", err)
+		}
+	}
+	read := func() {
+		t.Helper()
+		if _, err := client.QuerySingle(context.Background(), "SELECT 1"); err != nil {
+			t.Fatalf("unexpected error from QuerySingle: %v", err)
+		}
+	}
+
+	// The Leader, a, is discovered from /nodes, and reads go to the Follower.
+	write()
+	read()
+	if aWrites.Load() != 1 || bWrites.Load() != 0 {
+		t.Fatalf("expected write to discovered leader, got a=%d b=%d", aWrites.Load(), bWrites.Load())
+	}
+	if aReads.Load() != 0 || bReads.Load() != 1 {
+		t.Fatalf("expected read from follower, got a=%d b=%d", aReads.Load(), bReads.Load())
+	}
+
+	// Leadership moves to b, which the client learns from a's redirect.
+	moved.Store(true)
+	write()
+	if aWrites.Load() != 2 || bWrites.Load() != 1 {
+		t.Fatalf("expected write to be redirected, got a=%d b=%d", aWrites.Load(), bWrites.Load())
+	}
+	write()
+	write()
+	if aWrites.Load() != 2 || bWrites.Load() != 3 {
+		t.Fatalf("expected writes to go to new leader, got a=%d b=%d", aWrites.Load(), bWrites.Load())
+	}
+	read()
+	if aReads.Load() != 1 {
+		t.Fatalf("expected read from new follower, got a=%d b=%d", aReads.Load(), bReads.Load())
+	}
+}
+
+func Test_LeaderBalancer(t *testing.T) {
+	var moved atomic.Bool
+	var aRequests, bRequests atomic.Int32
+	var a, b *httptest.Server
+
+	handler := func(name string, requests *atomic.Int32) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/nodes" {
+				fmt.Fprintf(w, `{"nodes": [
+					{"id": "a", "api_addr": %q, "voter": true, "reachable": true},
+					{"id": "b", "api_addr": %q, "voter": true, "reachable": true, "leader": true}
+				]}`, a.URL, b.URL)
+				return
+			}
+			requests.Add(1)
+			if name == "b" && moved.Load() {
+				http.Redirect(w, r, a.URL+r.URL.RequestURI(), http.StatusMovedPermanently)
+				return
+			}
+			w.Write([]byte(`{"results": [{"columns": ["1"], "types": ["integer"], "values": [[1]]}]}`))
+		}
+	}
+	a = httptest.NewServer(handler("a", &aRequests))
+	defer a.Close()
+	b = httptest.NewServer(handler("b", &bRequests))
+	defer b.Close()
+
+	lb, err := NewLeaderBalancer([]string{a.URL, b.URL}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewLeaderBalancer: %v", err)
+	}
+	u, err := lb.Leader()
+	if err != nil {
+		t.Fatalf("unexpected error from Leader: %v", err)
+	}
+	if u.String() != b.URL {
+		t.Fatalf("expected leader %s, got %s", b.URL, u)
+	}
+	if u, err := lb.Follower(); err != nil || u.String() != a.URL {
+		t.Fatalf("expected follower %s, got %v (%v)", a.URL, u, err)
+	}
+
+	client, err := NewClientWithBalancer(lb, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClientWithBalancer: %v", err)
+	}
+	defer client.Close()
+	read := func() {
+		t.Helper()
+		if _, err := client.QuerySingle(context.Background(), "SELECT 1"); err != nil {
+			t.Fatalf("unexpected error from QuerySingle: %v", err)
+		}
+	}
+
+	// All requests are pinned to the Leader.
+	read()
+	read()
+	if aRequests.Load() != 0 || bRequests.Load() != 2 {
+		t.Fatalf("expected requests to go to leader, got a=%d b=%d", aRequests.Load(), bRequests.Load())
+	}
+
+	// Leadership moves to a, which the balancer learns from b's redirect.
+	moved.Store(true)
+	read()
+	read()
+	if aRequests.Load() != 2 || bRequests.Load() != 3 {
+		t.Fatalf("expected requests to go to new leader, got a=%d b=%d", aRequests.Load(), bRequests.Load())
+	}
+
+	// A failed request to the Leader forgets it.
+	lb.RecordFailure(mustParseURL(a.URL))
+	lb.mu.Lock()
+	leader := lb.leader
+	lb.mu.Unlock()
+	if leader != nil {
+		t.Fatalf("expected leader to be forgotten, got %s", leader)
+	}
+}
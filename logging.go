@@ -0,0 +1,62 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// SetLogger configures the client to log each request it sends, and each statement
+// error returned by the node, to l at debug level. Requests are logged with their
+// method, path, node, duration, and status code, or the error which prevented a
+// response. Pass nil to stop logging.
+func (c *Client) SetLogger(l *slog.Logger) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logger = l
+}
+
+func (c *Client) getLogger() *slog.Logger {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.logger
+}
+
+// logRequest logs a request sent to host, if the client has a logger.
+func (c *Client) logRequest(ctx context.Context, path string, host *url.URL, req *http.Request, resp *http.Response, err error, d time.Duration) {
+	l := c.getLogger()
+	if l == nil || !l.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("path", path),
+		slog.String("node", hostURL(host)),
+		slog.Duration("duration", d),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	} else {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+	}
+	l.LogAttrs(ctx, slog.LevelDebug, "rqlite request", attrs...)
+}
+
+// logStatementErrors logs each statement error in resp, a response to a request to
+// path, if the client has a logger.
+func (c *Client) logStatementErrors(ctx context.Context, path string, resp interface{ statementErrors() []error }) {
+	l := c.getLogger()
+	if l == nil || !l.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	for _, err := range resp.statementErrors() {
+		se := err.(*StatementError)
+		l.LogAttrs(ctx, slog.LevelDebug, "rqlite statement error",
+			slog.String("path", path),
+			slog.Int("index", se.Index),
+			slog.String("error", se.Message),
+		)
+	}
+}
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_SetLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"results": [{"rows_affected": 1}, {"error": "no such table: bar"}]}`))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	var buf bytes.Buffer
+	cl.SetLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	stmts := SQLStatements{{SQL: "DELETE FROM foo"}, {SQL: "DELETE FROM bar"}}
+	if _, err := cl.Execute(context.Background(), stmts, nil); err != nil {
+		t.Fatalf("unexpected error from Execute: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got %d: %s", len(lines), buf.String())
+	}
+	for _, exp := range []string{`msg="rqlite request"`, "method=POST", "path=/db/execute", "node=" + server.URL, "duration=", "status=200"} {
+		if !strings.Contains(lines[0], exp) {
+			t.Fatalf("expected request log to contain %q, got %s", exp, lines[0])
+		}
+	}
+	for _, exp := range []string{`msg="rqlite statement error"`, "path=/db/execute", "index=1", `error="no such table: bar"`} {
+		if !strings.Contains(lines[1], exp) {
+			t.Fatalf("expected statement error log to contain %q, got %s", exp, lines[1])
+		}
+	}
+
+	// Nothing is logged above debug level, or once the logger is removed.
+	buf.Reset()
+	cl.SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	cl.Execute(context.Background(), stmts, nil)
+	cl.SetLogger(nil)
+	cl.Execute(context.Background(), stmts, nil)
+	if buf.Len() != 0 {
+		t.Fatalf("expected nothing to be logged, got %s", buf.String())
+	}
+}
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Metrics records measurements of the requests made by a Client, for example to
+// export them to a monitoring system. Implementations must be safe for concurrent use,
+// and should return quickly, as they are called on the path of each request.
+type Metrics interface {
+	// ObserveRequest records a request the client sent, once its response headers
+	// have been received or it has failed.
+	ObserveRequest(m RequestMetrics)
+
+	// ObserveRetry records that a request to path is being retried. attempt is the
+	// number of the retry, starting at 1.
+	ObserveRetry(path string, attempt int)
+
+	// ObserveHealthChange records that a balancer's host became healthy or
+	// unhealthy. It is called by balancers given it with SetHealthObserver.
+	ObserveHealthChange(u *url.URL, healthy bool)
+}
+
+// RequestMetrics describes a request sent by a Client.
+type RequestMetrics struct {
+	// Method is the HTTP method of the request.
+	Method string
+
+	// Path is the API path requested, for example "/db/query".
+	Path string
+
+	// Host is the base URL of the node the request was sent to, with any
+	// credentials removed.
+	Host string
+
+	// StatusCode is the status code of the response, or 0 if no response was
+	// received.
+	StatusCode int
+
+	// Err is the error which prevented a response being received, if any.
+	Err error
+
+	// Duration is the time from sending the request to receiving the response
+	// headers, or to the request failing.
+	Duration time.Duration
+
+	// RequestSize is the size of the request body, in bytes, or -1 if it is not
+	// known, as when request bodies are streamed.
+	RequestSize int64
+
+	// ResponseSize is the size of the response body, in bytes, as given by its
+	// Content-Length, or -1 if it is not known.
+	ResponseSize int64
+}
+
+// HealthObserver is called by a balancer when one of its hosts becomes healthy or
+// unhealthy.
+type HealthObserver func(u *url.URL, healthy bool)
+
+// SetMetrics configures the client to record measurements of its requests with m.
+// Pass nil to stop recording.
+func (c *Client) SetMetrics(m Metrics) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.metrics = m
+}
+
+func (c *Client) getMetrics() Metrics {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.metrics
+}
+
+// observeRequest records the outcome of req, sent to host, with m.
+func observeRequest(m Metrics, path string, host *url.URL, req *http.Request, resp *http.Response, err error, d time.Duration) {
+	rm := RequestMetrics{
+		Method:       req.Method,
+		Path:         path,
+		Host:         hostURL(host),
+		Err:          err,
+		Duration:     d,
+		RequestSize:  req.ContentLength,
+		ResponseSize: -1,
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		rm.RequestSize = 0
+	} else if rm.RequestSize == 0 {
+		rm.RequestSize = -1
+	}
+	if resp != nil {
+		rm.StatusCode = resp.StatusCode
+		rm.ResponseSize = resp.ContentLength
+	}
+	m.ObserveRequest(rm)
+}
@@ -0,0 +1,139 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingMetrics is a Metrics which records everything it observes.
+type recordingMetrics struct {
+	mu       sync.Mutex
+	requests []RequestMetrics
+	retries  []string
+	health   []string
+}
+
+func (m *recordingMetrics) ObserveRequest(rm RequestMetrics) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests = append(m.requests, rm)
+}
+
+func (m *recordingMetrics) ObserveRetry(path string, attempt int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries = append(m.retries, path)
+}
+
+func (m *recordingMetrics) ObserveHealthChange(u *url.URL, healthy bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state := "unhealthy"
+	if healthy {
+		state = "healthy"
+	}
+	m.health = append(m.health, u.String()+" "+state)
+}
+
+func Test_SetMetrics(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"results": []}`))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+	m := &recordingMetrics{}
+	cl.SetMetrics(m)
+	cl.SetMaxRetries(1)
+
+	if _, err := cl.QuerySingle(context.Background(), "SELECT 1"); err != nil {
+		t.Fatalf("unexpected error from QuerySingle: %v", err)
+	}
+	if len(m.requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(m.requests))
+	}
+	for i, exp := range []int{http.StatusServiceUnavailable, http.StatusOK} {
+		rm := m.requests[i]
+		if rm.Method != "POST" || rm.Path != "/db/query" || rm.Host != server.URL || rm.StatusCode != exp || rm.Err != nil {
+			t.Fatalf("unexpected request metrics %d: %+v", i, rm)
+		}
+		if rm.RequestSize <= 0 {
+			t.Fatalf("expected request size, got %d", rm.RequestSize)
+		}
+	}
+	if exp, got := int64(len(`{"results": []}`)), m.requests[1].ResponseSize; exp != got {
+		t.Fatalf("expected response size %d, got %d", exp, got)
+	}
+	if len(m.retries) != 1 || m.retries[0] != "/db/query" {
+		t.Fatalf("expected one retry of /db/query, got %v", m.retries)
+	}
+
+	// A request which fails is recorded with its error.
+	server.Close()
+	cl.SetMaxRetries(0)
+	if _, err := cl.Status(context.Background()); err == nil {
+		t.Fatalf("expected error from Status")
+	}
+	if rm := m.requests[len(m.requests)-1]; rm.Path != "/status" || rm.StatusCode != 0 || rm.Err == nil || rm.RequestSize != 0 {
+		t.Fatalf("unexpected metrics for failed request: %+v", rm)
+	}
+
+	cl.SetMetrics(nil)
+	n := len(m.requests)
+	cl.Status(context.Background())
+	if len(m.requests) != n {
+		t.Fatalf("expected no requests to be recorded once metrics are removed")
+	}
+}
+
+func Test_SetHealthObserver(t *testing.T) {
+	var healthy sync.Map
+	check := func(u *url.URL) bool {
+		_, ok := healthy.Load(u.String())
+		return ok
+	}
+	a := "http://localhost:4001"
+	m := &recordingMetrics{}
+	rb, err := NewRoundRobinBalancer([]string{a}, check, time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error from NewRoundRobinBalancer: %v", err)
+	}
+	defer rb.Close()
+	rb.SetHealthObserver(m.ObserveHealthChange)
+
+	u := mustParseURL(a)
+	rb.MarkBad(u)
+	rb.MarkBad(u)
+	healthy.Store(a, true)
+	observed := func() int {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return len(m.health)
+	}
+	deadline := time.Now().Add(time.Second)
+	for observed() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	exp := []string{a + " unhealthy", a + " healthy"}
+	if len(m.health) != 2 || m.health[0] != exp[0] || m.health[1] != exp[1] {
+		t.Fatalf("expected health changes %v, got %v", exp, m.health)
+	}
+}
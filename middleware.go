@@ -0,0 +1,40 @@
+package http
+
+import "net/http"
+
+// Middleware wraps the http.RoundTripper which sends a Client's requests, returning a
+// RoundTripper which may inspect or modify each request and its response, for
+// example to log requests, add headers, or limit the rate of requests. It must call
+// next to send the request, unless it responds itself.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is an adapter allowing an ordinary function to be used as an
+// http.RoundTripper.
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req).
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Use adds middleware to the chain through which the client sends requests. The
+// first middleware added is outermost, seeing each request first and each response
+// last. Middleware wraps the Transport of the client's http.Client, or
+// http.DefaultTransport if it has none, so it sees each request sent when following a
+// redirect, and each request retried by the client.
+func (c *Client) Use(mw ...Middleware) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.middleware = append(c.middleware, mw...)
+
+	rt := c.httpClient.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		rt = c.middleware[i](rt)
+	}
+	hc := *c.httpClient
+	hc.Transport = rt
+	c.mwClient = &hc
+}
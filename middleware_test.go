@@ -0,0 +1,66 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func Test_Use(t *testing.T) {
+	var gotHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("X-Request-Source")
+		w.Write([]byte(`{"results": []}`))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	var calls []string
+	named := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, name+" "+req.URL.Path)
+				resp, err := next.RoundTrip(req)
+				calls = append(calls, name+" done")
+				return resp, err
+			})
+		}
+	}
+	cl.Use(named("outer"), named("inner"))
+	cl.Use(func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req.Header.Set("X-Request-Source", "middleware")
+			return next.RoundTrip(req)
+		})
+	})
+
+	if _, err := cl.QuerySingle(context.Background(), "SELECT 1"); err != nil {
+		t.Fatalf("unexpected error from QuerySingle: %v", err)
+	}
+	exp := []string{"outer /db/query", "inner /db/query", "inner done", "outer done"}
+	if !reflect.DeepEqual(exp, calls) {
+		t.Fatalf("unexpected middleware calls\nwant: %v\ngot:  %v", exp, calls)
+	}
+	if gotHeader != "middleware" {
+		t.Fatalf("expected header set by middleware, got %q", gotHeader)
+	}
+
+	// Middleware may respond without sending the request.
+	errLimited := errors.New("rate limited")
+	cl.Use(func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, errLimited
+		})
+	})
+	if _, err := cl.Status(context.Background()); !errors.Is(err, errLimited) {
+		t.Fatalf("expected error from middleware, got %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/url"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -81,10 +82,76 @@ type BackupOptions struct {
 	Redirect bool `uvalue:"redirect,omitempty"`
 }
 
+// BackupFileOptions configures how BackupToFile writes a backup to disk.
+type BackupFileOptions struct {
+	// BackupOptions configures the backup requested from the node.
+	BackupOptions
+
+	// KeepCompressed, if set, writes a backup requested with Compress to disk as
+	// received, rather than decompressing it.
+	KeepCompressed bool
+
+	// Verify, if set, checks that the file written is a valid SQLite database, as
+	// VerifySQLiteFile does. It is ignored for SQL text backups, and for compressed
+	// backups written with KeepCompressed.
+	Verify bool
+}
+
 // LoadOptions configures how to load data into the node.
 type LoadOptions struct {
 	// If set, instruct a Follower to return a redirect instead of forwarding.
 	Redirect bool `uvalue:"redirect,omitempty"`
+
+	// BinaryContentType, if set, is sent as the Content-Type when loading SQLite
+	// binary data, instead of "application/octet-stream". It is sent exactly as
+	// given, with no charset appended.
+	BinaryContentType string
+
+	// TextContentType, if set, is sent as the Content-Type when loading plain text
+	// SQL, instead of "text/plain". It is sent exactly as given, with no charset
+	// appended.
+	TextContentType string
+
+	// Format is the format of the data. By default, the format is detected from
+	// the data.
+	Format LoadFormat
+}
+
+// LoadFormat is the format of data loaded into the node.
+type LoadFormat int
+
+const (
+	// LoadFormatAuto detects the format of the data from its first bytes. Data
+	// which starts with the SQLite file header is a SQLite database file, and any
+	// other data is SQL text.
+	LoadFormatAuto LoadFormat = iota
+
+	// LoadFormatSQLite is a SQLite database file.
+	LoadFormatSQLite
+
+	// LoadFormatSQLText is plain text SQL, such as a dump of a database.
+	LoadFormatSQLText
+)
+
+// RestoreOptions configures how Restore restores data to the cluster.
+type RestoreOptions struct {
+	// LoadOptions configures the load, if the data is restored with /db/load.
+	LoadOptions
+
+	// Progress, if set, is called as the data is sent, with the total number of
+	// bytes sent so far.
+	Progress func(sent int64)
+}
+
+// CopyOptions configures how CopyTo copies a database between clusters.
+type CopyOptions struct {
+	// Backup configures the backup requested from the source cluster. A backup
+	// requested with Compress is decompressed as it is copied.
+	Backup BackupOptions
+
+	// Restore configures how the backup is restored to the destination cluster. If
+	// its Format is LoadFormatAuto, the format is set from Backup.Format.
+	Restore RestoreOptions
 }
 
 // ExecuteOptions holds optional settings for /db/execute requests.
@@ -112,10 +179,19 @@ type ExecuteOptions struct {
 
 	// RaftIndex requests that the Raft log index be included in the response.
 	RaftIndex bool `uvalue:"raft_index,omitempty"`
+
+	// NoDefaults causes these options to be used as given, without the client's
+	// default options being merged into them, for example to turn off a bool set by
+	// default. It is not sent to the node.
+	NoDefaults bool
 }
 
 // QueryOptions holds optional settings for /db/query requests.
 type QueryOptions struct {
+	// Transaction indicates whether the statements should be enclosed in a transaction,
+	// so that they all read the same state of the database.
+	Transaction bool `uvalue:"transaction,omitempty"`
+
 	// Timeout is applied at the database level.
 	Timeout time.Duration `uvalue:"timeout,omitempty"`
 
@@ -134,11 +210,77 @@ type QueryOptions struct {
 	// Level controls the read consistency level for the query.
 	Level               ReadConsistencyLevel `uvalue:"level,omitempty"`
 	LinearizableTimeout time.Duration        `uvalue:"linearizable_timeout,omitempty"`
-	Freshness           time.Duration        `uvalue:"freshness,omitempty"`
-	FreshnessStrict     bool                 `uvalue:"freshness_strict,omitempty"`
+
+	// Freshness bounds how stale a read may be, and is only valid when Level is
+	// ReadConsistencyLevelNone. See WithMaxStaleness.
+	Freshness       time.Duration `uvalue:"freshness,omitempty"`
+	FreshnessStrict bool          `uvalue:"freshness_strict,omitempty"`
+
+	// NoLeader asks a Follower to answer the query from its local database instead
+	// of forwarding it to the Leader. It is only valid when Level is
+	// ReadConsistencyLevelNone. Results may be stale, by an amount which is unbounded
+	// unless Freshness is also set.
+	NoLeader bool `uvalue:"noleader,omitempty"`
 
 	// RaftIndex requests that the Raft log index be included in the response.
 	RaftIndex bool `uvalue:"raft_index,omitempty"`
+
+	// NoDefaults causes these options to be used as given, without the client's
+	// default options being merged into them, for example to turn off a bool set by
+	// default. It is not sent to the node.
+	NoDefaults bool
+}
+
+// WithMaxStaleness configures the options for a read of the node's local database,
+// which fails if the node last heard from the Leader more than d ago. If strict is
+// set, the node also checks that its most recently applied data is no more than d
+// older than the Leader's. WithMaxStaleness returns qo so calls may be chained.
+func (qo *QueryOptions) WithMaxStaleness(d time.Duration, strict bool) *QueryOptions {
+	qo.Level = ReadConsistencyLevelNone
+	qo.Freshness = d
+	qo.FreshnessStrict = strict
+	return qo
+}
+
+// WithLeaderRead configures the options for a read served by the Leader, which gives
+// read-your-writes consistency for writes made by any client. The read is made at the
+// Weak level, so a Follower receiving it forwards it to the Leader, and the Leader
+// checks that it is still the Leader before reading its local database, without the
+// cost of a Strong read passing through the Raft log, or the heartbeat round of a
+// Linearizable read.
+//
+// A Leader which has just been deposed, but does not yet know it, may serve a read
+// which misses writes committed by its successor within that window. Settings which
+// only apply to other levels, such as Freshness, NoLeader, and LinearizableTimeout,
+// are cleared. Timeout still applies to the read. WithLeaderRead returns qo so calls
+// may be chained.
+func (qo *QueryOptions) WithLeaderRead() *QueryOptions {
+	qo.Level = ReadConsistencyLevelWeak
+	qo.LinearizableTimeout = 0
+	qo.Freshness = 0
+	qo.FreshnessStrict = false
+	qo.NoLeader = false
+	return qo
+}
+
+// validate checks that the options form a valid combination.
+func (qo *QueryOptions) validate() error {
+	if qo == nil {
+		return nil
+	}
+	if qo.Freshness < 0 {
+		return fmt.Errorf("freshness must not be negative")
+	}
+	if qo.FreshnessStrict && qo.Freshness == 0 {
+		return fmt.Errorf("freshness_strict requires freshness to be set")
+	}
+	if qo.Freshness > 0 && qo.Level != ReadConsistencyLevelNone {
+		return fmt.Errorf("freshness requires read consistency level none, got %s", qo.Level)
+	}
+	if qo.NoLeader && qo.Level != ReadConsistencyLevelNone {
+		return fmt.Errorf("noleader requires read consistency level none, got %s", qo.Level)
+	}
+	return nil
 }
 
 // RequestOptions holds optional settings for /db/request requests.
@@ -160,6 +302,16 @@ type RequestOptions struct {
 
 	// RaftIndex requests that the Raft log index be included in the response.
 	RaftIndex bool `uvalue:"raft_index,omitempty"`
+
+	// ReadOnly indicates that every statement in the request only reads from the
+	// database, so the request may be retried even if a node may have received it.
+	// See Client.SetMaxRetries. It is not sent to the node.
+	ReadOnly bool
+
+	// NoDefaults causes these options to be used as given, without the client's
+	// default options being merged into them, for example to turn off a bool set by
+	// default. It is not sent to the node.
+	NoDefaults bool
 }
 
 // NodeOptions holds optional settings for /nodes requests.
@@ -179,9 +331,34 @@ type ReadyOptions struct {
 	Timeout time.Duration `uvalue:"timeout,omitempty"`
 }
 
+// DurationFormat controls how durations are formatted when sent to the node as
+// query parameters.
+type DurationFormat int
+
+const (
+	// DurationFormatGo formats durations as Go duration strings, for example "1.5s".
+	DurationFormatGo DurationFormat = iota
+
+	// DurationFormatSeconds formats durations as a number of seconds, for example "1.5".
+	DurationFormatSeconds
+)
+
+// format returns the string representation of d in the given format.
+func (df DurationFormat) format(d time.Duration) string {
+	if df == DurationFormatSeconds {
+		return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+	}
+	return d.String()
+}
+
 // makeURLValues converts a struct to a url.Values, using the `uvalue` tag to
-// determine the key name.
+// determine the key name. Durations are formatted as Go duration strings.
 func makeURLValues(input any) (url.Values, error) {
+	return makeURLValuesWithFormat(input, DurationFormatGo)
+}
+
+// makeURLValuesWithFormat is like makeURLValues, but formats durations using df.
+func makeURLValuesWithFormat(input any, df DurationFormat) (url.Values, error) {
 	vals := url.Values{}
 	if input == nil {
 		return vals, nil
@@ -230,7 +407,7 @@ func makeURLValues(input any) (url.Values, error) {
 			if d == 0 && omitEmpty {
 				continue
 			}
-			strVal = d.String()
+			strVal = df.format(d)
 		} else if fieldValue.Type() == reflect.TypeOf(ReadConsistencyLevel(0)) {
 			rcl := fieldValue.Interface().(ReadConsistencyLevel)
 			if rcl == ReadConsistencyLevelUnknown {
@@ -270,3 +447,50 @@ func makeURLValues(input any) (url.Values, error) {
 	}
 	return vals, nil
 }
+
+// consistencyFields are the names of the fields of the options which together select
+// the read consistency of a query. They are merged as a unit by mergeOptions, so that
+// a call's choice of consistency is never combined with settings from the defaults
+// which only apply to another level.
+var consistencyFields = []string{"Level", "LinearizableTimeout", "Freshness", "FreshnessStrict", "NoLeader"}
+
+// mergeOptions returns opts merged with the default options def. Each field of opts
+// which is unset, that is which has its zero value, takes the value of the same field
+// of def, except that the fields named by consistencyFields are all taken from opts if
+// any of them is set, and all taken from def otherwise. If opts has NoDefaults set, it
+// is returned as given. Neither opts nor def is modified, but either may be returned.
+func mergeOptions[T any](def, opts *T) *T {
+	if def == nil {
+		return opts
+	}
+	if opts == nil {
+		return def
+	}
+	ov := reflect.ValueOf(opts).Elem()
+	if f := ov.FieldByName("NoDefaults"); f.IsValid() && f.Bool() {
+		return opts
+	}
+	var callConsistency bool
+	for _, name := range consistencyFields {
+		if f := ov.FieldByName(name); f.IsValid() && !f.IsZero() {
+			callConsistency = true
+		}
+	}
+
+	merged := *opts
+	mv := reflect.ValueOf(&merged).Elem()
+	dv := reflect.ValueOf(def).Elem()
+	for i := range mv.NumField() {
+		f := mv.Field(i)
+		if slices.Contains(consistencyFields, mv.Type().Field(i).Name) {
+			if !callConsistency {
+				f.Set(dv.Field(i))
+			}
+			continue
+		}
+		if f.IsZero() {
+			f.Set(dv.Field(i))
+		}
+	}
+	return &merged
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"net/url"
 	"reflect"
 	"testing"
 	"time"
@@ -256,3 +257,136 @@ func Test_RaftIndexOptions(t *testing.T) {
 		}
 	})
 }
+
+func Test_QueryOptions_WithMaxStaleness(t *testing.T) {
+	opts := (&QueryOptions{}).WithMaxStaleness(5*time.Second, false)
+	if err := opts.validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	vals, err := makeURLValues(opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exp := url.Values{"level": []string{"none"}, "freshness": []string{"5s"}}
+	if !reflect.DeepEqual(exp, vals) {
+		t.Fatalf("expected %v, got %v", exp, vals)
+	}
+
+	opts = (&QueryOptions{Timings: true}).WithMaxStaleness(time.Minute, true)
+	vals, err = makeURLValues(opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exp = url.Values{
+		"timings":          []string{"true"},
+		"level":            []string{"none"},
+		"freshness":        []string{"1m0s"},
+		"freshness_strict": []string{"true"},
+	}
+	if !reflect.DeepEqual(exp, vals) {
+		t.Fatalf("expected %v, got %v", exp, vals)
+	}
+}
+
+func Test_QueryOptions_WithLeaderRead(t *testing.T) {
+	opts := (&QueryOptions{}).WithLeaderRead()
+	if err := opts.validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	vals, err := makeURLValues(opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exp := url.Values{"level": []string{"weak"}}
+	if !reflect.DeepEqual(exp, vals) {
+		t.Fatalf("expected %v, got %v", exp, vals)
+	}
+
+	// Settings for other levels are cleared, and the timeout is retained.
+	opts = (&QueryOptions{Timeout: 2 * time.Second, LinearizableTimeout: time.Second}).
+		WithMaxStaleness(time.Minute, true).
+		WithLeaderRead()
+	if err := opts.validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	vals, err = makeURLValues(opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exp = url.Values{"level": []string{"weak"}, "timeout": []string{"2s"}}
+	if !reflect.DeepEqual(exp, vals) {
+		t.Fatalf("expected %v, got %v", exp, vals)
+	}
+}
+
+func Test_QueryOptions_Validate(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		opts   *QueryOptions
+		expErr bool
+	}{
+		{"nil", nil, false},
+		{"empty", &QueryOptions{}, false},
+		{"freshness with level none", &QueryOptions{Level: ReadConsistencyLevelNone, Freshness: time.Second}, false},
+		{"freshness without level", &QueryOptions{Freshness: time.Second}, true},
+		{"freshness with level weak", &QueryOptions{Level: ReadConsistencyLevelWeak, Freshness: time.Second}, true},
+		{"negative freshness", &QueryOptions{Level: ReadConsistencyLevelNone, Freshness: -time.Second}, true},
+		{"strict without freshness", &QueryOptions{Level: ReadConsistencyLevelNone, FreshnessStrict: true}, true},
+		{"noleader with level none", &QueryOptions{Level: ReadConsistencyLevelNone, NoLeader: true}, false},
+		{"noleader without level", &QueryOptions{NoLeader: true}, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.validate()
+			if tt.expErr && err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if !tt.expErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func Test_MakeURLValuesDurationFormat(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		df     DurationFormat
+		d      time.Duration
+		expVal string
+	}{
+		{"Go whole seconds", DurationFormatGo, time.Second, "1s"},
+		{"Go fractional seconds", DurationFormatGo, 1500 * time.Millisecond, "1.5s"},
+		{"seconds whole", DurationFormatSeconds, time.Second, "1"},
+		{"seconds fractional", DurationFormatSeconds, 1500 * time.Millisecond, "1.5"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			vals, err := makeURLValuesWithFormat(&QueryOptions{Timeout: tt.d}, tt.df)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := vals.Get("timeout"); got != tt.expVal {
+				t.Fatalf("expected timeout=%s, got %s", tt.expVal, got)
+			}
+		})
+	}
+}
+
+func Test_QueryOptions_NoLeader(t *testing.T) {
+	opts := &QueryOptions{Level: ReadConsistencyLevelNone, NoLeader: true}
+	vals, err := makeURLValues(opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exp := url.Values{"level": []string{"none"}, "noleader": []string{"true"}}
+	if !reflect.DeepEqual(exp, vals) {
+		t.Fatalf("expected %v, got %v", exp, vals)
+	}
+
+	vals, err = makeURLValues(&QueryOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if vals.Has("noleader") {
+		t.Fatalf("expected noleader to be omitted, got %v", vals)
+	}
+}
@@ -0,0 +1,116 @@
+package http
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// RetryPolicy configures how the client retries a failed Execute, Query, or Request,
+// each retry being sent to the next host returned by the client's balancer.
+//
+// The policy controls how often, and after how long, a request is retried, but not
+// whether it is safe to retry: that is decided as described for SetMaxRetries, so a
+// request which may write to the database is never retried if the node may have
+// received it.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts made for a request, including
+	// the first. If it is 0 or 1, requests are not retried.
+	MaxAttempts int
+
+	// Backoff is the time to wait before the first retry. It doubles with each
+	// further retry, up to MaxBackoff. If it is 0, retries are sent immediately,
+	// except when the node signaled backpressure.
+	Backoff time.Duration
+
+	// MaxBackoff is the maximum time to wait before a retry. If it is 0, the wait
+	// is not limited.
+	MaxBackoff time.Duration
+
+	// RetryableStatusCodes are the HTTP status codes for which a read-only request
+	// is retried. If nil, 502, 503, and 504 are retryable.
+	RetryableStatusCodes []int
+
+	// MarkBad, if set, causes a host to which a request could not be sent, or whose
+	// connection failed, to be marked bad with the balancer's MarkBad method, if it
+	// has one, such as the RandomBalancer and RoundRobinBalancer. The balancer then
+	// avoids the host until its health check succeeds.
+	MarkBad bool
+}
+
+// defaultRetryableStatusCodes are the status codes for which a read-only request is
+// retried, unless a RetryPolicy says otherwise.
+var defaultRetryableStatusCodes = []int{
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// retryable returns whether a read-only request which received status code is
+// retried under the policy.
+func (p *RetryPolicy) retryable(code int) bool {
+	codes := defaultRetryableStatusCodes
+	if p != nil && p.RetryableStatusCodes != nil {
+		codes = p.RetryableStatusCodes
+	}
+	return slices.Contains(codes, code)
+}
+
+// backoff returns the time to wait before retry number attempt, counting from 1.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	if p == nil || p.Backoff <= 0 {
+		return 0
+	}
+	d := p.Backoff
+	for range attempt - 1 {
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			break
+		}
+		d *= 2
+	}
+	if p.MaxBackoff > 0 {
+		d = min(d, p.MaxBackoff)
+	}
+	return d
+}
+
+// SetRetryPolicy sets the policy by which the client retries a failed Execute,
+// Query, or Request. A policy replaces any number of retries set with SetMaxRetries.
+// Pass nil to remove the policy, after which SetMaxRetries applies again. p is copied.
+func (c *Client) SetRetryPolicy(p *RetryPolicy) {
+	if p != nil {
+		cp := *p
+		cp.RetryableStatusCodes = slices.Clone(p.RetryableStatusCodes)
+		p = &cp
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retryPolicy = p
+}
+
+// getRetryPolicy returns the client's retry policy, or nil if none is set.
+func (c *Client) getRetryPolicy() *RetryPolicy {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.retryPolicy
+}
+
+// maxRetryCount returns the number of times a request may be retried.
+func (c *Client) maxRetryCount(p *RetryPolicy) int {
+	if p != nil {
+		return max(p.MaxAttempts-1, 0)
+	}
+	return int(c.maxRetries.Load())
+}
+
+// markBad marks host bad with the client's balancer, if its retry policy asks for it
+// and the balancer supports it.
+func (c *Client) markBad(host *url.URL) {
+	if p := c.getRetryPolicy(); p == nil || !p.MarkBad {
+		return
+	}
+	if mb, ok := c.lb.(interface{ MarkBad(*url.URL) }); ok {
+		mb.MarkBad(host)
+	}
+}
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_RetryPolicy_Failover(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	var hits atomic.Int32
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Write([]byte(`{"results": []}`))
+	}))
+	defer up.Close()
+
+	rb, err := NewRoundRobinBalancer([]string{down.URL, up.URL}, func(u *url.URL) bool { return false }, time.Hour)
+	if err != nil {
+		t.Fatalf("failed to create balancer: %v", err)
+	}
+	defer rb.Close()
+	cl, err := NewClient("", WithBalancer(rb), WithRetryPolicy(&RetryPolicy{
+		MaxAttempts: 2,
+		Backoff:     time.Millisecond,
+		MarkBad:     true,
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	if _, err := cl.ExecuteSingle(context.Background(), "INSERT INTO foo VALUES(1)"); err != nil {
+		t.Fatalf("unexpected error from ExecuteSingle: %v", err)
+	}
+	if exp, got := int32(1), hits.Load(); exp != got {
+		t.Fatalf("expected %d request to reach healthy host, got %d", exp, got)
+	}
+	bad := rb.Bad()
+	if len(bad) != 1 || bad[0].String() != down.URL {
+		t.Fatalf("expected failed host to be marked bad, got %v", bad)
+	}
+}
+
+func Test_RetryPolicy_StatusCodes(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1)%2 == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"results": []}`))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, WithRetryPolicy(&RetryPolicy{
+		MaxAttempts:          3,
+		RetryableStatusCodes: []int{http.StatusInternalServerError},
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	if _, err := cl.QuerySingle(context.Background(), "SELECT * FROM foo"); err != nil {
+		t.Fatalf("unexpected error from QuerySingle: %v", err)
+	}
+	if exp, got := int32(2), hits.Load(); exp != got {
+		t.Fatalf("expected %d requests, got %d", exp, got)
+	}
+
+	// A write which reached the node is never retried.
+	hits.Store(0)
+	if _, err := cl.ExecuteSingle(context.Background(), "INSERT INTO foo VALUES(1)"); err == nil {
+		t.Fatalf("expected error from ExecuteSingle")
+	}
+	if exp, got := int32(1), hits.Load(); exp != got {
+		t.Fatalf("expected %d request, got %d", exp, got)
+	}
+}
+
+func Test_RetryPolicy_Backoff(t *testing.T) {
+	p := &RetryPolicy{Backoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+	for attempt, exp := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond} {
+		if got := p.backoff(attempt + 1); got != exp {
+			t.Fatalf("expected backoff %s for attempt %d, got %s", exp, attempt+1, got)
+		}
+	}
+	var nilPolicy *RetryPolicy
+	if got := nilPolicy.backoff(1); got != 0 {
+		t.Fatalf("expected no backoff without a policy, got %s", got)
+	}
+}
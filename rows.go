@@ -0,0 +1,261 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// Rows is an iterator over the rows returned by a query made with QueryIter. Rows are
+// decoded from the response as they are read, so the whole result set is never held
+// in memory. Rows must be closed once iteration is finished.
+type Rows struct {
+	body    io.ReadCloser
+	dec     *json.Decoder
+	columns []string
+	types   []string
+	row     []any
+	done    bool
+	err     error
+}
+
+// QueryIter performs a read operation using /db/query, returning an iterator over the
+// rows returned by statement. opts may be nil, in which case default options are used.
+// Results are always requested in the default form, so opts.Associative is ignored.
+//
+// The response is decoded with the encoding/json package, regardless of the client's
+// Codec. An error returned by the node for the statement is returned by QueryIter.
+func (c *Client) QueryIter(ctx context.Context, statement *SQLStatement, opts *QueryOptions) (*Rows, error) {
+	opts = c.queryOptions(opts)
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	var o QueryOptions
+	if opts != nil {
+		o = *opts
+	}
+	o.Associative = false
+
+	stmts := SQLStatements{statement}
+	body, closeBody, err := c.statementsBody(stmts)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody()
+	queryParams, err := c.makeURLValues(&o)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.setStatementTimeout(stmts, queryParams); err != nil {
+		return nil, err
+	}
+	c.setTimeoutFromContext(ctx, queryParams)
+
+	resp, err := c.doJSONPostRequest(ctx, c.routeQuery(&o), queryPath, queryParams, body, true)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		b, err := readAll(ctx, resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return nil, c.withRequest(newHTTPError(resp.StatusCode, b), stmts, queryParams)
+	}
+
+	r := &Rows{body: resp.Body, dec: json.NewDecoder(resp.Body)}
+	r.dec.UseNumber()
+	if err := r.readHeader(); err != nil {
+		r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// readHeader reads the response up to the first row of the first result.
+func (r *Rows) readHeader() error {
+	if err := expectDelim(r.dec, '{'); err != nil {
+		return err
+	}
+	for {
+		key, err := r.nextKey()
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "":
+			return errors.New("response contains no results")
+		case "error":
+			var msg string
+			if err := r.dec.Decode(&msg); err != nil {
+				return err
+			}
+			return errors.New(msg)
+		case "results":
+			if err := expectDelim(r.dec, '['); err != nil {
+				return err
+			}
+			if !r.dec.More() {
+				return errors.New("response contains no results")
+			}
+			return r.readResultHeader()
+		default:
+			if err := r.dec.Decode(&json.RawMessage{}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// readResultHeader reads the first result up to its first row.
+func (r *Rows) readResultHeader() error {
+	if err := expectDelim(r.dec, '{'); err != nil {
+		return err
+	}
+	for {
+		key, err := r.nextKey()
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "":
+			// The result has no rows.
+			r.done = true
+			return nil
+		case "columns":
+			err = r.dec.Decode(&r.columns)
+		case "types":
+			err = r.dec.Decode(&r.types)
+		case "error":
+			var msg string
+			if err := r.dec.Decode(&msg); err != nil {
+				return err
+			}
+			return errors.New(msg)
+		case "values":
+			return expectDelim(r.dec, '[')
+		default:
+			err = r.dec.Decode(&json.RawMessage{})
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// nextKey returns the next key of the object being decoded, or "" if the end of the
+// object was reached.
+func (r *Rows) nextKey() (string, error) {
+	tok, err := r.dec.Token()
+	if err != nil {
+		return "", err
+	}
+	if tok == json.Delim('}') {
+		return "", nil
+	}
+	key, ok := tok.(string)
+	if !ok {
+		return "", fmt.Errorf("unexpected token %v", tok)
+	}
+	return key, nil
+}
+
+// Columns returns the names of the columns.
+func (r *Rows) Columns() []string {
+	return r.columns
+}
+
+// Types returns the declared types of the columns.
+func (r *Rows) Types() []string {
+	return r.types
+}
+
+// Next prepares the next row for reading with Scan or Values. It returns false once
+// there are no more rows, or if an error occurs, which is returned by Err.
+func (r *Rows) Next() bool {
+	if r.done {
+		return false
+	}
+	if !r.dec.More() {
+		r.done = true
+		return false
+	}
+	r.row = nil
+	if err := r.dec.Decode(&r.row); err != nil {
+		r.err = err
+		r.done = true
+		return false
+	}
+	return true
+}
+
+// Values returns the values of the current row.
+func (r *Rows) Values() []any {
+	return r.row
+}
+
+// Scan copies the values of the current row into dest, which must hold one pointer
+// per column. Values are converted to the types pointed to as RowsInto converts them,
+// and a pointer to an any receives the value as an int64, float64, string, []byte,
+// bool, or nil.
+func (r *Rows) Scan(dest ...any) error {
+	if r.row == nil {
+		return errors.New("Scan called without a current row")
+	}
+	if len(dest) != len(r.row) {
+		return fmt.Errorf("expected %d destinations, got %d", len(r.row), len(dest))
+	}
+	for i, d := range dest {
+		var typ string
+		if i < len(r.types) {
+			typ = strings.ToLower(r.types[i])
+		}
+		if p, ok := d.(*any); ok {
+			v, err := normalizeValue(r.row[i], typ)
+			if err != nil {
+				return fmt.Errorf("column %d: %w", i, err)
+			}
+			*p = v
+			continue
+		}
+		dv := reflect.ValueOf(d)
+		if dv.Kind() != reflect.Pointer || dv.IsNil() {
+			return fmt.Errorf("destination %d is not a non-nil pointer", i)
+		}
+		if err := setField(dv.Elem(), r.row[i], typ); err != nil {
+			return fmt.Errorf("column %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Err returns the error, if any, encountered during iteration.
+func (r *Rows) Err() error {
+	return r.err
+}
+
+// Close closes the response from which rows are read. It is safe to call Close more
+// than once.
+func (r *Rows) Close() error {
+	r.done = true
+	r.row = nil
+	return r.body.Close()
+}
+
+// expectDelim reads the next token from dec, returning an error if it is not delim.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("expected %s, got %v", delim, tok)
+	}
+	return nil
+}
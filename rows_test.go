@@ -0,0 +1,150 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_QueryIter(t *testing.T) {
+	const n = 10000
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/db/query" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if r.URL.Query().Has("associative") {
+			t.Errorf("expected associative form not to be requested")
+		}
+		fmt.Fprint(w, `{"results": [{"columns": ["id", "name", "data"], "types": ["integer", "text", "blob"], "values": [`)
+		for i := range n {
+			if i > 0 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `[%d, "name%d", "AQID"]`, i, i)
+		}
+		fmt.Fprint(w, `], "time": 0.5}], "time": 1.5}`)
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	rows, err := cl.QueryIter(context.Background(), &SQLStatement{SQL: "SELECT * FROM foo"}, &QueryOptions{Associative: true})
+	if err != nil {
+		t.Fatalf("unexpected error from QueryIter: %v", err)
+	}
+	defer rows.Close()
+	if exp, got := fmt.Sprint([]string{"id", "name", "data"}), fmt.Sprint(rows.Columns()); exp != got {
+		t.Fatalf("expected columns %s, got %s", exp, got)
+	}
+
+	var count int
+	for rows.Next() {
+		var id int
+		var name string
+		var data []byte
+		if err := rows.Scan(&id, &name, &data); err != nil {
+			t.Fatalf("unexpected error from Scan: %v", err)
+		}
+		if id != count || name != fmt.Sprintf("name%d", count) || !bytes.Equal(data, []byte{1, 2, 3}) {
+			t.Fatalf("unexpected row %d: %d %s %v", count, id, name, data)
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("unexpected error iterating rows: %v", err)
+	}
+	if count != n {
+		t.Fatalf("expected %d rows, got %d", n, count)
+	}
+	if rows.Next() {
+		t.Fatalf("expected no more rows")
+	}
+}
+
+func Test_QueryIter_Results(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		response string
+		status   int
+		expErr   string
+		expRows  int
+	}{
+		{
+			name:     "no rows",
+			response: `{"results": [{"columns": ["id"], "types": ["integer"]}]}`,
+		},
+		{
+			name:     "statement error",
+			response: `{"results": [{"error": "no such table: foo"}]}`,
+			expErr:   "no such table: foo",
+		},
+		{
+			name:     "top-level error",
+			response: `{"error": "stale read"}`,
+			expErr:   "stale read",
+		},
+		{
+			name:     "HTTP error",
+			response: `{"error": "bad request"}`,
+			status:   http.StatusBadRequest,
+			expErr:   `unexpected status code: 400, error: bad request`,
+		},
+		{
+			name:     "raw values",
+			response: `{"results": [{"columns": ["a", "b"], "types": ["integer", "text"], "values": [[1, null]]}]}`,
+			expRows:  1,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			cl, err := NewClient(server.URL, nil)
+			if err != nil {
+				t.Fatalf("unexpected error from NewClient: %v", err)
+			}
+			defer cl.Close()
+
+			rows, err := cl.QueryIter(context.Background(), &SQLStatement{SQL: "SELECT * FROM foo"}, nil)
+			if tt.expErr != "" {
+				if err == nil || err.Error() != tt.expErr {
+					t.Fatalf("expected error %q, got %v", tt.expErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error from QueryIter: %v", err)
+			}
+			defer rows.Close()
+			var count int
+			for rows.Next() {
+				var a, b any
+				if err := rows.Scan(&a, &b); err != nil {
+					t.Fatalf("unexpected error from Scan: %v", err)
+				}
+				if a != int64(1) || b != nil {
+					t.Fatalf("unexpected values %v %v", a, b)
+				}
+				count++
+			}
+			if err := rows.Err(); err != nil {
+				t.Fatalf("unexpected error iterating rows: %v", err)
+			}
+			if count != tt.expRows {
+				t.Fatalf("expected %d rows, got %d", tt.expRows, count)
+			}
+		})
+	}
+}
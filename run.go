@@ -0,0 +1,117 @@
+package http
+
+import (
+	"context"
+	"strings"
+)
+
+// StatementKind identifies whether a statement reads from or writes to the database.
+type StatementKind int
+
+const (
+	// StatementUnknown indicates the kind of the statement could not be determined.
+	StatementUnknown StatementKind = iota
+
+	// StatementRead indicates the statement only reads from the database.
+	StatementRead
+
+	// StatementWrite indicates the statement may write to the database.
+	StatementWrite
+)
+
+// StatementClassifier returns the kind of a statement.
+type StatementClassifier func(stmt *SQLStatement) StatementKind
+
+// ClassifyStatement determines the kind of a statement from its leading SQL keyword,
+// ignoring any leading whitespace and comments. Statements whose kind cannot be
+// reliably determined from the keyword alone, such as those starting with WITH or
+// PRAGMA, are classified as StatementUnknown.
+func ClassifyStatement(stmt *SQLStatement) StatementKind {
+	switch firstKeyword(stmt.SQL) {
+	case "SELECT", "VALUES", "EXPLAIN":
+		return StatementRead
+	case "INSERT", "UPDATE", "DELETE", "REPLACE", "CREATE", "DROP", "ALTER",
+		"VACUUM", "REINDEX", "ANALYZE", "ATTACH", "DETACH",
+		"BEGIN", "COMMIT", "END", "ROLLBACK", "SAVEPOINT", "RELEASE":
+		return StatementWrite
+	}
+	return StatementUnknown
+}
+
+// SetStatementClassifier sets the function Run uses to determine the kind of each
+// statement. If f is nil, ClassifyStatement is used.
+func (c *Client) SetStatementClassifier(f StatementClassifier) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.classifier = f
+}
+
+// Run executes statements using the endpoint best suited to them. If every statement
+// is a write, the statements are sent to /db/execute, and if every statement is a read,
+// they are sent to /db/query. Otherwise, including if the kind of any statement is
+// unknown, they are sent to /db/request. Statements are classified using the function
+// set by SetStatementClassifier, or ClassifyStatement by default.
+//
+// Whichever endpoint is used, the results are returned as a RequestResponse. opts may
+// be nil, in which case default options are used. opts is not modified.
+func (c *Client) Run(ctx context.Context, statements SQLStatements, opts *RequestOptions) (*RequestResponse, error) {
+	c.mu.RLock()
+	classify := c.classifier
+	c.mu.RUnlock()
+	if classify == nil {
+		classify = ClassifyStatement
+	}
+
+	var reads, writes int
+	for _, stmt := range statements {
+		switch classify(stmt) {
+		case StatementRead:
+			reads++
+		case StatementWrite:
+			writes++
+		}
+	}
+
+	switch {
+	case len(statements) > 0 && writes == len(statements):
+		return c.request(ctx, c.routeWrite(), executePath, statements, opts)
+	case len(statements) > 0 && reads == len(statements):
+		var qo QueryOptions
+		if opts != nil {
+			qo.Level = opts.Level
+		}
+		return c.request(ctx, c.routeQuery(&qo), queryPath, statements, opts)
+	default:
+		return c.request(ctx, c.routeWrite(), requestPath, statements, opts)
+	}
+}
+
+// firstKeyword returns the first keyword of sql in upper case, skipping leading
+// whitespace, comments, and opening parentheses.
+func firstKeyword(sql string) string {
+	for {
+		sql = strings.TrimLeft(sql, " \t\r\n(")
+		switch {
+		case strings.HasPrefix(sql, "--"):
+			if i := strings.IndexByte(sql, '\n'); i >= 0 {
+				sql = sql[i+1:]
+			} else {
+				return ""
+			}
+		case strings.HasPrefix(sql, "/*"):
+			if i := strings.Index(sql[2:], "*/"); i >= 0 {
+				sql = sql[i+4:]
+			} else {
+				return ""
+			}
+		default:
+			end := strings.IndexFunc(sql, func(r rune) bool {
+				return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+			})
+			if end >= 0 {
+				sql = sql[:end]
+			}
+			return strings.ToUpper(sql)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_ClassifyStatement(t *testing.T) {
+	for _, tt := range []struct {
+		sql string
+		exp StatementKind
+	}{
+		{"SELECT * FROM foo", StatementRead},
+		{"  select 1", StatementRead},
+		{"(SELECT 1) UNION SELECT 2", StatementRead},
+		{"-- comment\nSELECT 1", StatementRead},
+		{"/* INSERT */ SELECT 1", StatementRead},
+		{"INSERT INTO foo VALUES(1)", StatementWrite},
+		{"update foo SET name='x'", StatementWrite},
+		{"DELETE FROM foo", StatementWrite},
+		{"CREATE TABLE foo (id INTEGER)", StatementWrite},
+		{"/* SELECT */\n\tDROP TABLE foo", StatementWrite},
+		{"WITH x AS (SELECT 1) SELECT * FROM x", StatementUnknown},
+		{"PRAGMA foreign_keys", StatementUnknown},
+		{"-- only a comment", StatementUnknown},
+		{"", StatementUnknown},
+	} {
+		if got := ClassifyStatement(&SQLStatement{SQL: tt.sql}); got != tt.exp {
+			t.Fatalf("classifying %q: expected %d, got %d", tt.sql, tt.exp, got)
+		}
+	}
+}
+
+func Test_Run(t *testing.T) {
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+		switch r.URL.Path {
+		case "/db/execute":
+			w.Write([]byte(`{"results": [{"last_insert_id": 1, "rows_affected": 1}]}`))
+		case "/db/query":
+			w.Write([]byte(`{"results": [{"columns": ["id"], "types": ["integer"], "values": [[1]]}]}`))
+		default:
+			w.Write([]byte(`{"results": [{"last_insert_id": 1, "rows_affected": 1}, {"columns": ["id"], "types": ["integer"], "values": [[1]]}]}`))
+		}
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	for _, tt := range []struct {
+		name  string
+		stmts []string
+		exp   string
+	}{
+		{"write only", []string{"INSERT INTO foo VALUES(1)", "UPDATE foo SET id=2"}, "/db/execute"},
+		{"read only", []string{"SELECT * FROM foo", "SELECT COUNT(*) FROM foo"}, "/db/query"},
+		{"mixed", []string{"INSERT INTO foo VALUES(1)", "SELECT * FROM foo"}, "/db/request"},
+		{"unknown", []string{"PRAGMA foreign_keys"}, "/db/request"},
+		{"empty", nil, "/db/request"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPath = ""
+			if _, err := cl.Run(context.Background(), NewSQLStatementsFromStrings(tt.stmts), nil); err != nil {
+				t.Fatalf("unexpected error calling Run: %v", err)
+			}
+			if gotPath != tt.exp {
+				t.Fatalf("expected request to %s, got %s", tt.exp, gotPath)
+			}
+		})
+	}
+
+	t.Run("results", func(t *testing.T) {
+		rr, err := cl.Run(context.Background(), NewSQLStatementsFromStrings([]string{"INSERT INTO foo VALUES(1)"}), nil)
+		if err != nil {
+			t.Fatalf("unexpected error calling Run: %v", err)
+		}
+		if er := rr.ExecuteResults(); len(er) != 1 || er[0].RowsAffected != 1 {
+			t.Fatalf("unexpected execute results: %+v", er)
+		}
+		rr, err = cl.Run(context.Background(), NewSQLStatementsFromStrings([]string{"SELECT * FROM foo"}), nil)
+		if err != nil {
+			t.Fatalf("unexpected error calling Run: %v", err)
+		}
+		if qr := rr.QueryResults().([]QueryResult); len(qr) != 1 || qr[0].Columns[0] != "id" {
+			t.Fatalf("unexpected query results: %+v", qr)
+		}
+	})
+
+	t.Run("custom classifier", func(t *testing.T) {
+		cl.SetStatementClassifier(func(stmt *SQLStatement) StatementKind {
+			if stmt.SQL == "PRAGMA foreign_keys" {
+				return StatementRead
+			}
+			return ClassifyStatement(stmt)
+		})
+		defer cl.SetStatementClassifier(nil)
+		if _, err := cl.Run(context.Background(), NewSQLStatementsFromStrings([]string{"PRAGMA foreign_keys"}), nil); err != nil {
+			t.Fatalf("unexpected error calling Run: %v", err)
+		}
+		if exp := "/db/query"; gotPath != exp {
+			t.Fatalf("expected request to %s, got %s", exp, gotPath)
+		}
+	})
+}
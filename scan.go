@@ -0,0 +1,375 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RowsInto converts the rows of a query result into values of type T, which must be
+// a struct type. Each column is stored in the field whose "db" tag matches the column
+// name. A field without a "db" tag matches a column whose name equals the field name,
+// ignoring case, and a field tagged `db:"-"` is ignored. Columns which match no field
+// are ignored. Columns sharing a name are distinguished as by UniqueColumns with
+// DuplicateColumnsSuffix.
+//
+// Values are converted to the type of their field. Numbers may be stored in any
+// numeric field, and integers in bool fields. BLOB columns, which rqlite returns as
+// base64-encoded text or as arrays of bytes, may be stored in []byte fields. Text may
+// be stored in time.Time fields if it is in RFC 3339 format, and numbers are treated
+// as Unix times in seconds. NULL is stored as the zero value of its field, or as nil
+// in pointer fields. A field whose pointer implements sql.Scanner is passed the value
+// as an int64, float64, string, []byte, bool, or nil.
+func RowsInto[T any](qr QueryResult) ([]T, error) {
+	cols, err := qr.UniqueColumns(DuplicateColumnsSuffix)
+	if err != nil {
+		return nil, err
+	}
+	types := make(map[string]string, len(cols))
+	for i, col := range cols {
+		if i < len(qr.Types) {
+			types[col] = qr.Types[i]
+		}
+	}
+	rows := make([]map[string]any, len(qr.Values))
+	for i, vals := range qr.Values {
+		rows[i] = make(map[string]any, len(cols))
+		for j := range min(len(vals), len(cols)) {
+			rows[i][cols[j]] = vals[j]
+		}
+	}
+	return rowsInto[T](rows, types)
+}
+
+// RowsIntoAssoc is like RowsInto, but converts the rows of a result in the
+// associative form.
+func RowsIntoAssoc[T any](qr QueryResultAssoc) ([]T, error) {
+	return rowsInto[T](qr.Rows, qr.Types)
+}
+
+// QueryTyped performs the query statement, with args as for NewSQLStatement, and
+// converts the rows of its result into values of type T, as RowsInto does. Results
+// are requested in the associative form. If the node returns an error for the
+// statement, it is returned.
+func QueryTyped[T any](ctx context.Context, c *Client, statement string, args ...any) ([]T, error) {
+	stmt, err := NewSQLStatement(statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	qr, err := c.Query(ctx, SQLStatements{stmt}, &QueryOptions{Associative: true})
+	if err != nil {
+		return nil, err
+	}
+	var out []T
+	if err := qr.Scan(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Scan converts the rows of the first result in the response into dest, which must be
+// a pointer to a slice of structs, as RowsInto does. Results in either the default or
+// the associative form are supported. If the response contains an error, it is
+// returned.
+func (qr *QueryResponse) Scan(dest any) error {
+	if f, _, msg := qr.HasError(); f {
+		return errors.New(msg)
+	}
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Pointer || dv.IsNil() || dv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("destination must be a non-nil pointer to a slice, got %T", dest)
+	}
+
+	var rows []map[string]any
+	var types map[string]string
+	switch v := qr.Results.(type) {
+	case []QueryResult:
+		if len(v) == 0 {
+			return errors.New("response contains no results")
+		}
+		m, err := v[0].AsAssoc(DuplicateColumnsSuffix)
+		if err != nil {
+			return err
+		}
+		rows, types = m.Rows, m.Types
+	case []QueryResultAssoc:
+		if len(v) == 0 {
+			return errors.New("response contains no results")
+		}
+		rows, types = v[0].Rows, v[0].Types
+	default:
+		return fmt.Errorf("unsupported results type %T", qr.Results)
+	}
+
+	sv := dv.Elem()
+	out, err := scanRows(sv.Type().Elem(), rows, types)
+	if err != nil {
+		return err
+	}
+	sv.Set(out)
+	return nil
+}
+
+func rowsInto[T any](rows []map[string]any, types map[string]string) ([]T, error) {
+	out, err := scanRows(reflect.TypeFor[T](), rows, types)
+	if err != nil {
+		return nil, err
+	}
+	return out.Interface().([]T), nil
+}
+
+// scanRows converts rows into a slice of the struct type typ.
+func scanRows(typ reflect.Type, rows []map[string]any, types map[string]string) (reflect.Value, error) {
+	if typ.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("cannot scan into %s, which is not a struct", typ)
+	}
+	fields := structFields(typ)
+	out := reflect.MakeSlice(reflect.SliceOf(typ), len(rows), len(rows))
+	for i, row := range rows {
+		for col, v := range row {
+			idx, ok := fields[col]
+			if !ok {
+				idx, ok = fields[strings.ToLower(col)]
+			}
+			if !ok {
+				continue
+			}
+			fv := out.Index(i).FieldByIndex(idx)
+			if err := setField(fv, v, strings.ToLower(types[col])); err != nil {
+				return reflect.Value{}, fmt.Errorf("row %d, column %s: %w", i, col, err)
+			}
+		}
+	}
+	return out, nil
+}
+
+// structFields returns the index of each field of typ into which a column may be
+// scanned. Fields with a "db" tag are keyed by the tag, and other fields by their
+// lowercased name.
+func structFields(typ reflect.Type) map[string][]int {
+	fields := make(map[string][]int)
+	for _, f := range reflect.VisibleFields(typ) {
+		if !f.IsExported() || (f.Anonymous && f.Type.Kind() == reflect.Struct) {
+			continue
+		}
+		name, ok := f.Tag.Lookup("db")
+		if name == "-" {
+			continue
+		}
+		if !ok || name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		if _, dup := fields[name]; !dup {
+			fields[name] = f.Index
+		}
+	}
+	return fields
+}
+
+var (
+	scannerType = reflect.TypeFor[sql.Scanner]()
+	timeType    = reflect.TypeFor[time.Time]()
+)
+
+// setField stores v, a value decoded from a column of type typ, in fv.
+func setField(fv reflect.Value, v any, typ string) error {
+	if fv.CanAddr() && fv.Addr().Type().Implements(scannerType) {
+		nv, err := normalizeValue(v, typ)
+		if err != nil {
+			return err
+		}
+		return fv.Addr().Interface().(sql.Scanner).Scan(nv)
+	}
+	if v == nil {
+		fv.SetZero()
+		return nil
+	}
+	if fv.Kind() == reflect.Pointer {
+		p := reflect.New(fv.Type().Elem())
+		if err := setField(p.Elem(), v, typ); err != nil {
+			return err
+		}
+		fv.Set(p)
+		return nil
+	}
+	if fv.Type() == timeType {
+		t, err := toTime(v)
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := toInt64(v)
+		if err != nil {
+			return err
+		}
+		if fv.OverflowInt(i) {
+			return fmt.Errorf("value %d overflows %s", i, fv.Type())
+		}
+		fv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := toInt64(v)
+		if err != nil {
+			return err
+		}
+		if i < 0 || fv.OverflowUint(uint64(i)) {
+			return fmt.Errorf("value %d overflows %s", i, fv.Type())
+		}
+		fv.SetUint(uint64(i))
+	case reflect.Float32, reflect.Float64:
+		f, err := toFloat64(v)
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	case reflect.Bool:
+		switch v := v.(type) {
+		case bool:
+			fv.SetBool(v)
+		default:
+			i, err := toInt64(v)
+			if err != nil {
+				return err
+			}
+			fv.SetBool(i != 0)
+		}
+	case reflect.String:
+		switch v := v.(type) {
+		case string:
+			fv.SetString(v)
+		case json.Number:
+			fv.SetString(v.String())
+		default:
+			return fmt.Errorf("cannot convert %T to string", v)
+		}
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("unsupported field type %s", fv.Type())
+		}
+		b, err := toBytes(v, typ)
+		if err != nil {
+			return err
+		}
+		fv.SetBytes(b)
+	default:
+		return fmt.Errorf("unsupported field type %s", fv.Type())
+	}
+	return nil
+}
+
+// normalizeValue converts v, a value decoded from a column of type typ, to one of the
+// types database/sql passes to a Scanner.
+func normalizeValue(v any, typ string) (any, error) {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		return v.Float64()
+	case string:
+		if typ == "blob" {
+			return toBytes(v, typ)
+		}
+		return v, nil
+	case []any:
+		return toBytes(v, typ)
+	default:
+		return v, nil
+	}
+}
+
+func toInt64(v any) (int64, error) {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		f, err := v.Float64()
+		if err != nil || f != float64(int64(f)) {
+			return 0, fmt.Errorf("cannot convert %s to integer", v)
+		}
+		return int64(f), nil
+	case int64:
+		return v, nil
+	case float64:
+		if v != float64(int64(v)) {
+			return 0, fmt.Errorf("cannot convert %v to integer", v)
+		}
+		return int64(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	default:
+		return 0, fmt.Errorf("cannot convert %T to integer", v)
+	}
+}
+
+func toFloat64(v any) (float64, error) {
+	switch v := v.(type) {
+	case json.Number:
+		return v.Float64()
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	default:
+		return 0, fmt.Errorf("cannot convert %T to float", v)
+	}
+}
+
+func toBytes(v any, typ string) ([]byte, error) {
+	switch v := v.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		if typ == "blob" {
+			return base64.StdEncoding.DecodeString(v)
+		}
+		return []byte(v), nil
+	case []any:
+		b := make([]byte, len(v))
+		for i, e := range v {
+			x, err := toInt64(e)
+			if err != nil || x < 0 || x > 255 {
+				return nil, fmt.Errorf("invalid byte %v", e)
+			}
+			b[i] = byte(x)
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("cannot convert %T to bytes", v)
+	}
+}
+
+func toTime(v any) (time.Time, error) {
+	switch v := v.(type) {
+	case string:
+		return time.Parse(time.RFC3339Nano, v)
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return time.Time{}, err
+		}
+		sec := int64(f)
+		return time.Unix(sec, int64((f-float64(sec))*1e9)).UTC(), nil
+	default:
+		return time.Time{}, fmt.Errorf("cannot convert %T to time", v)
+	}
+}
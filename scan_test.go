@@ -0,0 +1,146 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type scanPerson struct {
+	ID       int64  `db:"id"`
+	Name     string `db:"name"`
+	Score    float32
+	Active   bool           `db:"active"`
+	Photo    []byte         `db:"photo"`
+	Nickname sql.NullString `db:"nickname"`
+	Age      *int           `db:"age"`
+	Joined   time.Time      `db:"joined"`
+	Ignored  string         `db:"-"`
+}
+
+func Test_RowsInto(t *testing.T) {
+	resp := mustUnmarshalQueryResponse(`{"results": [{
+		"columns": ["id", "name", "score", "active", "photo", "nickname", "age", "joined", "extra"],
+		"types": ["integer", "text", "real", "integer", "blob", "text", "integer", "text", "text"],
+		"values": [
+			[1, "fiona", 9.5, 1, "AQID", "fi", 20, "2024-03-01T12:00:00Z", "x"],
+			[2, "declan", 7, 0, null, null, null, null, "y"]
+		]
+	}]}`)
+	people, err := RowsInto[scanPerson](resp.GetQueryResults()[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	age := 20
+	exp := []scanPerson{
+		{
+			ID: 1, Name: "fiona", Score: 9.5, Active: true, Photo: []byte{1, 2, 3},
+			Nickname: sql.NullString{String: "fi", Valid: true}, Age: &age,
+			Joined: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{ID: 2, Name: "declan", Score: 7},
+	}
+	if !reflect.DeepEqual(exp, people) {
+		t.Fatalf("unexpected rows\nwant: %+v\ngot:  %+v", exp, people)
+	}
+}
+
+func Test_RowsInto_Errors(t *testing.T) {
+	resp := mustUnmarshalQueryResponse(`{"results": [{
+		"columns": ["id", "name"],
+		"types": ["integer", "text"],
+		"values": [[1.5, "fiona"]]
+	}]}`)
+	if _, err := RowsInto[scanPerson](resp.GetQueryResults()[0]); err == nil || err.Error() != "row 0, column id: cannot convert 1.5 to integer" {
+		t.Fatalf("expected conversion error, got %v", err)
+	}
+	if _, err := RowsInto[int](resp.GetQueryResults()[0]); err == nil {
+		t.Fatalf("expected error scanning into non-struct")
+	}
+
+	type small struct {
+		ID int8 `db:"id"`
+	}
+	resp = mustUnmarshalQueryResponse(`{"results": [{"columns": ["id"], "types": ["integer"], "values": [[300]]}]}`)
+	if _, err := RowsInto[small](resp.GetQueryResults()[0]); err == nil {
+		t.Fatalf("expected overflow error")
+	}
+}
+
+func Test_QueryResponse_Scan(t *testing.T) {
+	type pair struct {
+		ID      int64  `db:"id"`
+		OtherID int64  `db:"id_1"`
+		Name    string `db:"name"`
+	}
+	resp := mustUnmarshalQueryResponse(`{"results": [{
+		"columns": ["id", "id", "name"],
+		"types": ["integer", "integer", "text"],
+		"values": [[1, 10, "fiona"], [2, 20, "declan"]]
+	}]}`)
+	var got []pair
+	if err := resp.Scan(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exp := []pair{{1, 10, "fiona"}, {2, 20, "declan"}}
+	if !reflect.DeepEqual(exp, got) {
+		t.Fatalf("unexpected rows\nwant: %+v\ngot:  %+v", exp, got)
+	}
+
+	resp = mustUnmarshalQueryResponse(`{"results": [{
+		"types": {"id": "integer", "name": "text"},
+		"rows": [{"id": 3, "name": "sinead"}]
+	}]}`)
+	got = nil
+	if err := resp.Scan(&got); err != nil {
+		t.Fatalf("unexpected error scanning associative results: %v", err)
+	}
+	if exp := []pair{{ID: 3, Name: "sinead"}}; !reflect.DeepEqual(exp, got) {
+		t.Fatalf("unexpected rows\nwant: %+v\ngot:  %+v", exp, got)
+	}
+
+	if err := resp.Scan(got); err == nil {
+		t.Fatalf("expected error scanning into non-pointer")
+	}
+
+	resp = mustUnmarshalQueryResponse(`{"results": [{"error": "no such table: foo"}]}`)
+	if err := resp.Scan(&got); err == nil || err.Error() != "no such table: foo" {
+		t.Fatalf("expected result error, got %v", err)
+	}
+}
+
+func Test_QueryTyped(t *testing.T) {
+	type person struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.URL.Query()["associative"]; !ok {
+			t.Errorf("expected associative query parameter, got %s", r.URL.RawQuery)
+		}
+		b, _ := io.ReadAll(r.Body)
+		if exp := `[["SELECT * FROM foo WHERE active = ?",1]]`; string(b) != exp {
+			t.Errorf("expected body %s, got %s", exp, b)
+		}
+		w.Write([]byte(`{"results": [{"types": {"id": "integer", "name": "text"}, "rows": [{"id": 1, "name": "fiona"}, {"id": 2, "name": "declan"}]}]}`))
+	}))
+	defer ts.Close()
+	client, err := NewClient(ts.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to create client: %s", err)
+	}
+	defer client.Close()
+
+	people, err := QueryTyped[person](context.Background(), client, "SELECT * FROM foo WHERE active = ?", 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exp := []person{{1, "fiona"}, {2, "declan"}}; !reflect.DeepEqual(exp, people) {
+		t.Fatalf("unexpected rows\nwant: %+v\ngot:  %+v", exp, people)
+	}
+}
@@ -0,0 +1,240 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ColumnInfo describes a column of a table.
+type ColumnInfo struct {
+	Name       string
+	Type       string
+	NotNull    bool
+	PrimaryKey bool
+}
+
+// TableInfo describes a table, and its columns in the order they are defined.
+type TableInfo struct {
+	Name    string
+	Columns []ColumnInfo
+}
+
+// SchemaChangeKind identifies the type of a SchemaChange.
+type SchemaChangeKind int
+
+const (
+	// TableAdded indicates a table is present only in the second schema.
+	TableAdded SchemaChangeKind = iota
+
+	// TableRemoved indicates a table is present only in the first schema.
+	TableRemoved
+
+	// ColumnAdded indicates a column is present only in the second schema.
+	ColumnAdded
+
+	// ColumnRemoved indicates a column is present only in the first schema.
+	ColumnRemoved
+
+	// ColumnChanged indicates a column is present in both schemas, but its
+	// definition differs.
+	ColumnChanged
+)
+
+// String returns the string representation of a SchemaChangeKind.
+func (k SchemaChangeKind) String() string {
+	switch k {
+	case TableAdded:
+		return "table added"
+	case TableRemoved:
+		return "table removed"
+	case ColumnAdded:
+		return "column added"
+	case ColumnRemoved:
+		return "column removed"
+	case ColumnChanged:
+		return "column changed"
+	default:
+		return "unknown"
+	}
+}
+
+// SchemaChange is a single difference between two schemas.
+type SchemaChange struct {
+	Kind  SchemaChangeKind
+	Table string
+
+	// Column is the name of the column, and is empty for table-level changes.
+	Column string
+
+	// From and To are the definitions of a changed column in the first and
+	// second schemas respectively. They are only set if Kind is ColumnChanged.
+	From ColumnInfo
+	To   ColumnInfo
+}
+
+// String returns a human-readable description of the change.
+func (sc SchemaChange) String() string {
+	if sc.Column == "" {
+		return fmt.Sprintf("%s: %s", sc.Kind, sc.Table)
+	}
+	return fmt.Sprintf("%s: %s.%s", sc.Kind, sc.Table, sc.Column)
+}
+
+// SchemaDiff compares two schemas, returning the changes required to go from a to b.
+// Changes are ordered by table name, and then by column order within each table.
+func SchemaDiff(a, b []TableInfo) []SchemaChange {
+	aTables := make(map[string]TableInfo, len(a))
+	for _, t := range a {
+		aTables[t.Name] = t
+	}
+	bTables := make(map[string]TableInfo, len(b))
+	for _, t := range b {
+		bTables[t.Name] = t
+	}
+
+	var names []string
+	for name := range aTables {
+		names = append(names, name)
+	}
+	for name := range bTables {
+		if _, ok := aTables[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	var changes []SchemaChange
+	for _, name := range names {
+		at, inA := aTables[name]
+		bt, inB := bTables[name]
+		switch {
+		case !inA:
+			changes = append(changes, SchemaChange{Kind: TableAdded, Table: name})
+		case !inB:
+			changes = append(changes, SchemaChange{Kind: TableRemoved, Table: name})
+		default:
+			changes = append(changes, diffColumns(name, at.Columns, bt.Columns)...)
+		}
+	}
+	return changes
+}
+
+func diffColumns(table string, a, b []ColumnInfo) []SchemaChange {
+	var changes []SchemaChange
+	for _, ac := range a {
+		i := slices.IndexFunc(b, func(c ColumnInfo) bool { return c.Name == ac.Name })
+		if i == -1 {
+			changes = append(changes, SchemaChange{Kind: ColumnRemoved, Table: table, Column: ac.Name})
+			continue
+		}
+		if bc := b[i]; !strings.EqualFold(ac.Type, bc.Type) || ac.NotNull != bc.NotNull || ac.PrimaryKey != bc.PrimaryKey {
+			changes = append(changes, SchemaChange{Kind: ColumnChanged, Table: table, Column: ac.Name, From: ac, To: bc})
+		}
+	}
+	for _, bc := range b {
+		if !slices.ContainsFunc(a, func(c ColumnInfo) bool { return c.Name == bc.Name }) {
+			changes = append(changes, SchemaChange{Kind: ColumnAdded, Table: table, Column: bc.Name})
+		}
+	}
+	return changes
+}
+
+// Schema returns the tables in the database, ordered by name, excluding SQLite's
+// internal tables.
+func (c *Client) Schema(ctx context.Context) ([]TableInfo, error) {
+	stmt := &SQLStatement{SQL: `SELECT m.name, p.name, p.type, p."notnull", p.pk
+		FROM sqlite_master AS m JOIN pragma_table_info(m.name) AS p
+		WHERE m.type = 'table' AND m.name NOT LIKE 'sqlite_%'
+		ORDER BY m.name, p.cid`}
+	qr, err := c.Query(ctx, SQLStatements{stmt}, c.plainQueryOptions(nil))
+	if err != nil {
+		return nil, err
+	}
+	if f, _, msg := qr.HasError(); f {
+		return nil, errors.New(msg)
+	}
+	results, ok := qr.Results.([]QueryResult)
+	if !ok || len(results) != 1 {
+		return nil, fmt.Errorf("unexpected results reading schema")
+	}
+
+	var tables []TableInfo
+	for _, row := range results[0].Values {
+		if len(row) != 5 {
+			return nil, fmt.Errorf("unexpected row reading schema: %v", row)
+		}
+		table, _ := row[0].(string)
+		col := ColumnInfo{
+			NotNull:    isNonZeroNumber(row[3]),
+			PrimaryKey: isNonZeroNumber(row[4]),
+		}
+		col.Name, _ = row[1].(string)
+		col.Type, _ = row[2].(string)
+
+		if len(tables) == 0 || tables[len(tables)-1].Name != table {
+			tables = append(tables, TableInfo{Name: table})
+		}
+		tables[len(tables)-1].Columns = append(tables[len(tables)-1].Columns, col)
+	}
+	return tables, nil
+}
+
+// QueryColumns returns the names and types of the columns returned by a query,
+// without returning any rows. Only the Name and Type fields of each ColumnInfo
+// are set.
+func (c *Client) QueryColumns(ctx context.Context, statement string, args ...any) ([]ColumnInfo, error) {
+	sql := fmt.Sprintf("SELECT * FROM (%s) LIMIT 0", strings.TrimRight(strings.TrimSpace(statement), ";"))
+	stmt, err := NewSQLStatement(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	qr, err := c.Query(ctx, SQLStatements{stmt}, c.plainQueryOptions(nil))
+	if err != nil {
+		return nil, err
+	}
+	if f, _, msg := qr.HasError(); f {
+		return nil, errors.New(msg)
+	}
+	results, ok := qr.Results.([]QueryResult)
+	if !ok || len(results) != 1 {
+		return nil, fmt.Errorf("unexpected results reading columns")
+	}
+
+	cols := make([]ColumnInfo, len(results[0].Columns))
+	for i, name := range results[0].Columns {
+		cols[i].Name = name
+		if i < len(results[0].Types) {
+			cols[i].Type = results[0].Types[i]
+		}
+	}
+	return cols, nil
+}
+
+// ValidateSQL checks that sql is a valid statement for the database, without executing
+// it. The statement is compiled by the node using EXPLAIN, so syntax errors, and
+// references to tables or columns which do not exist, are reported. A nil error is
+// returned if the statement is valid.
+//
+// As the statement is not executed, errors which only arise when a write is run, such
+// as constraint violations, are not detected. A statement which depends on an earlier
+// statement that has not yet been executed, such as an INSERT into a table not yet
+// created, is reported as invalid. Only a single statement should be passed.
+func (c *Client) ValidateSQL(ctx context.Context, sql string) error {
+	qr, err := c.QuerySingle(ctx, "EXPLAIN "+strings.TrimSpace(sql))
+	if err != nil {
+		return err
+	}
+	if f, _, msg := qr.HasError(); f {
+		return errors.New(msg)
+	}
+	return nil
+}
+
+func isNonZeroNumber(v any) bool {
+	n, ok := v.(json.Number)
+	return ok && n.String() != "0"
+}
@@ -0,0 +1,174 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func Test_SchemaDiff(t *testing.T) {
+	a := []TableInfo{
+		{
+			Name: "foo",
+			Columns: []ColumnInfo{
+				{Name: "id", Type: "INTEGER", PrimaryKey: true},
+				{Name: "name", Type: "TEXT"},
+			},
+		},
+		{
+			Name: "bar",
+			Columns: []ColumnInfo{
+				{Name: "id", Type: "INTEGER", PrimaryKey: true},
+			},
+		},
+	}
+	b := []TableInfo{
+		{
+			Name: "foo",
+			Columns: []ColumnInfo{
+				{Name: "id", Type: "INTEGER", PrimaryKey: true},
+				{Name: "name", Type: "TEXT", NotNull: true},
+				{Name: "age", Type: "INTEGER"},
+			},
+		},
+	}
+
+	exp := []SchemaChange{
+		{Kind: TableRemoved, Table: "bar"},
+		{
+			Kind:   ColumnChanged,
+			Table:  "foo",
+			Column: "name",
+			From:   ColumnInfo{Name: "name", Type: "TEXT"},
+			To:     ColumnInfo{Name: "name", Type: "TEXT", NotNull: true},
+		},
+		{Kind: ColumnAdded, Table: "foo", Column: "age"},
+	}
+	if got := SchemaDiff(a, b); !reflect.DeepEqual(exp, got) {
+		t.Fatalf("unexpected diff\nwant: %v\ngot:  %v", exp, got)
+	}
+
+	exp = []SchemaChange{
+		{Kind: TableAdded, Table: "bar"},
+		{
+			Kind:   ColumnChanged,
+			Table:  "foo",
+			Column: "name",
+			From:   ColumnInfo{Name: "name", Type: "TEXT", NotNull: true},
+			To:     ColumnInfo{Name: "name", Type: "TEXT"},
+		},
+		{Kind: ColumnRemoved, Table: "foo", Column: "age"},
+	}
+	if got := SchemaDiff(b, a); !reflect.DeepEqual(exp, got) {
+		t.Fatalf("unexpected reverse diff\nwant: %v\ngot:  %v", exp, got)
+	}
+
+	if got := SchemaDiff(a, a); len(got) != 0 {
+		t.Fatalf("expected no changes for identical schemas, got %v", got)
+	}
+}
+
+func Test_Schema(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/db/query" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"results": [{"columns": ["name", "name", "type", "notnull", "pk"], "values": [
+			["bar", "id", "INTEGER", 0, 1],
+			["foo", "id", "INTEGER", 0, 1],
+			["foo", "name", "TEXT", 1, 0]
+		]}]}`))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	tables, err := cl.Schema(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error calling Schema: %v", err)
+	}
+	exp := []TableInfo{
+		{Name: "bar", Columns: []ColumnInfo{{Name: "id", Type: "INTEGER", PrimaryKey: true}}},
+		{Name: "foo", Columns: []ColumnInfo{
+			{Name: "id", Type: "INTEGER", PrimaryKey: true},
+			{Name: "name", Type: "TEXT", NotNull: true},
+		}},
+	}
+	if !reflect.DeepEqual(exp, tables) {
+		t.Fatalf("unexpected schema\nwant: %+v\ngot:  %+v", exp, tables)
+	}
+}
+
+func Test_QueryColumns(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var stmts SQLStatements
+		if err := json.NewDecoder(r.Body).Decode(&stmts); err != nil {
+			t.Fatalf("unexpected error decoding body: %v", err)
+		}
+		if exp, got := "SELECT * FROM (SELECT id, name FROM foo WHERE id > ?) LIMIT 0", stmts[0].SQL; exp != got {
+			t.Fatalf("expected statement %s, got %s", exp, got)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"results": [{"columns": ["id", "name"], "types": ["integer", "text"]}]}`))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	cols, err := cl.QueryColumns(context.Background(), "SELECT id, name FROM foo WHERE id > ?;", 5)
+	if err != nil {
+		t.Fatalf("unexpected error calling QueryColumns: %v", err)
+	}
+	exp := []ColumnInfo{{Name: "id", Type: "integer"}, {Name: "name", Type: "text"}}
+	if !reflect.DeepEqual(exp, cols) {
+		t.Fatalf("unexpected columns\nwant: %+v\ngot:  %+v", exp, cols)
+	}
+}
+
+func Test_ValidateSQL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/db/query" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		var stmts SQLStatements
+		if err := json.NewDecoder(r.Body).Decode(&stmts); err != nil {
+			t.Errorf("unexpected error decoding body: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+		switch stmts[0].SQL {
+		case "EXPLAIN INSERT INTO foo(name) VALUES('fiona')":
+			w.Write([]byte(`{"results": [{"columns": ["addr", "opcode"], "types": ["integer", "text"], "values": [[0, "Init"]]}]}`))
+		case "EXPLAIN INSERT INTO foo(name) VALUS('fiona')":
+			w.Write([]byte(`{"results": [{"error": "near \"VALUS\": syntax error"}]}`))
+		default:
+			t.Errorf("unexpected statement: %s", stmts[0].SQL)
+		}
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	if err := cl.ValidateSQL(context.Background(), " INSERT INTO foo(name) VALUES('fiona')"); err != nil {
+		t.Fatalf("expected valid SQL, got %v", err)
+	}
+	err = cl.ValidateSQL(context.Background(), "INSERT INTO foo(name) VALUS('fiona')")
+	if err == nil || err.Error() != `near "VALUS": syntax error` {
+		t.Fatalf("expected syntax error, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// flightGroup deduplicates identical concurrent queries, so that only one request
+// is in flight for each distinct query at a time.
+type flightGroup struct {
+	mu sync.Mutex
+	m  map[string]*flightCall
+}
+
+// flightCall is a query in flight, whose result is shared by every caller which
+// made the same query while it was in flight.
+type flightCall struct {
+	done chan struct{}
+	dups int
+	qr   *QueryResponse
+	err  error
+}
+
+// do calls fn and returns its result, unless a call with the same key is already in
+// flight, in which case it waits for, and returns, the result of that call. A caller
+// waiting for another's call stops waiting if ctx is done.
+func (g *flightGroup) do(ctx context.Context, key string, fn func() (*QueryResponse, error)) (*QueryResponse, error) {
+	g.mu.Lock()
+	if g.m == nil {
+		g.m = make(map[string]*flightCall)
+	}
+	if call, ok := g.m[key]; ok {
+		call.dups++
+		g.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.qr, call.err
+		case <-ctx.Done():
+			return nil, contextError(ctx, ctx.Err())
+		}
+	}
+	call := &flightCall{done: make(chan struct{})}
+	g.m[key] = call
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.m, key)
+		g.mu.Unlock()
+		close(call.done)
+	}()
+	call.qr, call.err = fn()
+	return call.qr, call.err
+}
+
+// EnableSingleflight enables or disables deduplication of identical concurrent
+// queries. By default it is disabled.
+//
+// If enabled, a call to Query made while an identical query is in flight does not
+// send a request to the node. Queries are identical if they have the same statements,
+// parameters, and options, and their contexts carry the same headers and credentials,
+// as attached with WithHeaders and WithCredentials. Instead the call waits for the
+// query in flight to complete, and returns the same response and error. As the response is shared between callers, it must not be modified. The
+// query in flight is bound by the context of the call which sent it, so if that
+// context is canceled, every caller sharing the query receives the resulting error.
+// A caller waiting for the query in flight stops waiting if its own context is done.
+// If SetTimeoutFromContext is enabled, the timeout sent to the node is derived from
+// the deadline of the call which sent the query, and applies to every caller sharing
+// it.
+func (c *Client) EnableSingleflight(b bool) {
+	c.singleflight.Store(b)
+}
+
+// flightKey returns the key identifying a query for deduplication, and whether the
+// query can be deduplicated. Queries are only deduplicated if they are made with the
+// same headers and credentials attached to ctx, so that a response is never shared
+// with a caller which may not be authorized to see it.
+func (c *Client) flightKey(ctx context.Context, statements SQLStatements, opts *QueryOptions) (string, bool) {
+	b, err := json.Marshal(statements)
+	if err != nil {
+		return "", false
+	}
+	values, err := c.makeURLValues(opts)
+	if err != nil {
+		return "", false
+	}
+	d, err := statements.timeout()
+	if err != nil {
+		return "", false
+	}
+	return contextDigest(ctx) + "\n" + d.String() + "\n" + values.Encode() + "\n" + string(b), true
+}
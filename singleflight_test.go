@@ -0,0 +1,192 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_EnableSingleflight(t *testing.T) {
+	const n = 20
+	var hits atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"results": [{"columns": ["COUNT(*)"], "types": ["integer"], "values": [[3]]}]}`))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+	cl.EnableSingleflight(true)
+
+	stmts := SQLStatements{{SQL: "SELECT COUNT(*) FROM foo WHERE age > ?", PositionalParams: []any{20}}}
+	responses := make([]*QueryResponse, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			qr, err := cl.Query(context.Background(), stmts, nil)
+			if err != nil {
+				t.Errorf("unexpected error calling Query: %v", err)
+			}
+			responses[i] = qr
+		}()
+	}
+
+	// Only release the server once every other query is waiting on the first.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		cl.flights.mu.Lock()
+		var dups int
+		for _, call := range cl.flights.m {
+			dups += call.dups
+		}
+		cl.flights.mu.Unlock()
+		if dups == n-1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for queries to join, got %d", dups)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if exp, got := int32(1), hits.Load(); exp != got {
+		t.Fatalf("expected %d request to reach server, got %d", exp, got)
+	}
+	for i, qr := range responses {
+		if qr != responses[0] {
+			t.Fatalf("expected response %d to be shared", i)
+		}
+	}
+
+	// A later query, or one with different options, is sent to the server.
+	if _, err := cl.Query(context.Background(), stmts, nil); err != nil {
+		t.Fatalf("unexpected error calling Query: %v", err)
+	}
+	if _, err := cl.Query(context.Background(), stmts, &QueryOptions{Level: ReadConsistencyLevelStrong}); err != nil {
+		t.Fatalf("unexpected error calling Query: %v", err)
+	}
+	if exp, got := int32(3), hits.Load(); exp != got {
+		t.Fatalf("expected %d requests to reach server, got %d", exp, got)
+	}
+}
+
+func Test_EnableSingleflight_WaiterContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte(`{"results": []}`))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+	cl.EnableSingleflight(true)
+
+	stmts := SQLStatements{{SQL: "SELECT * FROM foo"}}
+	go cl.Query(context.Background(), stmts, nil)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		cl.flights.mu.Lock()
+		n := len(cl.flights.m)
+		cl.flights.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for query to be in flight")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := cl.Query(ctx, stmts, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded error, got %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("expected waiting caller to return at its deadline, took %s", d)
+	}
+}
+
+func Test_EnableSingleflight_Credentials(t *testing.T) {
+	var hits atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		user, _, _ := r.BasicAuth()
+		fmt.Fprintf(w, `{"results": [{"columns": ["owner"], "types": ["text"], "values": [[%q]]}]}`, user)
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+	cl.EnableSingleflight(true)
+
+	stmts := SQLStatements{{SQL: "SELECT owner FROM secrets"}}
+	users := []string{"alice", "bob"}
+	owners := make([]any, len(users))
+	var wg sync.WaitGroup
+	for i, user := range users {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx := WithCredentials(context.Background(), user, "secret")
+			v, err := cl.queryScalar(ctx, stmts[0], nil)
+			if err != nil {
+				t.Errorf("unexpected error calling Query: %v", err)
+			}
+			owners[i] = v
+		}()
+	}
+
+	// Only release the server once both queries are in flight separately.
+	deadline := time.Now().Add(5 * time.Second)
+	for hits.Load() != int32(len(users)) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for queries, got %d requests", hits.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	for i, user := range users {
+		if owners[i] != user {
+			t.Fatalf("expected %s to receive own results, got %v", user, owners[i])
+		}
+	}
+	if contextDigest(context.Background()) != "" {
+		t.Fatalf("expected empty digest for context without headers or credentials")
+	}
+	h1 := WithHeaders(context.Background(), http.Header{"X-Tenant": {"a"}})
+	h2 := WithHeaders(context.Background(), http.Header{"X-Tenant": {"b"}})
+	if contextDigest(h1) == contextDigest(h2) {
+		t.Fatalf("expected different digests for different headers")
+	}
+}
@@ -0,0 +1,422 @@
+// Package sqldriver provides a database/sql driver for rqlite, backed by the rqlite
+// HTTP client. Importing the package registers the driver under the name "rqlite",
+// with the data source name being the URL of a node:
+//
+//	db, err := sql.Open("rqlite", "http://localhost:4001")
+//
+// To use a client configured with options such as a load balancer or Basic Auth,
+// pass a Connector created by NewConnector to sql.OpenDB instead.
+//
+// Each statement is sent to rqlite as a separate HTTP request. A transaction is
+// emulated by buffering the statements passed to Exec, and sending them to the node
+// as a single request, with the transaction option set, when the transaction is
+// committed. As a consequence, the Result of an Exec within a transaction is only
+// available once the transaction has been committed, and a query within a
+// transaction does not observe the transaction's uncommitted writes.
+package sqldriver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	rqlitehttp "github.com/rqlite/rqlite-go-http"
+)
+
+func init() {
+	sql.Register("rqlite", &Driver{})
+}
+
+// Driver is the rqlite database/sql driver.
+type Driver struct{}
+
+// Open returns a new connection to the node at the URL name.
+func (d *Driver) Open(name string) (driver.Conn, error) {
+	c, err := d.OpenConnector(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.Connect(context.Background())
+}
+
+// OpenConnector returns a Connector for the node at the URL name.
+func (d *Driver) OpenConnector(name string) (driver.Connector, error) {
+	client, err := rqlitehttp.NewClient(name)
+	if err != nil {
+		return nil, err
+	}
+	return &connector{client: client, driver: d}, nil
+}
+
+// NewConnector returns a Connector which uses client for every connection, for use
+// with sql.OpenDB. The client is not closed when the sql.DB is closed.
+func NewConnector(client *rqlitehttp.Client) driver.Connector {
+	return &connector{client: client, driver: &Driver{}}
+}
+
+type connector struct {
+	client *rqlitehttp.Client
+	driver *Driver
+}
+
+// Connect implements driver.Connector.
+func (c *connector) Connect(context.Context) (driver.Conn, error) {
+	return &conn{client: c.client}, nil
+}
+
+// Driver implements driver.Connector.
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
+
+// conn is a connection to rqlite. As the HTTP client is stateless, a conn holds no
+// state other than any transaction in progress.
+type conn struct {
+	client *rqlitehttp.Client
+	tx     *tx
+}
+
+// Prepare implements driver.Conn.
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// PrepareContext implements driver.ConnPrepareContext. rqlite does not support
+// prepared statements, so the statement is sent in full each time it is executed.
+func (c *conn) PrepareContext(_ context.Context, query string) (driver.Stmt, error) {
+	return &stmt{conn: c, query: query}, nil
+}
+
+// Close implements driver.Conn.
+func (c *conn) Close() error {
+	c.tx = nil
+	return nil
+}
+
+// Begin implements driver.Conn.
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx implements driver.ConnBeginTx.
+func (c *conn) BeginTx(_ context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if c.tx != nil {
+		return nil, errors.New("transaction already in progress")
+	}
+	if sql.IsolationLevel(opts.Isolation) != sql.LevelDefault && sql.IsolationLevel(opts.Isolation) != sql.LevelSerializable {
+		return nil, fmt.Errorf("unsupported isolation level: %s", sql.IsolationLevel(opts.Isolation))
+	}
+	c.tx = &tx{conn: c}
+	return c.tx, nil
+}
+
+// Ping implements driver.Pinger.
+func (c *conn) Ping(ctx context.Context) error {
+	_, err := c.client.Status(ctx)
+	return err
+}
+
+// ExecContext implements driver.ExecerContext.
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	st, err := newStatement(query, args)
+	if err != nil {
+		return nil, err
+	}
+	if c.tx != nil {
+		return c.tx.add(st), nil
+	}
+
+	er, err := c.client.Execute(ctx, rqlitehttp.SQLStatements{st}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if f, _, msg := er.HasError(); f {
+		return nil, errors.New(msg)
+	}
+	if len(er.Results) != 1 {
+		return nil, fmt.Errorf("expected 1 result, got %d", len(er.Results))
+	}
+	return &result{lastInsertID: er.Results[0].LastInsertID, rowsAffected: er.Results[0].RowsAffected}, nil
+}
+
+// QueryContext implements driver.QueryerContext.
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	st, err := newStatement(query, args)
+	if err != nil {
+		return nil, err
+	}
+	// The client's default options are used, but results are always requested in
+	// the default form, which rows decodes.
+	opts := c.client.DefaultQueryOptions()
+	opts.Associative = false
+	opts.NoDefaults = true
+	qr, err := c.client.Query(ctx, rqlitehttp.SQLStatements{st}, &opts)
+	if err != nil {
+		return nil, err
+	}
+	if f, _, msg := qr.HasError(); f {
+		return nil, errors.New(msg)
+	}
+	results, ok := qr.Results.([]rqlitehttp.QueryResult)
+	if !ok || len(results) != 1 {
+		return nil, errors.New("unexpected query results")
+	}
+	return &rows{result: results[0]}, nil
+}
+
+// CheckNamedValue implements driver.NamedValueChecker, accepting any value the
+// default converter accepts, and time.Time values, which are sent as RFC 3339 text.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if t, ok := nv.Value.(time.Time); ok {
+		nv.Value = t.Format(time.RFC3339Nano)
+		return nil
+	}
+	v, err := driver.DefaultParameterConverter.ConvertValue(nv.Value)
+	if err != nil {
+		return err
+	}
+	nv.Value = v
+	return nil
+}
+
+// newStatement returns a statement for query with the given arguments. Arguments must
+// be either all named or all positional.
+func newStatement(query string, args []driver.NamedValue) (*rqlitehttp.SQLStatement, error) {
+	st := &rqlitehttp.SQLStatement{SQL: query}
+	for _, arg := range args {
+		if arg.Name != "" {
+			if st.NamedParams == nil {
+				st.NamedParams = make(map[string]any, len(args))
+			}
+			st.NamedParams[arg.Name] = arg.Value
+		} else {
+			st.PositionalParams = append(st.PositionalParams, arg.Value)
+		}
+	}
+	if st.NamedParams != nil && st.PositionalParams != nil {
+		return nil, errors.New("named and positional arguments must not be mixed")
+	}
+	return st, nil
+}
+
+// stmt is a prepared statement.
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+// Close implements driver.Stmt.
+func (s *stmt) Close() error { return nil }
+
+// NumInput implements driver.Stmt. The number of placeholders is not known, so
+// argument counts are not checked by database/sql.
+func (s *stmt) NumInput() int { return -1 }
+
+// Exec implements driver.Stmt.
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+// ExecContext implements driver.StmtExecContext.
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+// Query implements driver.Stmt.
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+// QueryContext implements driver.StmtQueryContext.
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	nvs := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		nvs[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return nvs
+}
+
+// tx is a transaction, whose statements are buffered until it is committed.
+type tx struct {
+	conn    *conn
+	stmts   rqlitehttp.SQLStatements
+	results []*txResult
+}
+
+// add buffers st, returning the Result which is set once the transaction commits.
+func (t *tx) add(st *rqlitehttp.SQLStatement) *txResult {
+	r := &txResult{}
+	t.stmts = append(t.stmts, st)
+	t.results = append(t.results, r)
+	return r
+}
+
+// Commit implements driver.Tx, sending the buffered statements to the node as a
+// single request executed within a transaction.
+func (t *tx) Commit() error {
+	t.conn.tx = nil
+	if len(t.stmts) == 0 {
+		return nil
+	}
+	rr, err := t.conn.client.Request(context.Background(), t.stmts, &rqlitehttp.RequestOptions{Transaction: true})
+	if err != nil {
+		return err
+	}
+	if f, _, msg := rr.HasError(); f {
+		return errors.New(msg)
+	}
+	ers := rr.ExecuteResults()
+	if len(ers) != len(t.results) {
+		return fmt.Errorf("expected %d results, got %d", len(t.results), len(ers))
+	}
+	for i, er := range ers {
+		t.results[i].result = &result{lastInsertID: er.LastInsertID, rowsAffected: er.RowsAffected}
+	}
+	return nil
+}
+
+// Rollback implements driver.Tx, discarding the buffered statements.
+func (t *tx) Rollback() error {
+	t.conn.tx = nil
+	return nil
+}
+
+// errNotCommitted is returned by the Result of an Exec within a transaction which has
+// not been committed.
+var errNotCommitted = errors.New("result not available until transaction is committed")
+
+// txResult is the Result of an Exec within a transaction.
+type txResult struct {
+	result *result
+}
+
+// LastInsertId implements driver.Result.
+func (r *txResult) LastInsertId() (int64, error) {
+	if r.result == nil {
+		return 0, errNotCommitted
+	}
+	return r.result.LastInsertId()
+}
+
+// RowsAffected implements driver.Result.
+func (r *txResult) RowsAffected() (int64, error) {
+	if r.result == nil {
+		return 0, errNotCommitted
+	}
+	return r.result.RowsAffected()
+}
+
+type result struct {
+	lastInsertID int64
+	rowsAffected int64
+}
+
+// LastInsertId implements driver.Result.
+func (r *result) LastInsertId() (int64, error) { return r.lastInsertID, nil }
+
+// RowsAffected implements driver.Result.
+func (r *result) RowsAffected() (int64, error) { return r.rowsAffected, nil }
+
+// rows iterates over the rows of a query result.
+type rows struct {
+	result rqlitehttp.QueryResult
+	next   int
+}
+
+// Columns implements driver.Rows.
+func (r *rows) Columns() []string {
+	return r.result.Columns
+}
+
+// Close implements driver.Rows.
+func (r *rows) Close() error {
+	r.next = len(r.result.Values)
+	return nil
+}
+
+// ColumnTypeDatabaseTypeName implements driver.RowsColumnTypeDatabaseTypeName.
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	if index < len(r.result.Types) {
+		return strings.ToUpper(r.result.Types[index])
+	}
+	return ""
+}
+
+// Next implements driver.Rows.
+func (r *rows) Next(dest []driver.Value) error {
+	if r.next >= len(r.result.Values) {
+		return io.EOF
+	}
+	vals := r.result.Values[r.next]
+	r.next++
+	for i := range dest {
+		if i >= len(vals) {
+			dest[i] = nil
+			continue
+		}
+		v, err := convertValue(vals[i], r.ColumnTypeDatabaseTypeName(i))
+		if err != nil {
+			return fmt.Errorf("column %d: %w", i, err)
+		}
+		dest[i] = v
+	}
+	return nil
+}
+
+// convertValue converts a value decoded from a query result to a driver.Value, using
+// the declared type of its column.
+func convertValue(v any, typ string) (driver.Value, error) {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		return v.Float64()
+	case string:
+		if typ == "BLOB" {
+			return base64.StdEncoding.DecodeString(v)
+		}
+		return v, nil
+	case []any:
+		b := make([]byte, len(v))
+		for i, e := range v {
+			n, ok := e.(json.Number)
+			if !ok {
+				return nil, fmt.Errorf("unexpected blob element %v", e)
+			}
+			x, err := n.Int64()
+			if err != nil {
+				return nil, err
+			}
+			b[i] = byte(x)
+		}
+		return b, nil
+	case nil, bool, int64, float64, []byte:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("unsupported value %v of type %T", v, v)
+	}
+}
+
+var (
+	_ driver.DriverContext      = (*Driver)(nil)
+	_ driver.ConnPrepareContext = (*conn)(nil)
+	_ driver.ConnBeginTx        = (*conn)(nil)
+	_ driver.ExecerContext      = (*conn)(nil)
+	_ driver.QueryerContext     = (*conn)(nil)
+	_ driver.Pinger             = (*conn)(nil)
+	_ driver.NamedValueChecker  = (*conn)(nil)
+	_ driver.StmtExecContext    = (*stmt)(nil)
+	_ driver.StmtQueryContext   = (*stmt)(nil)
+)
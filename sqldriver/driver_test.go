@@ -0,0 +1,241 @@
+package sqldriver
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	rqlitehttp "github.com/rqlite/rqlite-go-http"
+)
+
+// requestRecorder records the statements sent to a test server, and its paths.
+type requestRecorder struct {
+	paths  []string
+	stmts  []rqlitehttp.SQLStatements
+	values []string
+}
+
+func newTestServer(t *testing.T, rec *requestRecorder, responses map[string]string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec.paths = append(rec.paths, r.URL.Path)
+		rec.values = append(rec.values, r.URL.RawQuery)
+		if r.Method == http.MethodPost {
+			var stmts rqlitehttp.SQLStatements
+			if err := json.NewDecoder(r.Body).Decode(&stmts); err != nil {
+				t.Errorf("unexpected error decoding body: %v", err)
+			}
+			rec.stmts = append(rec.stmts, stmts)
+		}
+		resp, ok := responses[r.URL.Path]
+		if !ok {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(resp))
+	}))
+}
+
+func Test_ExecAndQuery(t *testing.T) {
+	rec := &requestRecorder{}
+	server := newTestServer(t, rec, map[string]string{
+		"/db/execute": `{"results": [{"last_insert_id": 7, "rows_affected": 1}]}`,
+		"/db/query": `{"results": [{
+			"columns": ["id", "name", "score", "data", "deleted"],
+			"types": ["integer", "text", "real", "blob", "integer"],
+			"values": [[1, "fiona", 1.5, "AQID", null], [2, "declan", 2, "", null]]
+		}]}`,
+		"/status": `{}`,
+	})
+	defer server.Close()
+
+	db, err := sql.Open("rqlite", server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error from sql.Open: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Ping(); err != nil {
+		t.Fatalf("unexpected error from Ping: %v", err)
+	}
+
+	res, err := db.Exec("INSERT INTO foo(name) VALUES(?)", "fiona")
+	if err != nil {
+		t.Fatalf("unexpected error from Exec: %v", err)
+	}
+	if id, err := res.LastInsertId(); err != nil || id != 7 {
+		t.Fatalf("expected last insert ID 7, got %d (%v)", id, err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		t.Fatalf("expected 1 row affected, got %d (%v)", n, err)
+	}
+	exp := rqlitehttp.SQLStatements{{SQL: "INSERT INTO foo(name) VALUES(?)", PositionalParams: []any{"fiona"}}}
+	if got := rec.stmts[len(rec.stmts)-1]; !reflect.DeepEqual(exp, got) {
+		t.Fatalf("expected statements %v, got %v", exp, got)
+	}
+
+	rows, err := db.Query("SELECT * FROM foo WHERE name = :name", sql.Named("name", "fiona"))
+	if err != nil {
+		t.Fatalf("unexpected error from Query: %v", err)
+	}
+	defer rows.Close()
+	exp = rqlitehttp.SQLStatements{{SQL: "SELECT * FROM foo WHERE name = :name", NamedParams: map[string]any{"name": "fiona"}}}
+	if got := rec.stmts[len(rec.stmts)-1]; !reflect.DeepEqual(exp, got) {
+		t.Fatalf("expected statements %v, got %v", exp, got)
+	}
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		t.Fatalf("unexpected error from ColumnTypes: %v", err)
+	}
+	if exp, got := "BLOB", types[3].DatabaseTypeName(); exp != got {
+		t.Fatalf("expected type %s, got %s", exp, got)
+	}
+
+	type row struct {
+		id      int64
+		name    string
+		score   float64
+		data    []byte
+		deleted sql.NullInt64
+	}
+	var got []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.id, &r.name, &r.score, &r.data, &r.deleted); err != nil {
+			t.Fatalf("unexpected error from Scan: %v", err)
+		}
+		got = append(got, r)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("unexpected error iterating rows: %v", err)
+	}
+	if len(got) != 2 || got[0].id != 1 || got[0].name != "fiona" || got[0].score != 1.5 ||
+		!bytes.Equal(got[0].data, []byte{1, 2, 3}) || got[0].deleted.Valid || got[1].score != 2 {
+		t.Fatalf("unexpected rows: %+v", got)
+	}
+}
+
+func Test_ExecError(t *testing.T) {
+	rec := &requestRecorder{}
+	server := newTestServer(t, rec, map[string]string{
+		"/db/execute": `{"results": [{"error": "no such table: foo"}]}`,
+	})
+	defer server.Close()
+
+	client, err := rqlitehttp.NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer client.Close()
+	db := sql.OpenDB(NewConnector(client))
+	defer db.Close()
+
+	if _, err := db.Exec("DELETE FROM foo"); err == nil || err.Error() != "no such table: foo" {
+		t.Fatalf("expected statement error, got %v", err)
+	}
+}
+
+func Test_Tx(t *testing.T) {
+	rec := &requestRecorder{}
+	server := newTestServer(t, rec, map[string]string{
+		"/db/request": `{"results": [{"last_insert_id": 1, "rows_affected": 1}, {"last_insert_id": 1, "rows_affected": 2}]}`,
+	})
+	defer server.Close()
+
+	client, err := rqlitehttp.NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer client.Close()
+	db := sql.OpenDB(NewConnector(client))
+	defer db.Close()
+
+	tx, err := db.BeginTx(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error from BeginTx: %v", err)
+	}
+	if _, err := tx.Exec("INSERT INTO foo(name) VALUES(?)", "fiona"); err != nil {
+		t.Fatalf("unexpected error from Exec: %v", err)
+	}
+	res, err := tx.Exec("UPDATE foo SET name = ?", "declan")
+	if err != nil {
+		t.Fatalf("unexpected error from Exec: %v", err)
+	}
+	if _, err := res.RowsAffected(); err != errNotCommitted {
+		t.Fatalf("expected errNotCommitted before commit, got %v", err)
+	}
+	if len(rec.paths) != 0 {
+		t.Fatalf("expected no requests before commit, got %v", rec.paths)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("unexpected error from Commit: %v", err)
+	}
+
+	if exp, got := []string{"/db/request"}, rec.paths; !reflect.DeepEqual(exp, got) {
+		t.Fatalf("expected paths %v, got %v", exp, got)
+	}
+	if exp, got := "transaction=true", rec.values[0]; exp != got {
+		t.Fatalf("expected query %s, got %s", exp, got)
+	}
+	exp := rqlitehttp.SQLStatements{
+		{SQL: "INSERT INTO foo(name) VALUES(?)", PositionalParams: []any{"fiona"}},
+		{SQL: "UPDATE foo SET name = ?", PositionalParams: []any{"declan"}},
+	}
+	if !reflect.DeepEqual(exp, rec.stmts[0]) {
+		t.Fatalf("expected statements %v, got %v", exp, rec.stmts[0])
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 2 {
+		t.Fatalf("expected 2 rows affected after commit, got %d (%v)", n, err)
+	}
+
+	// A rolled back transaction sends nothing.
+	tx, err = db.Begin()
+	if err != nil {
+		t.Fatalf("unexpected error from Begin: %v", err)
+	}
+	if _, err := tx.Exec("DELETE FROM foo"); err != nil {
+		t.Fatalf("unexpected error from Exec: %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("unexpected error from Rollback: %v", err)
+	}
+	if len(rec.paths) != 1 {
+		t.Fatalf("expected no request for rolled back transaction, got %v", rec.paths)
+	}
+}
+
+func Test_QueryDefaultOptions(t *testing.T) {
+	rec := &requestRecorder{}
+	server := newTestServer(t, rec, map[string]string{
+		"/db/query": `{"results": [{"columns": ["id"], "types": ["integer"], "values": [[1]]}]}`,
+	})
+	defer server.Close()
+
+	client, err := rqlitehttp.NewClient(server.URL, rqlitehttp.WithDefaultQueryOptions(&rqlitehttp.QueryOptions{
+		Associative: true,
+		Level:       rqlitehttp.ReadConsistencyLevelWeak,
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	db := sql.OpenDB(NewConnector(client))
+	defer db.Close()
+
+	var id int64
+	if err := db.QueryRow("SELECT id FROM foo").Scan(&id); err != nil {
+		t.Fatalf("unexpected error from QueryRow: %v", err)
+	}
+	if id != 1 {
+		t.Fatalf("expected id 1, got %d", id)
+	}
+	if exp, got := "level=weak", rec.values[len(rec.values)-1]; exp != got {
+		t.Fatalf("expected query parameters %s, got %s", exp, got)
+	}
+}
@@ -2,7 +2,12 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // SQLStatement represents a single SQL statement, possibly with parameters.
@@ -10,11 +15,21 @@ type SQLStatement struct {
 	// SQL is the text of the SQL statement, for example "INSERT INTO foo VALUES(?)".
 	SQL string
 
-	// PositionalParams is a slice of values for placeholders (?), if used.
+	// PositionalParams is a slice of values for placeholders (?), if used. A []byte
+	// value is sent as a BLOB.
 	PositionalParams []any
 
 	// NamedParams is a map of parameter names to values, if using named placeholders.
 	NamedParams map[string]any
+
+	// Timeout, if set, bounds the time the node spends executing the statement. rqlite
+	// does not support per-statement timeouts, so Timeout is not sent as part of the
+	// statement. Instead it is applied as the database-level timeout of the whole
+	// request by Query and Request. Every statement in a request which sets Timeout
+	// must set the same value, which must also match the Timeout in the request's
+	// options if that is set, otherwise the request is rejected. Timeout is not
+	// supported by Execute.
+	Timeout time.Duration
 }
 
 // NewSQLStatement creates a new SQLStatement from a SQL string and optional parameters.
@@ -35,12 +50,52 @@ func NewSQLStatement(stmt string, args ...any) (*SQLStatement, error) {
 	return &s, nil
 }
 
+// BuildBulkInsert returns a statement inserting rows into the given columns of table,
+// using a single multi-row VALUES clause. The parameters of the statement are the
+// values of rows in row-major order. Each row must have one value per column.
+func BuildBulkInsert(table string, columns []string, rows [][]any) (*SQLStatement, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("no columns specified")
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("no rows to insert")
+	}
+
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = quoteIdentifier(col)
+	}
+	tuple := placeholderTuple(len(columns))
+
+	params := make([]any, 0, len(rows)*len(columns))
+	tuples := make([]string, len(rows))
+	for i, row := range rows {
+		if len(row) != len(columns) {
+			return nil, fmt.Errorf("row %d has %d values, expected %d", i, len(row), len(columns))
+		}
+		params = append(params, row...)
+		tuples[i] = tuple
+	}
+
+	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", quoteIdentifier(table), strings.Join(quoted, ", "), strings.Join(tuples, ", "))
+	return &SQLStatement{SQL: sql, PositionalParams: params}, nil
+}
+
+// placeholderTuple returns a parenthesized list of n positional placeholders.
+func placeholderTuple(n int) string {
+	return "(" + strings.TrimSuffix(strings.Repeat("?, ", n), ", ") + ")"
+}
+
 // MarshalJSON implements a custom JSON representation so that SQL statements
 // always appear as an array in the format rqlite expects.
 func (s *SQLStatement) MarshalJSON() ([]byte, error) {
 	if len(s.NamedParams) > 0 {
 		// e.g. ["INSERT INTO foo(name, age) VALUES(:name, :age)", { "name": "...", "age": ... }]
-		arr := []any{s.SQL, s.NamedParams}
+		params := make(map[string]any, len(s.NamedParams))
+		for k, v := range s.NamedParams {
+			params[k] = encodeParam(v)
+		}
+		arr := []any{s.SQL, params}
 		return json.Marshal(arr)
 	}
 
@@ -48,7 +103,9 @@ func (s *SQLStatement) MarshalJSON() ([]byte, error) {
 		// e.g. ["INSERT INTO foo(name, age) VALUES(?, ?)", "param1", 123, ...]
 		arr := make([]any, 1, 1+len(s.PositionalParams))
 		arr[0] = s.SQL
-		arr = append(arr, s.PositionalParams...)
+		for _, v := range s.PositionalParams {
+			arr = append(arr, encodeParam(v))
+		}
 		return json.Marshal(arr)
 	}
 
@@ -95,9 +152,51 @@ func (s *SQLStatement) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// blobParam is a BLOB parameter. encoding/json encodes a []byte as a base64 string,
+// which rqlite would store as TEXT, so a blobParam is instead encoded as an array of
+// byte values, the form rqlite accepts for BLOB parameters.
+type blobParam []byte
+
+// MarshalJSON implements the json.Marshaler interface for blobParam.
+func (b blobParam) MarshalJSON() ([]byte, error) {
+	buf := make([]byte, 0, 2+4*len(b))
+	buf = append(buf, '[')
+	for i, x := range b {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = strconv.AppendUint(buf, uint64(x), 10)
+	}
+	return append(buf, ']'), nil
+}
+
+// encodeParam returns the value to encode for the statement parameter v.
+func encodeParam(v any) any {
+	if b, ok := v.([]byte); ok && b != nil {
+		return blobParam(b)
+	}
+	return v
+}
+
 // SQLStatements is a slice of SQLStatement.
 type SQLStatements []*SQLStatement
 
+// timeout returns the Timeout set by the statements, or 0 if none is set. It returns
+// an error if statements set different timeouts.
+func (sts SQLStatements) timeout() (time.Duration, error) {
+	var d time.Duration
+	for i, stmt := range sts {
+		if stmt.Timeout == 0 {
+			continue
+		}
+		if d != 0 && stmt.Timeout != d {
+			return 0, fmt.Errorf("statement %d timeout %s differs from earlier statement timeout %s", i, stmt.Timeout, d)
+		}
+		d = stmt.Timeout
+	}
+	return d, nil
+}
+
 func NewSQLStatementsFromStrings(stmts []string) SQLStatements {
 	s := make(SQLStatements, len(stmts))
 	for i, stmt := range stmts {
@@ -112,6 +211,39 @@ func (sts *SQLStatements) MarshalJSON() ([]byte, error) {
 	return json.Marshal([]*SQLStatement(*sts))
 }
 
+// WriteJSON writes the same JSON as MarshalJSON to w, marshaling one statement
+// at a time rather than building the entire array in memory.
+func (sts *SQLStatements) WriteJSON(w io.Writer) error {
+	return sts.writeJSON(w, json.Marshal)
+}
+
+// writeJSON is like WriteJSON, but marshals each statement using marshal.
+func (sts *SQLStatements) writeJSON(w io.Writer, marshal func(any) ([]byte, error)) error {
+	if *sts == nil {
+		_, err := io.WriteString(w, "null")
+		return err
+	}
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	for i, stmt := range *sts {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		b, err := marshal(stmt)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "]")
+	return err
+}
+
 func (sts *SQLStatements) UnmarshalJSON(data []byte) error {
 	var stmts []*SQLStatement
 	if err := json.Unmarshal(data, &stmts); err != nil {
@@ -1,8 +1,11 @@
 package http
 
 import (
+	"bytes"
+	"io"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func Test_NewSQLStatementFrom_Positional(t *testing.T) {
@@ -52,3 +55,140 @@ func Test_NewSQLStatementFrom_Named(t *testing.T) {
 		t.Fatalf("got: %v, want: %v", got, want)
 	}
 }
+
+func Test_SQLStatement_MarshalJSON_Blob(t *testing.T) {
+	for _, tt := range []struct {
+		stmt *SQLStatement
+		want string
+	}{
+		{
+			stmt: &SQLStatement{SQL: "INSERT INTO foo VALUES(?, ?, ?)", PositionalParams: []any{1, []byte{0, 1, 255}, []byte(nil)}},
+			want: `["INSERT INTO foo VALUES(?, ?, ?)",1,[0,1,255],null]`,
+		},
+		{
+			stmt: &SQLStatement{SQL: "INSERT INTO foo VALUES(:data)", NamedParams: map[string]any{"data": []byte{}}},
+			want: `["INSERT INTO foo VALUES(:data)",{"data":[]}]`,
+		},
+	} {
+		b, err := tt.stmt.MarshalJSON()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if string(b) != tt.want {
+			t.Fatalf("expected %s, got %s", tt.want, b)
+		}
+	}
+}
+
+func Test_SQLStatements_WriteJSON(t *testing.T) {
+	for _, stmts := range []SQLStatements{
+		nil,
+		{},
+		NewSQLStatementsFromStrings([]string{"SELECT 1"}),
+		{
+			{SQL: "INSERT INTO foo VALUES(?, ?)", PositionalParams: []any{"fiona", 20}},
+			{SQL: "INSERT INTO foo VALUES(:name)", NamedParams: map[string]any{"name": "declan"}},
+			{SQL: "SELECT * FROM foo"},
+		},
+	} {
+		exp, err := stmts.MarshalJSON()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var buf bytes.Buffer
+		if err := stmts.WriteJSON(&buf); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if exp, got := string(exp), buf.String(); exp != got {
+			t.Fatalf("expected %s, got %s", exp, got)
+		}
+	}
+
+	stmts := SQLStatements{{SQL: "INSERT INTO foo VALUES(?)", PositionalParams: []any{make(chan int)}}}
+	if err := stmts.WriteJSON(io.Discard); err == nil {
+		t.Fatalf("expected error writing unmarshalable statement")
+	}
+}
+
+func Test_BuildBulkInsert(t *testing.T) {
+	stmt, err := BuildBulkInsert("foo", []string{"id", "name"}, [][]any{
+		{1, "fiona"},
+		{2, "declan"},
+		{3, nil},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exp, got := `INSERT INTO "foo" ("id", "name") VALUES (?, ?), (?, ?), (?, ?)`, stmt.SQL; exp != got {
+		t.Fatalf("unexpected SQL\nwant: %s\ngot:  %s", exp, got)
+	}
+	if exp, got := []any{1, "fiona", 2, "declan", 3, nil}, stmt.PositionalParams; !reflect.DeepEqual(exp, got) {
+		t.Fatalf("unexpected params\nwant: %v\ngot:  %v", exp, got)
+	}
+
+	if _, err := BuildBulkInsert("foo", []string{"id", "name"}, [][]any{{1, "fiona"}, {2}}); err == nil {
+		t.Fatalf("expected error for row with too few values")
+	}
+	if _, err := BuildBulkInsert("foo", []string{"id"}, nil); err == nil {
+		t.Fatalf("expected error for no rows")
+	}
+	if _, err := BuildBulkInsert("foo", nil, [][]any{{}}); err == nil {
+		t.Fatalf("expected error for no columns")
+	}
+}
+
+func Test_SQLStatements_Timeout(t *testing.T) {
+	stmts := SQLStatements{
+		{SQL: "SELECT * FROM foo", Timeout: time.Second},
+		{SQL: "SELECT * FROM bar WHERE id = ?", PositionalParams: []any{1}},
+		{SQL: "SELECT * FROM baz", Timeout: time.Second},
+	}
+	b, err := stmts.MarshalJSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exp, got := `["SELECT * FROM foo",["SELECT * FROM bar WHERE id = ?",1],"SELECT * FROM baz"]`, string(b); exp != got {
+		t.Fatalf("unexpected serialization\nwant: %s\ngot:  %s", exp, got)
+	}
+	if d, err := stmts.timeout(); err != nil || d != time.Second {
+		t.Fatalf("expected timeout of 1s, got %s, %v", d, err)
+	}
+
+	stmts[2].Timeout = 2 * time.Second
+	if _, err := stmts.timeout(); err == nil {
+		t.Fatalf("expected error for mixed timeouts")
+	}
+}
+
+func Benchmark_SQLStatements_MarshalJSON(b *testing.B) {
+	stmts := benchmarkStatements(10000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf, err := stmts.MarshalJSON()
+		if err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+		io.Discard.Write(buf)
+	}
+}
+
+func Benchmark_SQLStatements_WriteJSON(b *testing.B) {
+	stmts := benchmarkStatements(10000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := stmts.WriteJSON(io.Discard); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func benchmarkStatements(n int) SQLStatements {
+	stmts := make(SQLStatements, n)
+	for i := range stmts {
+		stmts[i] = &SQLStatement{
+			SQL:              "INSERT INTO foo(name, age) VALUES(?, ?)",
+			PositionalParams: []any{"fiona", i},
+		}
+	}
+	return stmts
+}
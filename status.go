@@ -0,0 +1,357 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoLeader is returned when the node does not know of a Leader.
+var ErrNoLeader = errors.New("no leader")
+
+// RaftLogStats describes the state of a node's Raft log and snapshots.
+type RaftLogStats struct {
+	// AppliedIndex is the index of the last log entry applied to the database.
+	AppliedIndex int64
+
+	// CommitIndex is the index of the last log entry known to be committed.
+	CommitIndex int64
+
+	// LastLogIndex is the index of the last entry in the log.
+	LastLogIndex int64
+
+	// LastSnapshotIndex is the index of the last log entry included in a snapshot.
+	LastSnapshotIndex int64
+
+	// LogSize is the size of the Raft log on disk, in bytes.
+	LogSize int64
+
+	// SnapshotThreshold is the number of outstanding log entries which triggers
+	// a snapshot.
+	SnapshotThreshold int64
+
+	// TrailingLogs is the number of log entries retained after a snapshot.
+	TrailingLogs int64
+}
+
+// EntriesSinceSnapshot returns the number of log entries written since the last snapshot.
+func (rs RaftLogStats) EntriesSinceSnapshot() int64 {
+	return rs.LastLogIndex - rs.LastSnapshotIndex
+}
+
+// NeedsSnapshot returns whether enough log entries have been written since the last
+// snapshot that the node is due to take another. If the snapshot threshold is not
+// known, NeedsSnapshot returns false.
+func (rs RaftLogStats) NeedsSnapshot() bool {
+	return rs.SnapshotThreshold > 0 && rs.EntriesSinceSnapshot() >= rs.SnapshotThreshold
+}
+
+// RaftLogStats returns statistics about the node's Raft log and snapshots, parsed
+// from its status.
+func (c *Client) RaftLogStats(ctx context.Context) (RaftLogStats, error) {
+	b, err := c.Status(ctx)
+	if err != nil {
+		return RaftLogStats{}, err
+	}
+	var status struct {
+		Store struct {
+			Raft struct {
+				AppliedIndex      statusInt `json:"applied_index"`
+				CommitIndex       statusInt `json:"commit_index"`
+				LastLogIndex      statusInt `json:"last_log_index"`
+				LastSnapshotIndex statusInt `json:"last_snapshot_index"`
+				LogSize           statusInt `json:"log_size"`
+			} `json:"raft"`
+			SnapshotThreshold statusInt `json:"snapshot_threshold"`
+			TrailingLogs      statusInt `json:"trailing_logs"`
+		} `json:"store"`
+	}
+	if err := json.Unmarshal(b, &status); err != nil {
+		return RaftLogStats{}, err
+	}
+	raft := status.Store.Raft
+	return RaftLogStats{
+		AppliedIndex:      int64(raft.AppliedIndex),
+		CommitIndex:       int64(raft.CommitIndex),
+		LastLogIndex:      int64(raft.LastLogIndex),
+		LastSnapshotIndex: int64(raft.LastSnapshotIndex),
+		LogSize:           int64(raft.LogSize),
+		SnapshotThreshold: int64(status.Store.SnapshotThreshold),
+		TrailingLogs:      int64(status.Store.TrailingLogs),
+	}, nil
+}
+
+// ReplicationLag returns the number of log entries the node has yet to apply, that the
+// Leader has committed. If the node is the Leader, the lag is 0. If the node does not
+// know of a Leader, ErrNoLeader is returned.
+//
+// The Leader's commit index is as last reported to the node by the Leader, so the lag
+// of a node which has lost contact with the Leader may be underestimated.
+func (c *Client) ReplicationLag(ctx context.Context) (int64, error) {
+	b, err := c.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var status struct {
+		Store struct {
+			Raft struct {
+				State        string    `json:"state"`
+				AppliedIndex statusInt `json:"applied_index"`
+				CommitIndex  statusInt `json:"commit_index"`
+			} `json:"raft"`
+			Leader struct {
+				NodeID string `json:"node_id"`
+				Addr   string `json:"addr"`
+			} `json:"leader"`
+		} `json:"store"`
+	}
+	if err := json.Unmarshal(b, &status); err != nil {
+		return 0, err
+	}
+	raft := status.Store.Raft
+	if raft.State == "Leader" {
+		return 0, nil
+	}
+	if status.Store.Leader.Addr == "" && status.Store.Leader.NodeID == "" {
+		return 0, ErrNoLeader
+	}
+	return max(int64(raft.CommitIndex-raft.AppliedIndex), 0), nil
+}
+
+// CommitEvent reports that the node has applied further entries from its Raft log.
+type CommitEvent struct {
+	// PreviousIndex is the applied index observed by the previous poll.
+	PreviousIndex int64
+
+	// AppliedIndex is the index of the last log entry applied to the database. Any
+	// write whose Raft index is at or below AppliedIndex has been committed and applied.
+	AppliedIndex int64
+
+	// CommitIndex is the index of the last log entry known to be committed.
+	CommitIndex int64
+}
+
+// WatchCommits polls the node's status every interval, calling fn each time the
+// node's applied index advances. It can be used to learn when queued writes, or
+// writes made with ExecuteOptions.RaftIndex set, have been applied.
+//
+// rqlite does not offer a streaming endpoint for write acknowledgements, so commits
+// are observed by polling, and fn is called once per poll however many entries were
+// applied in between. The first poll establishes the starting index, and does not
+// call fn.
+//
+// WatchCommits blocks until ctx is done, fn returns an error, or polling the node
+// fails, and returns the corresponding error.
+func (c *Client) WatchCommits(ctx context.Context, interval time.Duration, fn func(CommitEvent) error) error {
+	stats, err := c.RaftLogStats(ctx)
+	if err != nil {
+		return err
+	}
+	last := stats.AppliedIndex
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		stats, err := c.RaftLogStats(ctx)
+		if err != nil {
+			return err
+		}
+		if stats.AppliedIndex <= last {
+			continue
+		}
+		ev := CommitEvent{
+			PreviousIndex: last,
+			AppliedIndex:  stats.AppliedIndex,
+			CommitIndex:   stats.CommitIndex,
+		}
+		last = stats.AppliedIndex
+		if err := fn(ev); err != nil {
+			return err
+		}
+	}
+}
+
+// sequenceNumberInterval is how often WaitForSequenceNumber polls the node's status.
+const sequenceNumberInterval = 100 * time.Millisecond
+
+// WaitForSequenceNumber waits until the node has persisted the queued write with
+// sequence number seq, as returned by QueuedExecute, polling the node's status until
+// the highest sequence number it reports as persisted reaches seq.
+//
+// Sequence numbers are assigned by the node which queued the write, so the client
+// should be connected to that node. WaitForSequenceNumber blocks until the write has
+// been persisted, ctx is done, or polling the node fails, and returns the
+// corresponding error.
+func (c *Client) WaitForSequenceNumber(ctx context.Context, seq int64) error {
+	ticker := time.NewTicker(sequenceNumberInterval)
+	defer ticker.Stop()
+	for {
+		persisted, err := c.persistedSequenceNumber(ctx)
+		if err != nil {
+			return err
+		}
+		if persisted >= seq {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// persistedSequenceNumber returns the highest sequence number of the queued writes
+// the node reports as persisted.
+func (c *Client) persistedSequenceNumber(ctx context.Context) (int64, error) {
+	b, err := c.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var status struct {
+		HTTP struct {
+			Queue struct {
+				Default struct {
+					SequenceNumber *statusInt `json:"sequence_number"`
+				} `json:"_default"`
+			} `json:"queue"`
+		} `json:"http"`
+	}
+	if err := json.Unmarshal(b, &status); err != nil {
+		return 0, err
+	}
+	if status.HTTP.Queue.Default.SequenceNumber == nil {
+		return 0, errors.New("status does not report queue sequence number")
+	}
+	return int64(*status.HTTP.Queue.Default.SequenceNumber), nil
+}
+
+// DatabaseSize returns the size, in bytes, of the node's SQLite database file, as
+// reported in its status. The size does not include the WAL file, if any, which is
+// reported by WALSize.
+func (c *Client) DatabaseSize(ctx context.Context) (int64, error) {
+	sizes, err := c.sqliteSizes(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if sizes.DBSize == nil {
+		return 0, errors.New("status does not report database size")
+	}
+	return int64(*sizes.DBSize), nil
+}
+
+// WALSize returns the size, in bytes, of the node's SQLite WAL file, as reported in
+// its status, and whether the node reports it. Nodes which do not use a WAL, or which
+// are running older versions of rqlite, may not report a WAL size.
+func (c *Client) WALSize(ctx context.Context) (int64, bool, error) {
+	sizes, err := c.sqliteSizes(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	if sizes.WALSize == nil {
+		return 0, false, nil
+	}
+	return int64(*sizes.WALSize), true, nil
+}
+
+// sqliteSizes are the sizes of the SQLite files reported in a node's status.
+type sqliteSizes struct {
+	DBSize  *statusInt `json:"db_size"`
+	WALSize *statusInt `json:"wal_size"`
+}
+
+func (c *Client) sqliteSizes(ctx context.Context) (sqliteSizes, error) {
+	b, err := c.Status(ctx)
+	if err != nil {
+		return sqliteSizes{}, err
+	}
+	var status struct {
+		Store struct {
+			SQLite3 sqliteSizes `json:"sqlite3"`
+		} `json:"store"`
+	}
+	if err := json.Unmarshal(b, &status); err != nil {
+		return sqliteSizes{}, err
+	}
+	return status.Store.SQLite3, nil
+}
+
+// Uptime returns how long the node has been running, derived from the start time
+// reported in its status. If the node does not report a start time, the uptime it
+// reports is used instead.
+func (c *Client) Uptime(ctx context.Context) (time.Duration, error) {
+	b, err := c.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return uptimeFromStatus(b, time.Now())
+}
+
+// startTimeLayouts are the layouts accepted for a node's start time. Nodes report
+// RFC 3339 timestamps, but the default formatting of a Go time.Time is accepted too.
+var startTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	"2006-01-02 15:04:05.999999999 -0700",
+	"2006-01-02T15:04:05.999999999",
+}
+
+// uptimeFromStatus returns the uptime of a node, as of now, given its status.
+func uptimeFromStatus(b []byte, now time.Time) (time.Duration, error) {
+	var status struct {
+		Node struct {
+			StartTime string `json:"start_time"`
+			Uptime    string `json:"uptime"`
+		} `json:"node"`
+	}
+	if err := json.Unmarshal(b, &status); err != nil {
+		return 0, err
+	}
+
+	if st := strings.TrimSpace(status.Node.StartTime); st != "" {
+		// Strip any monotonic clock reading, as included by time.Time's String method.
+		if i := strings.Index(st, " m="); i != -1 {
+			st = st[:i]
+		}
+		for _, layout := range startTimeLayouts {
+			t, err := time.Parse(layout, st)
+			if err == nil {
+				return max(now.Sub(t), 0), nil
+			}
+		}
+		return 0, fmt.Errorf("unrecognized start time: %s", status.Node.StartTime)
+	}
+	if status.Node.Uptime != "" {
+		return time.ParseDuration(status.Node.Uptime)
+	}
+	return 0, errors.New("status does not report start time or uptime")
+}
+
+// statusInt is an integer in a node's status, which may be encoded either as a
+// JSON number or as a string.
+type statusInt int64
+
+// UnmarshalJSON implements the json.Unmarshaler interface for statusInt.
+func (si *statusInt) UnmarshalJSON(data []byte) error {
+	data = bytes.Trim(data, `"`)
+	if len(data) == 0 || string(data) == "null" {
+		*si = 0
+		return nil
+	}
+	i, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return err
+	}
+	*si = statusInt(i)
+	return nil
+}
@@ -0,0 +1,408 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_RaftLogStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/status" {
+			t.Errorf("expected path /status, got %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"store": {
+				"raft": {
+					"applied_index": 9000,
+					"commit_index": 9000,
+					"last_log_index": "9001",
+					"last_snapshot_index": 512,
+					"log_size": 2097152,
+					"state": "Leader"
+				},
+				"snapshot_threshold": 8192,
+				"trailing_logs": 10240
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	stats, err := cl.RaftLogStats(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error calling RaftLogStats: %v", err)
+	}
+	exp := RaftLogStats{
+		AppliedIndex:      9000,
+		CommitIndex:       9000,
+		LastLogIndex:      9001,
+		LastSnapshotIndex: 512,
+		LogSize:           2097152,
+		SnapshotThreshold: 8192,
+		TrailingLogs:      10240,
+	}
+	if exp != stats {
+		t.Fatalf("mismatched stats\nwant: %+v\ngot:  %+v", exp, stats)
+	}
+	if exp, got := int64(8489), stats.EntriesSinceSnapshot(); exp != got {
+		t.Fatalf("expected %d entries since snapshot, got %d", exp, got)
+	}
+	if !stats.NeedsSnapshot() {
+		t.Fatalf("expected snapshot to be needed")
+	}
+}
+
+func Test_RaftLogStats_NeedsSnapshot(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		stats RaftLogStats
+		exp   bool
+	}{
+		{"below threshold", RaftLogStats{LastLogIndex: 100, LastSnapshotIndex: 50, SnapshotThreshold: 100}, false},
+		{"at threshold", RaftLogStats{LastLogIndex: 150, LastSnapshotIndex: 50, SnapshotThreshold: 100}, true},
+		{"above threshold", RaftLogStats{LastLogIndex: 500, LastSnapshotIndex: 50, SnapshotThreshold: 100}, true},
+		{"unknown threshold", RaftLogStats{LastLogIndex: 500}, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stats.NeedsSnapshot(); got != tt.exp {
+				t.Fatalf("expected %v, got %v", tt.exp, got)
+			}
+		})
+	}
+}
+
+func Test_WatchCommits(t *testing.T) {
+	indexes := []int{5, 5, 7, 7, 10}
+	var polls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(polls.Add(1)) - 1
+		idx := indexes[min(n, len(indexes)-1)]
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{"store": {"raft": {"applied_index": %d, "commit_index": %d}}}`, idx, idx+1)
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	var events []CommitEvent
+	errDone := errors.New("done")
+	err = cl.WatchCommits(context.Background(), time.Millisecond, func(ev CommitEvent) error {
+		events = append(events, ev)
+		if len(events) == 2 {
+			return errDone
+		}
+		return nil
+	})
+	if err != errDone {
+		t.Fatalf("expected error from callback, got %v", err)
+	}
+	exp := []CommitEvent{
+		{PreviousIndex: 5, AppliedIndex: 7, CommitIndex: 8},
+		{PreviousIndex: 7, AppliedIndex: 10, CommitIndex: 11},
+	}
+	if !reflect.DeepEqual(exp, events) {
+		t.Fatalf("unexpected events\nwant: %+v\ngot:  %+v", exp, events)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = cl.WatchCommits(ctx, time.Millisecond, func(ev CommitEvent) error {
+		t.Fatalf("unexpected event once index stops advancing: %+v", ev)
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func Test_ReplicationLag(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		status string
+		exp    int64
+		expErr error
+	}{
+		{
+			name:   "lagging follower",
+			status: `{"store": {"raft": {"state": "Follower", "applied_index": 90, "commit_index": 100}, "leader": {"node_id": "1", "addr": "localhost:4002"}}}`,
+			exp:    10,
+		},
+		{
+			name:   "caught up follower",
+			status: `{"store": {"raft": {"state": "Follower", "applied_index": "100", "commit_index": "100"}, "leader": {"node_id": "1", "addr": "localhost:4002"}}}`,
+			exp:    0,
+		},
+		{
+			name:   "leader",
+			status: `{"store": {"raft": {"state": "Leader", "applied_index": 99, "commit_index": 100}, "leader": {"node_id": "1", "addr": "localhost:4002"}}}`,
+			exp:    0,
+		},
+		{
+			name:   "no leader",
+			status: `{"store": {"raft": {"state": "Candidate", "applied_index": 90, "commit_index": 100}, "leader": {"node_id": "", "addr": ""}}}`,
+			expErr: ErrNoLeader,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(tt.status))
+			}))
+			defer server.Close()
+
+			cl, err := NewClient(server.URL, nil)
+			if err != nil {
+				t.Fatalf("unexpected error from NewClient: %v", err)
+			}
+			defer cl.Close()
+
+			lag, err := cl.ReplicationLag(context.Background())
+			if err != tt.expErr {
+				t.Fatalf("expected error %v, got %v", tt.expErr, err)
+			}
+			if lag != tt.exp {
+				t.Fatalf("expected lag %d, got %d", tt.exp, lag)
+			}
+		})
+	}
+}
+
+func Test_UptimeFromStatus(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		name   string
+		status string
+		exp    time.Duration
+		expErr bool
+	}{
+		{
+			name:   "RFC 3339 UTC",
+			status: `{"node": {"start_time": "2024-03-01T10:30:00.5Z", "uptime": "1s"}}`,
+			exp:    89*time.Minute + 59*time.Second + 500*time.Millisecond,
+		},
+		{
+			name:   "RFC 3339 with offset",
+			status: `{"node": {"start_time": "2024-03-01T13:00:00+02:00"}}`,
+			exp:    time.Hour,
+		},
+		{
+			name:   "Go time format with monotonic reading",
+			status: `{"node": {"start_time": "2024-03-01 11:00:00.123 +0000 UTC m=+0.012345"}}`,
+			exp:    time.Hour - 123*time.Millisecond,
+		},
+		{
+			name:   "start time in the future",
+			status: `{"node": {"start_time": "2024-03-01T12:00:05Z"}}`,
+			exp:    0,
+		},
+		{
+			name:   "uptime only",
+			status: `{"node": {"uptime": "2h3m4.5s"}}`,
+			exp:    2*time.Hour + 3*time.Minute + 4500*time.Millisecond,
+		},
+		{
+			name:   "unrecognized start time",
+			status: `{"node": {"start_time": "yesterday"}}`,
+			expErr: true,
+		},
+		{
+			name:   "missing",
+			status: `{"node": {}}`,
+			expErr: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := uptimeFromStatus([]byte(tt.status), now)
+			if tt.expErr {
+				if err == nil {
+					t.Fatalf("expected error, got uptime %s", d)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if d != tt.exp {
+				t.Fatalf("expected uptime %s, got %s", tt.exp, d)
+			}
+		})
+	}
+}
+
+func Test_Uptime(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{"node": {"start_time": %q}}`, start.Format(time.RFC3339Nano))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	d, err := cl.Uptime(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error calling Uptime: %v", err)
+	}
+	if d < time.Hour || d > time.Hour+time.Minute {
+		t.Fatalf("expected uptime of about an hour, got %s", d)
+	}
+}
+
+func Test_DatabaseSize(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		status    string
+		expSize   int64
+		expWAL    int64
+		expHasWAL bool
+		expErr    bool
+	}{
+		{
+			name:      "database and WAL",
+			status:    `{"store": {"sqlite3": {"db_size": 8192, "db_size_friendly": "8.2 kB", "wal_size": 4152, "path": "/data/db.sqlite"}}}`,
+			expSize:   8192,
+			expWAL:    4152,
+			expHasWAL: true,
+		},
+		{
+			name:    "database only",
+			status:  `{"store": {"sqlite3": {"db_size": "1048576"}}}`,
+			expSize: 1048576,
+		},
+		{
+			name:   "missing",
+			status: `{"store": {"sqlite3": {}}}`,
+			expErr: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(tt.status))
+			}))
+			defer server.Close()
+
+			cl, err := NewClient(server.URL, nil)
+			if err != nil {
+				t.Fatalf("unexpected error from NewClient: %v", err)
+			}
+			defer cl.Close()
+
+			size, err := cl.DatabaseSize(context.Background())
+			if tt.expErr {
+				if err == nil {
+					t.Fatalf("expected error, got size %d", size)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error calling DatabaseSize: %v", err)
+			} else if size != tt.expSize {
+				t.Fatalf("expected size %d, got %d", tt.expSize, size)
+			}
+
+			wal, ok, err := cl.WALSize(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error calling WALSize: %v", err)
+			}
+			if ok != tt.expHasWAL || wal != tt.expWAL {
+				t.Fatalf("expected WAL size %d (reported=%v), got %d (reported=%v)", tt.expWAL, tt.expHasWAL, wal, ok)
+			}
+		})
+	}
+}
+
+func Test_QueuedExecute_WaitForSequenceNumber(t *testing.T) {
+	var polls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/db/execute":
+			if !r.URL.Query().Has("queue") || r.URL.Query().Has("wait") {
+				t.Errorf("expected queue and not wait, got %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{"results": [], "sequence_number": 1653314298877648934}`))
+		case "/status":
+			seq := "1653314298877648000"
+			if polls.Add(1) >= 3 {
+				seq = "1653314298877648934"
+			}
+			fmt.Fprintf(w, `{"http": {"queue": {"_default": {"sequence_number": %s}}}}`, seq)
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	seq, err := cl.QueuedExecute(context.Background(), SQLStatements{{SQL: "INSERT INTO foo VALUES(1)"}}, &ExecuteOptions{Wait: true})
+	if err != nil {
+		t.Fatalf("unexpected error from QueuedExecute: %v", err)
+	}
+	if exp := int64(1653314298877648934); exp != seq {
+		t.Fatalf("expected sequence number %d, got %d", exp, seq)
+	}
+	if err := cl.WaitForSequenceNumber(context.Background(), seq); err != nil {
+		t.Fatalf("unexpected error from WaitForSequenceNumber: %v", err)
+	}
+	if exp, got := int32(3), polls.Load(); exp != got {
+		t.Fatalf("expected %d polls, got %d", exp, got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := cl.WaitForSequenceNumber(ctx, seq+1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func Test_WaitForQueue(t *testing.T) {
+	response := `{"results": [], "sequence_number": 2}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/db/execute" {
+			t.Errorf("expected path /db/execute, got %s", r.URL.Path)
+		}
+		if exp, got := "queue=true&timeout=5s&wait=true", r.URL.RawQuery; exp != got {
+			t.Errorf("expected query %s, got %s", exp, got)
+		}
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	if err := cl.WaitForQueue(context.Background(), 5*time.Second); err != nil {
+		t.Fatalf("unexpected error from WaitForQueue: %v", err)
+	}
+	response = `{"error": "timeout waiting for queue to flush"}`
+	if err := cl.WaitForQueue(context.Background(), 5*time.Second); err == nil || err.Error() != "timeout waiting for queue to flush" {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"context"
+	"time"
+)
+
+// Timing describes how long a request took.
+type Timing struct {
+	// ServerTime is the time the node reported spending processing the request.
+	ServerTime time.Duration
+
+	// RoundTrip is the wall-clock time from sending the request to decoding the
+	// response, as measured by the client.
+	RoundTrip time.Duration
+}
+
+// Overhead returns the portion of the round trip not spent processing the request
+// on the node, such as network latency and encoding.
+func (t Timing) Overhead() time.Duration {
+	return t.RoundTrip - t.ServerTime
+}
+
+// ExecuteWithTiming is like Execute, but also returns the timing of the request.
+// Timing information is always requested from the node, regardless of opts.Timings.
+// opts is not modified.
+func (c *Client) ExecuteWithTiming(ctx context.Context, statements SQLStatements, opts *ExecuteOptions) (*ExecuteResponse, Timing, error) {
+	var o ExecuteOptions
+	if opts != nil {
+		o = *opts
+	}
+	o.Timings = true
+	start := time.Now()
+	resp, err := c.Execute(ctx, statements, &o)
+	t := Timing{RoundTrip: time.Since(start)}
+	if resp != nil {
+		t.ServerTime = secondsToDuration(resp.Time)
+	}
+	return resp, t, err
+}
+
+// QueryWithTiming is like Query, but also returns the timing of the request.
+// Timing information is always requested from the node, regardless of opts.Timings.
+// opts is not modified.
+func (c *Client) QueryWithTiming(ctx context.Context, statements SQLStatements, opts *QueryOptions) (*QueryResponse, Timing, error) {
+	var o QueryOptions
+	if opts != nil {
+		o = *opts
+	}
+	o.Timings = true
+	start := time.Now()
+	resp, err := c.Query(ctx, statements, &o)
+	t := Timing{RoundTrip: time.Since(start)}
+	if resp != nil {
+		t.ServerTime = secondsToDuration(resp.Time)
+	}
+	return resp, t, err
+}
+
+// RequestWithTiming is like Request, but also returns the timing of the request.
+// Timing information is always requested from the node, regardless of opts.Timings.
+// opts is not modified.
+func (c *Client) RequestWithTiming(ctx context.Context, statements SQLStatements, opts *RequestOptions) (*RequestResponse, Timing, error) {
+	var o RequestOptions
+	if opts != nil {
+		o = *opts
+	}
+	o.Timings = true
+	start := time.Now()
+	resp, err := c.Request(ctx, statements, &o)
+	t := Timing{RoundTrip: time.Since(start)}
+	if resp != nil {
+		t.ServerTime = secondsToDuration(resp.Time)
+	}
+	return resp, t, err
+}
+
+// secondsToDuration converts a time in seconds, as reported by the node, to a Duration.
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_WithTiming(t *testing.T) {
+	const delay = 20 * time.Millisecond
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("timings") != "true" {
+			t.Errorf("expected timings to be requested, got %s", r.URL.RawQuery)
+		}
+		time.Sleep(delay)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"results": [], "time": 0.005}`))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	ctx := context.Background()
+	stmts := NewSQLStatementsFromStrings([]string{"SELECT 1"})
+	for _, tt := range []struct {
+		name string
+		fn   func() (Timing, error)
+	}{
+		{"execute", func() (Timing, error) { _, t, err := cl.ExecuteWithTiming(ctx, stmts, nil); return t, err }},
+		{"query", func() (Timing, error) { _, t, err := cl.QueryWithTiming(ctx, stmts, &QueryOptions{}); return t, err }},
+		{"request", func() (Timing, error) { _, t, err := cl.RequestWithTiming(ctx, stmts, nil); return t, err }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			timing, err := tt.fn()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if exp, got := 5*time.Millisecond, timing.ServerTime; exp != got {
+				t.Fatalf("expected server time %s, got %s", exp, got)
+			}
+			if timing.RoundTrip < delay {
+				t.Fatalf("expected round trip of at least %s, got %s", delay, timing.RoundTrip)
+			}
+			if exp, got := timing.RoundTrip-timing.ServerTime, timing.Overhead(); exp != got {
+				t.Fatalf("expected overhead %s, got %s", exp, got)
+			}
+		})
+	}
+}
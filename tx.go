@@ -0,0 +1,102 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrTxDone is returned by any operation on a Tx which has already been committed or
+// rolled back.
+var ErrTxDone = errors.New("transaction has already been committed or rolled back")
+
+// Tx accumulates statements to be executed atomically, in a single transaction, when
+// the Tx is committed. Nothing is sent to the node until Commit is called, so a Tx
+// cannot read its own writes before it is committed. A Tx is safe for concurrent use
+// by multiple goroutines.
+type Tx struct {
+	c *Client
+
+	mu    sync.Mutex
+	stmts SQLStatements
+	done  bool
+}
+
+// Begin starts a transaction, which is sent to the node using /db/request when it is
+// committed.
+func (c *Client) Begin() *Tx {
+	return &Tx{c: c}
+}
+
+// Execute adds a write statement to the transaction, returning its index among the
+// transaction's statements, which is the index of its result in the response returned
+// by Commit. args should be a single map of named parameters, or a slice of positional
+// parameters.
+func (tx *Tx) Execute(statement string, args ...any) (int, error) {
+	return tx.add(statement, args...)
+}
+
+// Query adds a read statement to the transaction, returning its index among the
+// transaction's statements, which is the index of its result in the response returned
+// by Commit. args should be a single map of named parameters, or a slice of positional
+// parameters.
+func (tx *Tx) Query(statement string, args ...any) (int, error) {
+	return tx.add(statement, args...)
+}
+
+func (tx *Tx) add(statement string, args ...any) (int, error) {
+	stmt, err := NewSQLStatement(statement, args...)
+	if err != nil {
+		return 0, err
+	}
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.done {
+		return 0, ErrTxDone
+	}
+	tx.stmts = append(tx.stmts, stmt)
+	return len(tx.stmts) - 1, nil
+}
+
+// Commit sends the transaction's statements to the node, to be executed in a single
+// transaction. If any statement fails, the whole transaction is rolled back, and the
+// error for each failed statement is returned as a *StatementError identifying it by
+// index, joined with errors.Join, along with the response. If the transaction has no
+// statements, nothing is sent and an empty response is returned.
+//
+// The Tx cannot be used once Commit has been called, even if it fails.
+func (tx *Tx) Commit(ctx context.Context) (*RequestResponse, error) {
+	tx.mu.Lock()
+	if tx.done {
+		tx.mu.Unlock()
+		return nil, ErrTxDone
+	}
+	tx.done = true
+	stmts := tx.stmts
+	tx.stmts = nil
+	tx.mu.Unlock()
+
+	if len(stmts) == 0 {
+		return &RequestResponse{Results: []RequestResult{}}, nil
+	}
+	rr, err := tx.c.Request(ctx, stmts, &RequestOptions{Transaction: true})
+	if err != nil {
+		return rr, err
+	}
+	errs := rr.statementErrors()
+	attachSQL(errs, stmts)
+	return rr, errors.Join(errs...)
+}
+
+// Rollback discards the transaction's statements. As nothing is sent to the node
+// before Commit, nothing is sent to the node by Rollback.
+func (tx *Tx) Rollback() error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+	tx.stmts = nil
+	return nil
+}
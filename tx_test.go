@@ -0,0 +1,101 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func Test_Tx(t *testing.T) {
+	var requests int
+	var sent SQLStatements
+	response := `{"results": [{"last_insert_id": 1, "rows_affected": 1}, {"columns": ["id"], "types": ["integer"], "values": [[1]]}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/db/request" {
+			t.Errorf("expected path /db/request, got %s", r.URL.Path)
+		}
+		if exp, got := "transaction=true", r.URL.RawQuery; exp != got {
+			t.Errorf("expected query %s, got %s", exp, got)
+		}
+		sent = nil
+		if err := json.NewDecoder(r.Body).Decode(&sent); err != nil {
+			t.Errorf("unexpected error decoding body: %v", err)
+		}
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	tx := cl.Begin()
+	if i, err := tx.Execute("INSERT INTO foo(name) VALUES(?)", "fiona"); err != nil || i != 0 {
+		t.Fatalf("expected index 0, got %d (%v)", i, err)
+	}
+	if i, err := tx.Query("SELECT id FROM foo WHERE name = :name", map[string]any{"name": "fiona"}); err != nil || i != 1 {
+		t.Fatalf("expected index 1, got %d (%v)", i, err)
+	}
+	if requests != 0 {
+		t.Fatalf("expected no requests before commit, got %d", requests)
+	}
+	rr, err := tx.Commit(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error from Commit: %v", err)
+	}
+	if exp, got := 2, len(rr.GetRequestResults()); exp != got {
+		t.Fatalf("expected %d results, got %d", exp, got)
+	}
+	exp := SQLStatements{
+		{SQL: "INSERT INTO foo(name) VALUES(?)", PositionalParams: []any{"fiona"}},
+		{SQL: "SELECT id FROM foo WHERE name = :name", NamedParams: map[string]any{"name": "fiona"}},
+	}
+	if !reflect.DeepEqual(exp, sent) {
+		t.Fatalf("unexpected statements\nwant: %v\ngot:  %v", exp, sent)
+	}
+
+	// The Tx cannot be used after commit.
+	if _, err := tx.Execute("DELETE FROM foo"); err != ErrTxDone {
+		t.Fatalf("expected ErrTxDone from Execute, got %v", err)
+	}
+	if _, err := tx.Commit(context.Background()); err != ErrTxDone {
+		t.Fatalf("expected ErrTxDone from Commit, got %v", err)
+	}
+	if err := tx.Rollback(); err != ErrTxDone {
+		t.Fatalf("expected ErrTxDone from Rollback, got %v", err)
+	}
+
+	// A rolled back Tx sends nothing.
+	tx = cl.Begin()
+	tx.Execute("DELETE FROM foo")
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("unexpected error from Rollback: %v", err)
+	}
+	if _, err := tx.Commit(context.Background()); err != ErrTxDone {
+		t.Fatalf("expected ErrTxDone from Commit, got %v", err)
+	}
+	if requests != 1 {
+		t.Fatalf("expected no request for rolled back Tx, got %d requests", requests)
+	}
+
+	// Failed statements are identified by index.
+	response = `{"results": [{"last_insert_id": 2, "rows_affected": 1}, {"error": "no such table: bar"}]}`
+	tx = cl.Begin()
+	tx.Execute("INSERT INTO foo(name) VALUES(?)", "declan")
+	tx.Execute("INSERT INTO bar(name) VALUES(?)", "declan")
+	rr, err = tx.Commit(context.Background())
+	var se *StatementError
+	if !errors.As(err, &se) || se.Index != 1 || se.Message != "no such table: bar" {
+		t.Fatalf("expected error for statement 1, got %v", err)
+	}
+	if rr == nil {
+		t.Fatalf("expected response with error")
+	}
+}
@@ -0,0 +1,92 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// UpsertMany inserts rows into table, updating any existing rows whose keyCol
+// value conflicts with that of a new row. All rows are written by a single
+// INSERT ... ON CONFLICT statement, executed within a transaction.
+//
+// The columns written are the union of the keys of all rows, in sorted order. If
+// a row has no value for a column, NULL is written for that column. Every row
+// must have a value for keyCol. opts may be nil, in which case default options
+// are used.
+func (c *Client) UpsertMany(ctx context.Context, table, keyCol string, rows []map[string]any, opts *ExecuteOptions) (*ExecuteResponse, error) {
+	stmt, err := buildUpsertMany(table, keyCol, rows)
+	if err != nil {
+		return nil, err
+	}
+	var txOpts ExecuteOptions
+	if opts != nil {
+		txOpts = *opts
+	}
+	txOpts.Transaction = true
+	return c.Execute(ctx, SQLStatements{stmt}, &txOpts)
+}
+
+// buildUpsertMany returns a single statement which upserts all rows into table.
+func buildUpsertMany(table, keyCol string, rows []map[string]any) (*SQLStatement, error) {
+	if table == "" || keyCol == "" {
+		return nil, errors.New("table and key column must be specified")
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("no rows to upsert")
+	}
+
+	var cols []string
+	for i, row := range rows {
+		if _, ok := row[keyCol]; !ok {
+			return nil, fmt.Errorf("row %d has no value for key column %s", i, keyCol)
+		}
+		for col := range row {
+			if !slices.Contains(cols, col) {
+				cols = append(cols, col)
+			}
+		}
+	}
+	slices.Sort(cols)
+
+	quoted := make([]string, len(cols))
+	for i, col := range cols {
+		quoted[i] = quoteIdentifier(col)
+	}
+	placeholders := placeholderTuple(len(cols))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "INSERT INTO %s (%s) VALUES ", quoteIdentifier(table), strings.Join(quoted, ", "))
+	params := make([]any, 0, len(rows)*len(cols))
+	for i, row := range rows {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(placeholders)
+		for _, col := range cols {
+			params = append(params, row[col])
+		}
+	}
+
+	var updates []string
+	for i, col := range cols {
+		if col != keyCol {
+			updates = append(updates, fmt.Sprintf("%s = excluded.%s", quoted[i], quoted[i]))
+		}
+	}
+	fmt.Fprintf(&sb, " ON CONFLICT (%s) DO ", quoteIdentifier(keyCol))
+	if len(updates) == 0 {
+		sb.WriteString("NOTHING")
+	} else {
+		sb.WriteString("UPDATE SET " + strings.Join(updates, ", "))
+	}
+
+	return &SQLStatement{SQL: sb.String(), PositionalParams: params}, nil
+}
+
+// quoteIdentifier returns s quoted for use as an SQLite identifier.
+func quoteIdentifier(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
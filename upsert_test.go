@@ -0,0 +1,91 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func Test_BuildUpsertMany(t *testing.T) {
+	rows := []map[string]any{
+		{"id": 1, "name": "fiona"},
+		{"id": 2, "age": 30},
+		{"name": "declan", "id": 3, "age": 20},
+	}
+	stmt, err := buildUpsertMany("foo", "id", rows)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expSQL := `INSERT INTO "foo" ("age", "id", "name") VALUES (?, ?, ?), (?, ?, ?), (?, ?, ?) ` +
+		`ON CONFLICT ("id") DO UPDATE SET "age" = excluded."age", "name" = excluded."name"`
+	if stmt.SQL != expSQL {
+		t.Fatalf("unexpected SQL\nwant: %s\ngot:  %s", expSQL, stmt.SQL)
+	}
+	expParams := []any{nil, 1, "fiona", 30, 2, nil, 20, 3, "declan"}
+	if !reflect.DeepEqual(expParams, stmt.PositionalParams) {
+		t.Fatalf("unexpected params\nwant: %v\ngot:  %v", expParams, stmt.PositionalParams)
+	}
+
+	stmt, err = buildUpsertMany(`my"table`, "id", []map[string]any{{"id": 1}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exp := `INSERT INTO "my""table" ("id") VALUES (?) ON CONFLICT ("id") DO NOTHING`; stmt.SQL != exp {
+		t.Fatalf("unexpected SQL\nwant: %s\ngot:  %s", exp, stmt.SQL)
+	}
+
+	if _, err := buildUpsertMany("foo", "id", nil); err == nil {
+		t.Fatalf("expected error for no rows")
+	}
+	if _, err := buildUpsertMany("foo", "id", []map[string]any{{"id": 1}, {"name": "x"}}); err == nil {
+		t.Fatalf("expected error for row missing key column")
+	}
+}
+
+func Test_UpsertMany(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/db/execute" {
+			t.Errorf("expected path /db/execute, got %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("transaction") != "true" {
+			t.Errorf("expected transaction to be set, got %s", r.URL.RawQuery)
+		}
+		if r.URL.Query().Get("timings") != "true" {
+			t.Errorf("expected timings to be set, got %s", r.URL.RawQuery)
+		}
+		var stmts SQLStatements
+		if err := json.NewDecoder(r.Body).Decode(&stmts); err != nil {
+			t.Errorf("unexpected error decoding body: %v", err)
+		}
+		if len(stmts) != 1 {
+			t.Errorf("expected 1 statement, got %d", len(stmts))
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"results": [{"last_insert_id": 2, "rows_affected": 2}]}`))
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	opts := &ExecuteOptions{Timings: true}
+	resp, err := cl.UpsertMany(context.Background(), "foo", "id", []map[string]any{
+		{"id": 1, "name": "fiona"},
+		{"id": 2},
+	}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error calling UpsertMany: %v", err)
+	}
+	if exp, got := int64(2), resp.Results[0].RowsAffected; exp != got {
+		t.Fatalf("expected %d rows affected, got %d", exp, got)
+	}
+	if opts.Transaction {
+		t.Fatalf("caller's options were modified")
+	}
+}
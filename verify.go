@@ -0,0 +1,149 @@
+package http
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// ErrInvalidSQLiteFile is returned when a file is not a valid SQLite database.
+var ErrInvalidSQLiteFile = errors.New("invalid SQLite file")
+
+// sqliteHeaderSize is the size of the header at the start of every SQLite database file.
+const sqliteHeaderSize = 100
+
+// VerifySQLiteFile checks that the file at path is a valid SQLite database, for
+// example after writing a backup to disk. It checks the file's header, and that its
+// size is consistent with the page size recorded in the header.
+//
+// If a database/sql driver named "sqlite3" or "sqlite" has been registered by the
+// program, the file is also opened read-only and checked with "PRAGMA quick_check".
+// This package does not itself depend on any SQLite driver.
+func VerifySQLiteFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	header := make([]byte, sqliteHeaderSize)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return fmt.Errorf("%w: reading header: %w", ErrInvalidSQLiteFile, err)
+	}
+	if !validSQLiteData(header) {
+		return fmt.Errorf("%w: bad header", ErrInvalidSQLiteFile)
+	}
+
+	// The page size is a power of two between 512 and 65536, with 65536 stored as 1.
+	pageSize := int64(binary.BigEndian.Uint16(header[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return fmt.Errorf("%w: bad page size %d", ErrInvalidSQLiteFile, pageSize)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.Size()%pageSize != 0 {
+		return fmt.Errorf("%w: size %d is not a multiple of page size %d", ErrInvalidSQLiteFile, fi.Size(), pageSize)
+	}
+
+	return quickCheck(path)
+}
+
+// quickCheck runs "PRAGMA quick_check" on the database at path, if a SQLite
+// driver is registered.
+func quickCheck(path string) error {
+	drivers := sql.Drivers()
+	var driver string
+	for _, d := range []string{"sqlite3", "sqlite"} {
+		if slices.Contains(drivers, d) {
+			driver = d
+			break
+		}
+	}
+	if driver == "" {
+		return nil
+	}
+
+	db, err := sql.Open(driver, (&url.URL{Scheme: "file", Path: path, RawQuery: "mode=ro"}).String())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	var result string
+	if err := db.QueryRow("PRAGMA quick_check").Scan(&result); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSQLiteFile, err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("%w: quick_check: %s", ErrInvalidSQLiteFile, result)
+	}
+	return nil
+}
+
+// BackupToFile downloads a copy of the SQLite database from the node, writing it to
+// the file at path. The backup is written to a temporary file in the same directory,
+// which is synced and then renamed to path, so path is only replaced once the complete
+// backup has been written. opts may be nil, in which case default options are used.
+//
+// If opts.Compress is set, the backup is decompressed as it is written, unless
+// opts.KeepCompressed is also set. If opts.Verify is set, the file is checked with
+// VerifySQLiteFile before it is renamed, and left in place of path only if it is valid.
+func (c *Client) BackupToFile(ctx context.Context, path string, opts *BackupFileOptions) (retErr error) {
+	var o BackupFileOptions
+	if opts != nil {
+		o = *opts
+	}
+	rc, err := c.Backup(ctx, &o.BackupOptions)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	var r io.Reader = rc
+	if o.Compress && !o.KeepCompressed {
+		gz, err := gzip.NewReader(rc)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if retErr != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	if _, err := io.Copy(f, r); err != nil {
+		return contextError(ctx, err)
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if o.Verify && o.Format != "sql" && !(o.Compress && o.KeepCompressed) {
+		if err := VerifySQLiteFile(f.Name()); err != nil {
+			return err
+		}
+	}
+	return os.Rename(f.Name(), path)
+}
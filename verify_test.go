@@ -0,0 +1,120 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_VerifySQLiteFile(t *testing.T) {
+	if err := VerifySQLiteFile("testdata/simple.db"); err != nil {
+		t.Fatalf("unexpected error verifying valid file: %v", err)
+	}
+
+	valid, err := os.ReadFile("testdata/simple.db")
+	if err != nil {
+		t.Fatalf("failed to read test data: %v", err)
+	}
+	for _, tt := range []struct {
+		name string
+		data []byte
+	}{
+		{"text", []byte("CREATE TABLE foo (id INTEGER NOT NULL PRIMARY KEY, name TEXT)")},
+		{"header only", valid[:16]},
+		{"truncated", valid[:len(valid)-10]},
+		{"corrupt header", append([]byte("SQLite format 2\x00"), valid[16:]...)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "bad.db")
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatalf("failed to write file: %v", err)
+			}
+			if err := VerifySQLiteFile(path); !errors.Is(err, ErrInvalidSQLiteFile) {
+				t.Fatalf("expected ErrInvalidSQLiteFile, got %v", err)
+			}
+		})
+	}
+
+	if err := VerifySQLiteFile(filepath.Join(t.TempDir(), "missing.db")); err == nil {
+		t.Fatalf("expected error verifying missing file")
+	}
+}
+
+func Test_BackupToFile(t *testing.T) {
+	valid, err := os.ReadFile("testdata/simple.db")
+	if err != nil {
+		t.Fatalf("failed to read test data: %v", err)
+	}
+	data := valid
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/db/backup" {
+			t.Errorf("expected path /db/backup, got %s", r.URL.Path)
+		}
+		if !r.URL.Query().Has("compress") {
+			w.Write(data)
+			return
+		}
+		gz := gzip.NewWriter(w)
+		gz.Write(data)
+		gz.Close()
+	}))
+	defer server.Close()
+
+	cl, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from NewClient: %v", err)
+	}
+	defer cl.Close()
+
+	for _, tt := range []struct {
+		name string
+		opts *BackupFileOptions
+		gzip bool
+	}{
+		{"default", nil, false},
+		{"verified", &BackupFileOptions{Verify: true}, false},
+		{"decompressed", &BackupFileOptions{BackupOptions: BackupOptions{Compress: true}, Verify: true}, false},
+		{"kept compressed", &BackupFileOptions{BackupOptions: BackupOptions{Compress: true}, KeepCompressed: true, Verify: true}, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "backup.db")
+			if err := cl.BackupToFile(context.Background(), path, tt.opts); err != nil {
+				t.Fatalf("unexpected error from BackupToFile: %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read backup: %v", err)
+			}
+			if tt.gzip {
+				gz, err := gzip.NewReader(bytes.NewReader(got))
+				if err != nil {
+					t.Fatalf("expected gzip data: %v", err)
+				}
+				if got, err = io.ReadAll(gz); err != nil {
+					t.Fatalf("failed to decompress backup: %v", err)
+				}
+			}
+			if !bytes.Equal(valid, got) {
+				t.Fatalf("backup does not match database")
+			}
+		})
+	}
+
+	// An invalid backup is not written to path when verified.
+	data = valid[:len(valid)-10]
+	dir := t.TempDir()
+	path := filepath.Join(dir, "backup.db")
+	if err := cl.BackupToFile(context.Background(), path, &BackupFileOptions{Verify: true}); !errors.Is(err, ErrInvalidSQLiteFile) {
+		t.Fatalf("expected ErrInvalidSQLiteFile, got %v", err)
+	}
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 0 {
+		t.Fatalf("expected no files to be left, got %v (%v)", entries, err)
+	}
+}
@@ -0,0 +1,114 @@
+package http
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownVersion is returned by ParseVersion when the node did not report its version.
+var ErrUnknownVersion = errors.New("version unknown")
+
+// Version is a semantic version of rqlite, as returned by Client.Version.
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+
+	// PreRelease is the pre-release tag, such as "rc1", and is empty for a release.
+	PreRelease string
+}
+
+// ParseVersion parses a version of the form "vX.Y.Z", with an optional pre-release
+// tag such as "v8.36.0-rc1". The leading "v" is optional, and any build metadata
+// following a "+" is ignored. If s is "unknown", the value Client.Version returns
+// when the node does not report its version, ErrUnknownVersion is returned.
+func ParseVersion(s string) (Version, error) {
+	s = strings.TrimSpace(s)
+	if s == "unknown" {
+		return Version{}, ErrUnknownVersion
+	}
+	orig := s
+	s = strings.TrimPrefix(s, "v")
+	s, _, _ = strings.Cut(s, "+")
+
+	var v Version
+	s, v.PreRelease, _ = strings.Cut(s, "-")
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("invalid version %q", orig)
+	}
+	nums := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version %q", orig)
+		}
+		*nums[i] = n
+	}
+	return v, nil
+}
+
+// String returns the version in the form "vX.Y.Z", or "vX.Y.Z-tag" for a pre-release.
+func (v Version) String() string {
+	s := fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.PreRelease != "" {
+		s += "-" + v.PreRelease
+	}
+	return s
+}
+
+// Compare returns -1 if v is older than other, 1 if it is newer, and 0 if they are
+// the same version. A pre-release is older than the release with the same numbers,
+// and pre-release tags are ordered according to the semantic versioning rules.
+func (v Version) Compare(other Version) int {
+	if c := cmp.Compare(v.Major, other.Major); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Minor, other.Minor); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Patch, other.Patch); c != 0 {
+		return c
+	}
+	return comparePreRelease(v.PreRelease, other.PreRelease)
+}
+
+// AtLeast returns whether v is the release major.minor.patch, or newer. A pre-release
+// of major.minor.patch is not considered to be at least that version.
+func (v Version) AtLeast(major, minor, patch int) bool {
+	return v.Compare(Version{Major: major, Minor: minor, Patch: patch}) >= 0
+}
+
+func comparePreRelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	aIDs, bIDs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(aIDs) && i < len(bIDs); i++ {
+		an, aErr := strconv.Atoi(aIDs[i])
+		bn, bErr := strconv.Atoi(bIDs[i])
+		var c int
+		switch {
+		case aErr == nil && bErr == nil:
+			c = cmp.Compare(an, bn)
+		case aErr == nil:
+			c = -1
+		case bErr == nil:
+			c = 1
+		default:
+			c = strings.Compare(aIDs[i], bIDs[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(aIDs), len(bIDs))
+}
@@ -0,0 +1,92 @@
+package http
+
+import (
+	"testing"
+)
+
+func Test_ParseVersion(t *testing.T) {
+	for _, tt := range []struct {
+		in  string
+		exp Version
+	}{
+		{"8.36.3", Version{Major: 8, Minor: 36, Patch: 3}},
+		{"v8.36.3", Version{Major: 8, Minor: 36, Patch: 3}},
+		{"v9.0.0-rc1", Version{Major: 9, PreRelease: "rc1"}},
+		{"v9.0.0-beta.2+abc123", Version{Major: 9, PreRelease: "beta.2"}},
+	} {
+		got, err := ParseVersion(tt.in)
+		if err != nil {
+			t.Fatalf("unexpected error parsing %s: %v", tt.in, err)
+		}
+		if got != tt.exp {
+			t.Fatalf("parsing %s: expected %+v, got %+v", tt.in, tt.exp, got)
+		}
+	}
+
+	if _, err := ParseVersion("unknown"); err != ErrUnknownVersion {
+		t.Fatalf("expected ErrUnknownVersion, got %v", err)
+	}
+	for _, s := range []string{"", "v8", "v8.36", "v8.x.1", "v8.36.3.1", "v-1.0.0"} {
+		if _, err := ParseVersion(s); err == nil {
+			t.Fatalf("expected error parsing %q", s)
+		}
+	}
+}
+
+func Test_Version_Compare(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		exp  int
+	}{
+		{"v8.36.3", "v8.36.3", 0},
+		{"v8.36.3", "8.36.3", 0},
+		{"v8.36.3", "v8.36.4", -1},
+		{"v8.37.0", "v8.36.9", 1},
+		{"v9.0.0", "v8.99.99", 1},
+		{"v9.0.0-rc1", "v9.0.0", -1},
+		{"v9.0.0-rc1", "v8.36.3", 1},
+		{"v9.0.0-alpha", "v9.0.0-beta", -1},
+		{"v9.0.0-beta.2", "v9.0.0-beta.11", -1},
+		{"v9.0.0-beta", "v9.0.0-beta.1", -1},
+		{"v9.0.0-1", "v9.0.0-alpha", -1},
+	} {
+		a, err := ParseVersion(tt.a)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		b, err := ParseVersion(tt.b)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := a.Compare(b); got != tt.exp {
+			t.Fatalf("comparing %s to %s: expected %d, got %d", tt.a, tt.b, tt.exp, got)
+		}
+		if got := b.Compare(a); got != -tt.exp {
+			t.Fatalf("comparing %s to %s: expected %d, got %d", tt.b, tt.a, -tt.exp, got)
+		}
+	}
+}
+
+func Test_Version_AtLeast(t *testing.T) {
+	v, err := ParseVersion("v8.36.3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !v.AtLeast(8, 36, 3) || !v.AtLeast(8, 0, 0) || !v.AtLeast(7, 99, 99) {
+		t.Fatalf("expected %s to be at least older versions", v)
+	}
+	if v.AtLeast(8, 36, 4) || v.AtLeast(9, 0, 0) {
+		t.Fatalf("expected %s not to be at least newer versions", v)
+	}
+
+	rc, err := ParseVersion("v9.0.0-rc1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rc.AtLeast(9, 0, 0) {
+		t.Fatalf("expected %s not to be at least v9.0.0", rc)
+	}
+	if exp, got := "v9.0.0-rc1", rc.String(); exp != got {
+		t.Fatalf("expected %s, got %s", exp, got)
+	}
+}
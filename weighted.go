@@ -0,0 +1,176 @@
+package http
+
+import (
+	"math"
+	"math/rand/v2"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultWeightedDecay is the decay used by a WeightedBalancer if none is given.
+	defaultWeightedDecay = 10 * time.Second
+
+	// weightedFailureCost is the latency a WeightedBalancer adds to the cost of a host
+	// whose every recent request has failed.
+	weightedFailureCost = time.Second
+)
+
+// weightedHost tracks the recent performance of a single host.
+type weightedHost struct {
+	u *url.URL
+
+	// latency is the moving average of the host's latency, in nanoseconds.
+	latency   float64
+	latencyAt time.Time
+
+	// errRate is the moving average of the host's failures, between 0 and 1.
+	errRate   float64
+	errRateAt time.Time
+}
+
+// WeightedBalancer takes a list of addresses and prefers those which have recently
+// been responding quickly and without errors. This can be useful for clusters whose
+// nodes are spread across regions, where some nodes are much slower to reach than
+// others.
+//
+// Each call to Next() picks two hosts at random, and returns the one with the lower
+// cost, an approach known as the power of two choices. A host's cost is the moving
+// average of the latency of its requests, plus a penalty in proportion to its recent
+// error rate. The averages decay over time, so a host which has not been sent a request
+// for a while is tried again, and rejoins the rotation if it has recovered.
+//
+// The client reports the latency and outcome of each request to a WeightedBalancer, as
+// it implements LatencyRecorder and OutcomeRecorder.
+type WeightedBalancer struct {
+	decay time.Duration
+
+	mu    sync.Mutex
+	hosts []*weightedHost
+	now   func() time.Time
+}
+
+// NewWeightedBalancer returns a new WeightedBalancer. decay controls how quickly the
+// balancer forgets a host's past performance: the weight of an observation falls by a
+// factor of e every decay. If decay is 0, 10 seconds is used.
+func NewWeightedBalancer(urls []string, decay time.Duration) (*WeightedBalancer, error) {
+	if _, err := parseHosts(urls); err != nil {
+		return nil, err
+	}
+	if decay <= 0 {
+		decay = defaultWeightedDecay
+	}
+	wb := &WeightedBalancer{
+		decay: decay,
+		now:   time.Now,
+	}
+	for _, s := range urls {
+		u, _ := url.Parse(s)
+		wb.hosts = append(wb.hosts, &weightedHost{u: u})
+	}
+	return wb, nil
+}
+
+// Next returns the cheaper of two hosts chosen at random.
+func (wb *WeightedBalancer) Next() (*url.URL, error) {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+	n := len(wb.hosts)
+	if n == 0 {
+		return nil, ErrNoHostsAvailable
+	}
+	if n == 1 {
+		return wb.hosts[0].u, nil
+	}
+	i := rand.IntN(n)
+	j := rand.IntN(n - 1)
+	if j >= i {
+		j++
+	}
+	now := wb.now()
+	a, b := wb.hosts[i], wb.hosts[j]
+	if wb.cost(b, now) < wb.cost(a, now) {
+		return b.u, nil
+	}
+	return a.u, nil
+}
+
+// RecordLatency records that a request to u took d to complete.
+func (wb *WeightedBalancer) RecordLatency(u *url.URL, d time.Duration) {
+	wb.withHost(u, func(h *weightedHost, now time.Time) {
+		h.latency = wb.average(h.latency, float64(d), h.latencyAt, now)
+		h.latencyAt = now
+	})
+}
+
+// RecordSuccess records that a request to u succeeded.
+func (wb *WeightedBalancer) RecordSuccess(u *url.URL) {
+	wb.recordOutcome(u, 0)
+}
+
+// RecordFailure records that a request to u failed.
+func (wb *WeightedBalancer) RecordFailure(u *url.URL) {
+	wb.recordOutcome(u, 1)
+}
+
+// Latency returns the moving average of the latency of requests to u, decayed to the
+// present, or 0 if no requests to u have been recorded.
+func (wb *WeightedBalancer) Latency(u *url.URL) time.Duration {
+	var d time.Duration
+	wb.withHost(u, func(h *weightedHost, now time.Time) {
+		d = time.Duration(h.latency * wb.weight(h.latencyAt, now))
+	})
+	return d
+}
+
+// ErrorRate returns the moving average of the proportion of requests to u which
+// failed, decayed to the present, between 0 and 1.
+func (wb *WeightedBalancer) ErrorRate(u *url.URL) float64 {
+	var r float64
+	wb.withHost(u, func(h *weightedHost, now time.Time) {
+		r = h.errRate * wb.weight(h.errRateAt, now)
+	})
+	return r
+}
+
+func (wb *WeightedBalancer) recordOutcome(u *url.URL, failed float64) {
+	wb.withHost(u, func(h *weightedHost, now time.Time) {
+		h.errRate = wb.average(h.errRate, failed, h.errRateAt, now)
+		h.errRateAt = now
+	})
+}
+
+// withHost calls fn with the host whose address is u, if any, and the current time.
+func (wb *WeightedBalancer) withHost(u *url.URL, fn func(h *weightedHost, now time.Time)) {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+	key := u.String()
+	for _, h := range wb.hosts {
+		if h.u.String() == key {
+			fn(h, wb.now())
+			return
+		}
+	}
+}
+
+// cost returns the cost of sending a request to h.
+func (wb *WeightedBalancer) cost(h *weightedHost, now time.Time) float64 {
+	return h.latency*wb.weight(h.latencyAt, now) +
+		h.errRate*wb.weight(h.errRateAt, now)*float64(weightedFailureCost)
+}
+
+// average returns the moving average avg, last updated at, updated with sample at now.
+// The first sample, when at is zero, becomes the average.
+func (wb *WeightedBalancer) average(avg, sample float64, at, now time.Time) float64 {
+	w := wb.weight(at, now)
+	return avg*w + sample*(1-w)
+}
+
+// weight returns the weight remaining at now of an average last updated at.
+func (wb *WeightedBalancer) weight(at, now time.Time) float64 {
+	if at.IsZero() {
+		return 0
+	}
+	return math.Exp(-float64(now.Sub(at)) / float64(wb.decay))
+}
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_WeightedBalancer(t *testing.T) {
+	if _, err := NewWeightedBalancer(nil, 0); err != ErrNoHostsAvailable {
+		t.Fatalf("expected ErrNoHostsAvailable, got %v", err)
+	}
+	if _, err := NewWeightedBalancer([]string{"http://a:4001", "http://a:4001"}, 0); err != ErrDuplicateAddresses {
+		t.Fatalf("expected ErrDuplicateAddresses, got %v", err)
+	}
+
+	wb, err := NewWeightedBalancer([]string{"http://fast:4001", "http://slow:4001"}, 10*time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	now := time.Now()
+	wb.now = func() time.Time { return now }
+	fast, slow := mustParseURL("http://fast:4001"), mustParseURL("http://slow:4001")
+
+	wb.RecordLatency(fast, 10*time.Millisecond)
+	wb.RecordLatency(slow, 200*time.Millisecond)
+	if exp, got := 200*time.Millisecond, wb.Latency(slow); exp != got {
+		t.Fatalf("expected latency %s, got %s", exp, got)
+	}
+	for range 100 {
+		u, err := wb.Next()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if u.String() != fast.String() {
+			t.Fatalf("expected fast host, got %s", u)
+		}
+	}
+
+	// Failures make the fast host more costly than the slow one.
+	wb.RecordFailure(fast)
+	if exp, got := 1.0, wb.ErrorRate(fast); exp != got {
+		t.Fatalf("expected error rate %v, got %v", exp, got)
+	}
+	if u, _ := wb.Next(); u.String() != slow.String() {
+		t.Fatalf("expected slow host while fast host is failing, got %s", u)
+	}
+
+	// A success after a decay period brings the error rate down.
+	now = now.Add(10 * time.Second)
+	wb.RecordSuccess(fast)
+	if rate := wb.ErrorRate(fast); rate <= 0 || rate >= 0.5 {
+		t.Fatalf("expected error rate to fall below 0.5, got %v", rate)
+	}
+
+	// Latency samples are averaged over time.
+	wb.RecordLatency(slow, 100*time.Millisecond)
+	if got := wb.Latency(slow); got <= 100*time.Millisecond || got >= 200*time.Millisecond {
+		t.Fatalf("expected averaged latency between samples, got %s", got)
+	}
+}
+
+func Test_WeightedBalancer_Client(t *testing.T) {
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"results": []}`))
+	}))
+	defer fast.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	wb, err := NewWeightedBalancer([]string{fast.URL, failing.URL}, time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client, err := NewClientWithBalancer(wb, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	var failures int
+	for range 50 {
+		if _, err := client.QuerySingle(context.Background(), "SELECT 1"); err != nil {
+			failures++
+		}
+	}
+	if wb.Latency(mustParseURL(fast.URL)) == 0 {
+		t.Fatalf("expected latency of fast host to be recorded")
+	}
+	if failures > 2 {
+		t.Fatalf("expected failing host to be avoided, got %d failures", failures)
+	}
+}